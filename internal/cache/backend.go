@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/cache/v9"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrCacheMiss is returned by Backend.Get if the key is not cached.
+var ErrCacheMiss = cache.ErrCacheMiss
+
+// NoExpiration is the TTL of results that never expire.
+const NoExpiration time.Duration = -1
+
+// Backend stores the cached results of an RPCCache.
+type Backend interface {
+	Get(ctx context.Context, key string) (json.RawMessage, error)
+	// Set sets the key. Backends shared by several gateway instances may keep the existing entry instead, so that
+	// concurrent misses of the same key do not overwrite each other.
+	Set(ctx context.Context, key string, value json.RawMessage, ttl time.Duration) error
+	// Replace sets the key whether or not it is already set, e.g. to refresh an entry in place.
+	Replace(ctx context.Context, key string, value json.RawMessage, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	// DeleteFromLocalCache deletes the key from the gateway's memory only. Use for testing.
+	DeleteFromLocalCache(key string)
+}
+
+// redisBackend stores results in Redis, with a TinyLFU cache in front of it.
+type redisBackend struct {
+	cacheRead  *cache.Cache
+	cacheWrite *cache.Cache
+	redisWrite redis.UniversalClient // The client of cacheWrite.
+}
+
+func (b *redisBackend) Get(ctx context.Context, key string) (json.RawMessage, error) {
+	var result json.RawMessage
+
+	if err := b.cacheRead.Get(ctx, key, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Set only sets the key if it is not set yet.
+func (b *redisBackend) Set(ctx context.Context, key string, value json.RawMessage, ttl time.Duration) error {
+	return b.set(ctx, key, value, ttl, true)
+}
+
+func (b *redisBackend) Replace(ctx context.Context, key string, value json.RawMessage, ttl time.Duration) error {
+	return b.set(ctx, key, value, ttl, false)
+}
+
+func (b *redisBackend) set(ctx context.Context, key string, value json.RawMessage, ttl time.Duration, setNX bool) error {
+	if ttl != NoExpiration {
+		return b.cacheWrite.Set(&cache.Item{
+			Ctx:   ctx,
+			Key:   key,
+			Value: value,
+			SetNX: setNX,
+			TTL:   ttl,
+		})
+	}
+
+	// The go-redis/cache library only sets keys with a negative TTL in the local cache, so results that never expire
+	// are set in Redis directly, without an expiry.
+	valueBytes, err := b.cacheWrite.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if err := b.cacheWrite.Set(&cache.Item{Ctx: ctx, Key: key, Value: value, TTL: NoExpiration}); err != nil {
+		return err
+	}
+
+	if setNX {
+		return b.redisWrite.SetNX(ctx, key, valueBytes, 0).Err()
+	}
+
+	return b.redisWrite.Set(ctx, key, valueBytes, 0).Err()
+}
+
+// Delete deletes the key from Redis and the local cache. The local caches of other gateway instances may still
+// return it until it expires from them.
+func (b *redisBackend) Delete(ctx context.Context, key string) error {
+	return b.cacheWrite.Delete(ctx, key)
+}
+
+func (b *redisBackend) DeleteFromLocalCache(key string) {
+	b.cacheRead.DeleteFromLocalCache(key)
+	b.cacheWrite.DeleteFromLocalCache(key)
+}
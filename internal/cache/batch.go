@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+)
+
+// HandleBatchRequestParallel answers the cacheable sub-requests of a batch from the cache, and calls originFunc with
+// the remaining sub-requests, in their original order. Successful results of the cacheable sub-requests are then
+// cached. Sub-request IDs must be unique within the batch, since responses are matched to requests by ID.
+// revalidateFunc refreshes a sub-request's entry in its stale-while-revalidate window in the background, as for single
+// requests, and may be nil if entries are not refreshed.
+//
+// Returns the responses in the order of the requests, followed by any response from origin that does not match a
+// request, and whether every response came from the cache.
+func (c *RPCCache) HandleBatchRequestParallel(
+	chainName string,
+	reqBodies []jsonrpc.SingleRequestBody,
+	originFunc func([]jsonrpc.SingleRequestBody) ([]jsonrpc.SingleResponseBody, error),
+	revalidateFunc func(jsonrpc.SingleRequestBody) (*jsonrpc.SingleResponseBody, error),
+) ([]jsonrpc.SingleResponseBody, bool, error) {
+	ctx := context.Background()
+
+	cachedResults := make(map[int]json.RawMessage)
+	pendingEntries := make(map[int64]pendingEntry)
+	negativeEntries := make(map[int64]negativeEntry)
+
+	var originReqBodies []jsonrpc.SingleRequestBody
+
+	for i := range reqBodies {
+		reqBody := &reqBodies[i]
+
+		if reqBody.ID == nil || !c.ShouldCacheMethod(reqBody.Method) {
+			originReqBodies = append(originReqBodies, *reqBody)
+			continue
+		}
+
+		key := CreateRequestKey(chainName, *reqBody)
+		entry, err := c.getEntry(ctx, key, reqBody.Method)
+
+		if now := time.Now(); err == nil && entry.isServed(now) {
+			if revalidateFunc != nil && !entry.isFresh(now) {
+				subRequest := *reqBody
+				c.revalidateAsync(key, subRequest, func() (*jsonrpc.SingleResponseBody, error) {
+					return revalidateFunc(subRequest)
+				})
+			}
+
+			cachedResults[i] = entry.Result
+
+			continue
+		}
+
+		hasStaleEntry := err == nil
+
+		if entry, ok := c.getNegativeEntry(key, reqBody); ok {
+			if c.isNegativelyCached(ctx, entry, reqBody.Method) {
+				cachedResults[i] = nullResult
+				continue
+			}
+
+			negativeEntries[*reqBody.ID] = entry
+		}
+
+		// Decide the TTL before the request to origin, as for single requests.
+		ttl := finalityTTL{ttl: c.cacheConfig.GetTTLForMethod(reqBody.Method)}
+		if c.finality != nil {
+			ttl = c.finality.getTTL(reqBody, ttl.ttl)
+		}
+
+		pendingEntries[*reqBody.ID] = pendingEntry{key: key, ttl: ttl, replace: hasStaleEntry}
+		originReqBodies = append(originReqBodies, *reqBody)
+	}
+
+	var originRespBodies []jsonrpc.SingleResponseBody
+
+	if len(originReqBodies) > 0 {
+		var err error
+
+		originRespBodies, err = originFunc(originReqBodies)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	originRespBodyIndexByID := make(map[int64]int, len(originRespBodies))
+	for i, respBody := range originRespBodies {
+		originRespBodyIndexByID[respBody.ID] = i
+	}
+
+	respBodies := make([]jsonrpc.SingleResponseBody, 0, len(reqBodies))
+	isMatchedOriginRespBody := make([]bool, len(originRespBodies))
+
+	for i, reqBody := range reqBodies {
+		if result, ok := cachedResults[i]; ok {
+			respBodies = append(respBodies, jsonrpc.SingleResponseBody{
+				ID:      *reqBody.ID,
+				JSONRPC: reqBody.JSONRPCVersion,
+				Result:  result,
+			})
+
+			continue
+		}
+
+		// Notifications have no response.
+		if reqBody.ID == nil {
+			continue
+		}
+
+		originIndex, ok := originRespBodyIndexByID[*reqBody.ID]
+		if !ok || isMatchedOriginRespBody[originIndex] {
+			continue
+		}
+
+		isMatchedOriginRespBody[originIndex] = true
+		respBody := originRespBodies[originIndex]
+		respBodies = append(respBodies, respBody)
+
+		if entry, ok := pendingEntries[*reqBody.ID]; ok && isCacheableResponse(&respBody) {
+			c.setAsync(ctx, entry, reqBody.Method, respBody.Result)
+		}
+
+		if entry, ok := negativeEntries[*reqBody.ID]; ok && respBody.Error == nil && isNullResult(respBody.Result) {
+			c.setNegativeAsync(ctx, entry, reqBody.Method)
+		}
+	}
+
+	for i, respBody := range originRespBodies {
+		if !isMatchedOriginRespBody[i] {
+			respBodies = append(respBodies, respBody)
+		}
+	}
+
+	return respBodies, len(originReqBodies) == 0, nil
+}
+
+// isCacheableResponse returns whether the response has a result worth caching. Errors and null results are not
+// cached, as for single requests.
+func isCacheableResponse(respBody *jsonrpc.SingleResponseBody) bool {
+	return respBody.Error == nil && len(respBody.Result) > 0 && !isNullResult(respBody.Result)
+}
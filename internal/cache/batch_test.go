@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBatchCache() *RPCCache {
+	cacheConfig := config.ChainCacheConfig{
+		MethodTTLs: map[string]time.Duration{"eth_getTransactionReceipt": time.Minute},
+	}
+
+	return FromBackend(cacheConfig, NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+}
+
+func newTestSubRequest(id int64, method string, params ...any) jsonrpc.SingleRequestBody {
+	return jsonrpc.SingleRequestBody{ID: lo.ToPtr(id), JSONRPCVersion: "2.0", Method: method, Params: params}
+}
+
+func TestHandleBatchRequestParallel(t *testing.T) {
+	cache := newTestBatchCache()
+
+	cachedRequest := newTestSubRequest(3, "eth_getTransactionReceipt", "0x01")
+	assert.NoError(t, cache.backend.Set(context.Background(), CreateRequestKey("mainnet", cachedRequest), json.RawMessage(`{"result":"cached"}`), time.Minute))
+
+	reqBodies := []jsonrpc.SingleRequestBody{
+		newTestSubRequest(7, "eth_blockNumber"),
+		cachedRequest,
+		newTestSubRequest(1, "eth_getTransactionReceipt", "0x02"),
+		{JSONRPCVersion: "2.0", Method: "eth_getTransactionReceipt", Params: []any{"0x03"}}, // Notification.
+	}
+
+	var originReqBodies []jsonrpc.SingleRequestBody
+
+	originFunc := func(reqBodies []jsonrpc.SingleRequestBody) ([]jsonrpc.SingleResponseBody, error) {
+		originReqBodies = reqBodies
+
+		// Upstreams may respond in any order.
+		return []jsonrpc.SingleResponseBody{
+			{ID: 1, JSONRPC: "2.0", Result: json.RawMessage(`"receipt"`)},
+			{ID: 7, JSONRPC: "2.0", Result: json.RawMessage(`"0x10"`)},
+		}, nil
+	}
+
+	respBodies, cached, err := cache.HandleBatchRequestParallel("mainnet", reqBodies, originFunc, nil)
+
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	// Only the misses are forwarded, in their original order.
+	assert.Equal(t, []jsonrpc.SingleRequestBody{reqBodies[0], reqBodies[2], reqBodies[3]}, originReqBodies)
+	// Responses follow the order of the requests.
+	assert.Equal(t, []jsonrpc.SingleResponseBody{
+		{ID: 7, JSONRPC: "2.0", Result: json.RawMessage(`"0x10"`)},
+		{ID: 3, JSONRPC: "2.0", Result: json.RawMessage(`"cached"`)},
+		{ID: 1, JSONRPC: "2.0", Result: json.RawMessage(`"receipt"`)},
+	}, respBodies)
+
+	// The miss is cached asynchronously.
+	assert.Eventually(t, func() bool {
+		entry, err := cache.getEntry(context.Background(), CreateRequestKey("mainnet", reqBodies[2]), reqBodies[2].Method)
+		return err == nil && string(entry.Result) == `"receipt"`
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestHandleBatchRequestParallel_AllCached(t *testing.T) {
+	cache := newTestBatchCache()
+
+	reqBodies := []jsonrpc.SingleRequestBody{
+		newTestSubRequest(1, "eth_getTransactionReceipt", "0x01"),
+		newTestSubRequest(2, "eth_getTransactionReceipt", "0x02"),
+	}
+
+	for _, reqBody := range reqBodies {
+		assert.NoError(t, cache.backend.Set(context.Background(), CreateRequestKey("mainnet", reqBody), json.RawMessage(`{"result":"cached"}`), time.Minute))
+	}
+
+	originFunc := func([]jsonrpc.SingleRequestBody) ([]jsonrpc.SingleResponseBody, error) {
+		t.Fatal("origin must not be called when every sub-request is cached")
+		return nil, nil
+	}
+
+	respBodies, cached, err := cache.HandleBatchRequestParallel("mainnet", reqBodies, originFunc, nil)
+
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, []int64{1, 2}, lo.Map(respBodies, func(respBody jsonrpc.SingleResponseBody, _ int) int64 { return respBody.ID }))
+}
+
+func TestHandleBatchRequestParallel_ErrorsAreNotCached(t *testing.T) {
+	cache := newTestBatchCache()
+
+	reqBodies := []jsonrpc.SingleRequestBody{
+		newTestSubRequest(1, "eth_getTransactionReceipt", "0x01"),
+		newTestSubRequest(2, "eth_getTransactionReceipt", "0x02"),
+	}
+
+	originFunc := func([]jsonrpc.SingleRequestBody) ([]jsonrpc.SingleResponseBody, error) {
+		return []jsonrpc.SingleResponseBody{
+			{ID: 1, JSONRPC: "2.0", Error: &jsonrpc.Error{Code: -32000, Message: "error"}},
+			{ID: 2, JSONRPC: "2.0", Result: json.RawMessage("null")},
+		}, nil
+	}
+
+	respBodies, _, err := cache.HandleBatchRequestParallel("mainnet", reqBodies, originFunc, nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, respBodies, 2)
+
+	time.Sleep(10 * time.Millisecond)
+
+	for _, reqBody := range reqBodies {
+		_, err := cache.backend.Get(context.Background(), CreateRequestKey("mainnet", reqBody))
+		assert.ErrorIs(t, err, ErrCacheMiss)
+	}
+}
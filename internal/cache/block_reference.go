@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
+)
+
+// The position of the block parameter of the methods whose result depends on a single block. The parameter is either
+// a block number, a block tag, a block hash, or an EIP-1898 object with a blockNumber or blockHash.
+var blockParamIndexByMethod = map[string]int{
+	"eth_getBlockByNumber":                    0,
+	"eth_getBlockByHash":                      0,
+	"eth_getBlockTransactionCountByNumber":    0,
+	"eth_getBlockTransactionCountByHash":      0,
+	"eth_getTransactionByBlockNumberAndIndex": 0,
+	"eth_getTransactionByBlockHashAndIndex":   0,
+	"eth_getUncleByBlockNumberAndIndex":       0,
+	"eth_getUncleByBlockHashAndIndex":         0,
+	"eth_getUncleCountByBlockNumber":          0,
+	"eth_getUncleCountByBlockHash":            0,
+	"eth_getBlockReceipts":                    0,
+	"eth_getBalance":                          1,
+	"eth_getCode":                             1,
+	"eth_getTransactionCount":                 1,
+	"eth_call":                                1,
+	"eth_getStorageAt":                        2,
+	"eth_getProof":                            2,
+	"debug_traceBlockByNumber":                0,
+	"debug_traceBlockByHash":                  0,
+	"debug_traceCall":                         1,
+	"trace_block":                             0,
+	"trace_replayBlockTransactions":           0,
+	"trace_call":                              2,
+}
+
+// blockReference is the block a request's result depends on.
+type blockReference struct {
+	tag         metadata.BlockTag // Empty unless the request references the block by tag.
+	blockHeight uint64
+	isBlockHash bool
+}
+
+// getBlockReference returns the block the request's result depends on, or false if it is not known, e.g. because the
+// result depends on a transaction rather than a block.
+func getBlockReference(requestBody *jsonrpc.SingleRequestBody) (blockReference, bool) {
+	if requestBody.Method == "eth_getLogs" {
+		return getLogsBlockReference(requestBody.Params)
+	}
+
+	paramIndex, ok := blockParamIndexByMethod[requestBody.Method]
+	if !ok {
+		return blockReference{}, false
+	}
+
+	// An omitted block parameter defaults to the latest block.
+	if paramIndex >= len(requestBody.Params) || requestBody.Params[paramIndex] == nil {
+		return blockReference{tag: metadata.LatestBlockTag}, true
+	}
+
+	return parseBlockParam(requestBody.Params[paramIndex])
+}
+
+// getLogsBlockReference returns the last block of an eth_getLogs filter, since the results of a range of blocks
+// become final when its last block does.
+func getLogsBlockReference(params []any) (blockReference, bool) {
+	if len(params) != 1 {
+		return blockReference{}, false
+	}
+
+	filter, ok := params[0].(map[string]any)
+	if !ok {
+		return blockReference{}, false
+	}
+
+	if _, ok := filter["blockHash"]; ok {
+		return blockReference{isBlockHash: true}, true
+	}
+
+	toBlock, ok := filter["toBlock"]
+	if !ok || toBlock == nil {
+		return blockReference{tag: metadata.LatestBlockTag}, true
+	}
+
+	return parseBlockParam(toBlock)
+}
+
+func parseBlockParam(param any) (blockReference, bool) {
+	switch value := param.(type) {
+	case string:
+		if len(value) == 66 && strings.HasPrefix(value, "0x") {
+			return blockReference{isBlockHash: true}, true
+		}
+
+		switch tag := metadata.BlockTag(value); tag {
+		case metadata.EarliestBlockTag:
+			return blockReference{blockHeight: 0}, true
+		case metadata.LatestBlockTag, metadata.PendingBlockTag, metadata.SafeBlockTag, metadata.FinalizedBlockTag:
+			return blockReference{tag: tag}, true
+		}
+
+		blockHeight, err := hexutil.DecodeUint64(value)
+		if err != nil {
+			return blockReference{}, false
+		}
+
+		return blockReference{blockHeight: blockHeight}, true
+	case map[string]any:
+		if _, ok := value["blockHash"]; ok {
+			return blockReference{isBlockHash: true}, true
+		}
+
+		if blockNumber, ok := value["blockNumber"]; ok {
+			return parseBlockParam(blockNumber)
+		}
+	}
+
+	return blockReference{}, false
+}
@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
+	"go.uber.org/zap"
+)
+
+// Expired keys are removed from the reorg index at most this often.
+const reorgIndexPruneInterval = time.Minute
+
+// finalityTracker decides the TTL of each request from the finality of the block it references, and deletes the
+// cached results of reorged blocks.
+type finalityTracker struct {
+	config             *config.CacheFinalityConfig
+	chainMetadataStore *metadata.ChainMetadataStore
+	reorgIndex         *reorgIndex
+}
+
+// finalityTTL is the TTL of a request's result, along with the block it is invalidated by the reorg of, if any.
+type finalityTTL struct {
+	ttl         time.Duration
+	blockHeight uint64
+	isReorgable bool
+}
+
+// getTTL returns the TTL of the request's result. Results that do not depend on a single known block get the
+// method's TTL.
+func (f *finalityTracker) getTTL(requestBody *jsonrpc.SingleRequestBody, methodTTL time.Duration) finalityTTL {
+	reference, ok := getBlockReference(requestBody)
+	if !ok {
+		return finalityTTL{ttl: methodTTL}
+	}
+
+	finalizedTTL := f.config.FinalizedTTL
+	if finalizedTTL == 0 {
+		finalizedTTL = NoExpiration
+	}
+
+	if reference.isBlockHash {
+		return finalityTTL{ttl: finalizedTTL}
+	}
+
+	headBlockHeight, finalizedBlockHeight := f.chainMetadataStore.GetGlobalMaxBlockHeights()
+
+	blockHeight := reference.blockHeight
+	if reference.tag != "" {
+		// The block a tag refers to changes as the chain advances, so the result is never final.
+		blockHeight = headBlockHeight
+	} else if f.isFinal(blockHeight, headBlockHeight, finalizedBlockHeight) {
+		return finalityTTL{ttl: finalizedTTL}
+	}
+
+	ttl := methodTTL
+	if f.config.HeadTTL > 0 {
+		ttl = f.config.HeadTTL
+	}
+
+	return finalityTTL{ttl: ttl, blockHeight: blockHeight, isReorgable: true}
+}
+
+func (f *finalityTracker) isFinal(blockHeight, headBlockHeight, finalizedBlockHeight uint64) bool {
+	if blockHeight == 0 {
+		return true
+	}
+
+	if finalizedBlockHeight > 0 && blockHeight <= finalizedBlockHeight {
+		return true
+	}
+
+	return f.config.Confirmations > 0 && blockHeight+f.config.Confirmations <= headBlockHeight
+}
+
+// reorgIndex tracks the cached keys of the results that depend on a block that is not final, by block height.
+type reorgIndex struct {
+	prunedAt               time.Time
+	keysByBlockHeight      map[uint64][]string
+	expiresAtByBlockHeight map[uint64]time.Time
+	lock                   sync.Mutex
+}
+
+func newReorgIndex() *reorgIndex {
+	return &reorgIndex{
+		prunedAt:               time.Now(),
+		keysByBlockHeight:      make(map[uint64][]string),
+		expiresAtByBlockHeight: make(map[uint64]time.Time),
+	}
+}
+
+func (r *reorgIndex) add(blockHeight uint64, key string, ttl time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	r.keysByBlockHeight[blockHeight] = append(r.keysByBlockHeight[blockHeight], key)
+
+	if expiresAt := now.Add(ttl); expiresAt.After(r.expiresAtByBlockHeight[blockHeight]) {
+		r.expiresAtByBlockHeight[blockHeight] = expiresAt
+	}
+
+	// The keys of a block height are dropped once all of them have expired, since there is nothing left to delete.
+	if now.Sub(r.prunedAt) >= reorgIndexPruneInterval {
+		r.prunedAt = now
+
+		for height, expiresAt := range r.expiresAtByBlockHeight {
+			if now.After(expiresAt) {
+				delete(r.keysByBlockHeight, height)
+				delete(r.expiresAtByBlockHeight, height)
+			}
+		}
+	}
+}
+
+// removeFrom removes and returns the keys of the given block height and above.
+func (r *reorgIndex) removeFrom(blockHeight uint64) []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var keys []string
+
+	for height, heightKeys := range r.keysByBlockHeight {
+		if height >= blockHeight {
+			keys = append(keys, heightKeys...)
+
+			delete(r.keysByBlockHeight, height)
+			delete(r.expiresAtByBlockHeight, height)
+		}
+	}
+
+	return keys
+}
+
+// UseChainMetadataStore gives the cache the chain's current block heights. If enabled in the cache config, the TTL of
+// each request then depends on the finality of the block it references, the cached results of reorged blocks are
+// deleted, and "latest" block params are resolved to the current head. Must be called before the store is started.
+func (c *RPCCache) UseChainMetadataStore(chainMetadataStore *metadata.ChainMetadataStore) {
+	c.chainMetadataStore = chainMetadataStore
+
+	if c.cacheConfig.Finality == nil {
+		return
+	}
+
+	c.finality = &finalityTracker{
+		config:             c.cacheConfig.Finality,
+		chainMetadataStore: chainMetadataStore,
+		reorgIndex:         newReorgIndex(),
+	}
+
+	chainMetadataStore.OnReorg(c.invalidateFrom)
+}
+
+// invalidateFrom deletes the cached results that depend on the given block height or above.
+func (c *RPCCache) invalidateFrom(blockHeight uint64) {
+	keys := c.finality.reorgIndex.removeFrom(blockHeight)
+
+	zap.L().Info("invalidating cached results of reorged blocks", zap.Uint64("blockHeight", blockHeight), zap.Int("keys", len(keys)))
+
+	for _, key := range keys {
+		if err := c.backend.Delete(context.Background(), key); err != nil {
+			c.metricsContainer.CacheErrors.WithLabelValues("delete").Inc()
+			zap.L().Error("cache_delete error", zap.Error(err), zap.String("key", key))
+
+			continue
+		}
+
+		c.metricsContainer.CacheInvalidations.WithLabelValues().Inc()
+	}
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+const testBlockHash = "0x3a6f67beb73d07b1dd10c12de79767b6009f7b351ba1fe6282040aa6c57afef1"
+
+func TestGetBlockReference(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		method   string
+		params   []any
+		expected blockReference
+		ok       bool
+	}{
+		{"Block number", "eth_getBlockByNumber", []any{"0x100", false}, blockReference{blockHeight: 256}, true},
+		{"Block tag", "eth_getBlockByNumber", []any{"latest", false}, blockReference{tag: metadata.LatestBlockTag}, true},
+		{"Earliest", "eth_getBalance", []any{"0x0", "earliest"}, blockReference{blockHeight: 0}, true},
+		{"Block hash", "eth_getBlockByHash", []any{testBlockHash, false}, blockReference{isBlockHash: true}, true},
+		{"Omitted block", "eth_call", []any{map[string]any{"to": "0x0"}}, blockReference{tag: metadata.LatestBlockTag}, true},
+		{"EIP-1898 block hash", "eth_call", []any{map[string]any{}, map[string]any{"blockHash": testBlockHash}}, blockReference{isBlockHash: true}, true},
+		{"EIP-1898 block number", "eth_getStorageAt", []any{"0x0", "0x0", map[string]any{"blockNumber": "0x10"}}, blockReference{blockHeight: 16}, true},
+		{"Logs range", "eth_getLogs", []any{map[string]any{"fromBlock": "0x1", "toBlock": "0x2"}}, blockReference{blockHeight: 2}, true},
+		{"Logs to latest", "eth_getLogs", []any{map[string]any{"fromBlock": "0x1"}}, blockReference{tag: metadata.LatestBlockTag}, true},
+		{"Logs block hash", "eth_getLogs", []any{map[string]any{"blockHash": testBlockHash}}, blockReference{isBlockHash: true}, true},
+		{"Transaction hash", "eth_getTransactionReceipt", []any{testBlockHash}, blockReference{}, false},
+		{"Invalid block number", "eth_getBlockByNumber", []any{"0xzz", false}, blockReference{}, false},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			reference, ok := getBlockReference(&jsonrpc.SingleRequestBody{Method: testCase.method, Params: testCase.params})
+
+			assert.Equal(t, testCase.ok, ok)
+			assert.Equal(t, testCase.expected, reference)
+		})
+	}
+}
+
+func newTestFinalityCache(t *testing.T, finalityConfig *config.CacheFinalityConfig) (*RPCCache, *metadata.ChainMetadataStore) {
+	t.Helper()
+
+	cacheConfig := config.ChainCacheConfig{TTL: time.Minute, Finality: finalityConfig}
+	cache := FromBackend(cacheConfig, NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	cache.UseChainMetadataStore(chainMetadataStore)
+	chainMetadataStore.Start()
+
+	chainMetadataStore.ProcessBlockHeightUpdate("group1", "upstream1", 1000)
+	chainMetadataStore.ProcessFinalizedBlockHeightUpdate("group1", "upstream1", 900)
+	assert.Eventually(t, func() bool {
+		blockHeight, finalizedBlockHeight := chainMetadataStore.GetGlobalMaxBlockHeights()
+		return blockHeight == 1000 && finalizedBlockHeight == 900
+	}, time.Second, time.Millisecond)
+
+	return cache, chainMetadataStore
+}
+
+func TestFinalityTracker_GetTTL(t *testing.T) {
+	cache, _ := newTestFinalityCache(t, &config.CacheFinalityConfig{Confirmations: 50, HeadTTL: 2 * time.Second})
+
+	for _, testCase := range []struct {
+		name     string
+		method   string
+		params   []any
+		expected finalityTTL
+	}{
+		{"Block hash", "eth_getBlockByHash", []any{testBlockHash, false}, finalityTTL{ttl: NoExpiration}},
+		{"Finalized block", "eth_getBlockByNumber", []any{"0x384", false}, finalityTTL{ttl: NoExpiration}},
+		{"Confirmed block", "eth_getBlockByNumber", []any{"0x3b6", false}, finalityTTL{ttl: NoExpiration}},
+		{"Block near the head", "eth_getBlockByNumber", []any{"0x3e0", false}, finalityTTL{ttl: 2 * time.Second, blockHeight: 992, isReorgable: true}},
+		{"Latest block", "eth_getBalance", []any{"0x0", "latest"}, finalityTTL{ttl: 2 * time.Second, blockHeight: 1000, isReorgable: true}},
+		{"No block", "eth_getTransactionReceipt", []any{testBlockHash}, finalityTTL{ttl: time.Minute}},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			ttl := cache.finality.getTTL(&jsonrpc.SingleRequestBody{Method: testCase.method, Params: testCase.params}, time.Minute)
+			assert.Equal(t, testCase.expected, ttl)
+		})
+	}
+
+	// Without a confirmation depth, only finalized blocks are final.
+	cache.finality.config = &config.CacheFinalityConfig{FinalizedTTL: time.Hour}
+	assert.Equal(t, finalityTTL{ttl: time.Hour}, cache.finality.getTTL(&jsonrpc.SingleRequestBody{Method: "eth_getBlockByNumber", Params: []any{"0x384"}}, time.Minute))
+	assert.Equal(t, finalityTTL{ttl: time.Minute, blockHeight: 950, isReorgable: true}, cache.finality.getTTL(&jsonrpc.SingleRequestBody{Method: "eth_getBlockByNumber", Params: []any{"0x3b6"}}, time.Minute))
+}
+
+func TestRPCCache_ReorgInvalidation(t *testing.T) {
+	cache, chainMetadataStore := newTestFinalityCache(t, &config.CacheFinalityConfig{})
+
+	originFunc := func() (*jsonrpc.SingleResponseBody, error) {
+		return &jsonrpc.SingleResponseBody{Result: json.RawMessage(`{"number":"0x3e8"}`)}, nil
+	}
+
+	headRequest := jsonrpc.SingleRequestBody{Method: "eth_getBlockByNumber", Params: []any{"0x3e8", false}}
+	finalizedRequest := jsonrpc.SingleRequestBody{Method: "eth_getBlockByNumber", Params: []any{"0x384", false}}
+
+	for _, request := range []jsonrpc.SingleRequestBody{headRequest, finalizedRequest} {
+		_, cached, err := cache.HandleRequestParallel("mainnet", request, originFunc, nil)
+		assert.NoError(t, err)
+		assert.False(t, cached)
+	}
+
+	// Allow the async cache sets to complete.
+	time.Sleep(5 * time.Millisecond)
+
+	header := &ethTypes.Header{Number: big.NewInt(1000), ParentHash: common.Hash{0xa}}
+	chainMetadataStore.ProcessBlockHeaderUpdate("group1", "upstream1", header)
+
+	reorgedHeader := &ethTypes.Header{Number: big.NewInt(1000), ParentHash: common.Hash{0xa}, Root: common.Hash{0xb}}
+	chainMetadataStore.ProcessBlockHeaderUpdate("group1", "upstream2", reorgedHeader)
+
+	// The listener deletes the keys asynchronously.
+	assert.Eventually(t, func() bool {
+		_, err := cache.backend.Get(context.Background(), CreateRequestKey("mainnet", headRequest))
+		return err == ErrCacheMiss
+	}, time.Second, 5*time.Millisecond)
+
+	_, err := cache.backend.Get(context.Background(), CreateRequestKey("mainnet", finalizedRequest))
+	assert.NoError(t, err)
+}
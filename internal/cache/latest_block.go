@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"maps"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
+)
+
+// ResolveLatestBlock returns a copy of the request with its "latest" block params, including omitted ones, replaced by
+// the chain's current head. Identical requests within the same block then share a cache entry, and the response is
+// consistent with the block it was evaluated at. The head is capped at maxBlockHeight unless it is zero, e.g. at the
+// head of the upstream the request is routed to, which may be a few blocks behind the chain's head and would fail to
+// serve a block it does not have yet. Returns the request itself if resolving is not enabled in the cache config, the
+// method does not reference a block, or the head is not known yet.
+func (c *RPCCache) ResolveLatestBlock(requestBody *jsonrpc.SingleRequestBody, maxBlockHeight uint64) *jsonrpc.SingleRequestBody {
+	if !c.cacheConfig.ResolveLatest || c.chainMetadataStore == nil {
+		return requestBody
+	}
+
+	headBlockHeight, _ := c.chainMetadataStore.GetGlobalMaxBlockHeights()
+	if maxBlockHeight > 0 {
+		headBlockHeight = min(headBlockHeight, maxBlockHeight)
+	}
+
+	if headBlockHeight == 0 {
+		return requestBody
+	}
+
+	params, ok := resolveLatestParams(requestBody.Method, requestBody.Params, hexutil.EncodeUint64(headBlockHeight))
+	if !ok {
+		return requestBody
+	}
+
+	resolved := *requestBody
+	resolved.Params = params
+
+	return &resolved
+}
+
+// resolveLatestParams returns a copy of the params with their "latest" block params replaced by the given block
+// number, or false if there is nothing to replace. The original params are left untouched.
+func resolveLatestParams(method string, params []any, blockNumber string) ([]any, bool) {
+	if method == "eth_getLogs" {
+		return resolveLatestLogsFilter(params, blockNumber)
+	}
+
+	paramIndex, ok := blockParamIndexByMethod[method]
+	if !ok || paramIndex > len(params) {
+		return nil, false
+	}
+
+	resolved := make([]any, len(params), max(len(params), paramIndex+1))
+	copy(resolved, params)
+
+	if paramIndex == len(params) {
+		// An omitted trailing block param defaults to the latest block.
+		return append(resolved, blockNumber), true
+	}
+
+	switch param := params[paramIndex].(type) {
+	case nil:
+		resolved[paramIndex] = blockNumber
+	case string:
+		if !isLatestTag(param) {
+			return nil, false
+		}
+
+		resolved[paramIndex] = blockNumber
+	case map[string]any:
+		// EIP-1898 block params.
+		if !isLatestTag(param["blockNumber"]) {
+			return nil, false
+		}
+
+		blockParam := maps.Clone(param)
+		blockParam["blockNumber"] = blockNumber
+		resolved[paramIndex] = blockParam
+	default:
+		return nil, false
+	}
+
+	return resolved, true
+}
+
+func resolveLatestLogsFilter(params []any, blockNumber string) ([]any, bool) {
+	if len(params) != 1 {
+		return nil, false
+	}
+
+	filter, ok := params[0].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	if _, ok := filter["blockHash"]; ok {
+		return nil, false
+	}
+
+	resolvedFilter := maps.Clone(filter)
+	isResolved := false
+
+	// Omitted fromBlock and toBlock default to the latest block.
+	for _, field := range []string{"fromBlock", "toBlock"} {
+		if value := filter[field]; value == nil || isLatestTag(value) {
+			resolvedFilter[field] = blockNumber
+			isResolved = true
+		}
+	}
+
+	if !isResolved {
+		return nil, false
+	}
+
+	return []any{resolvedFilter}, true
+}
+
+func isLatestTag(param any) bool {
+	tag, ok := param.(string)
+	return ok && metadata.BlockTag(tag) == metadata.LatestBlockTag
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestResolveLatestCache(t *testing.T, resolveLatest bool, headBlockHeight uint64) *RPCCache {
+	t.Helper()
+
+	cacheConfig := config.ChainCacheConfig{TTL: time.Minute, ResolveLatest: resolveLatest}
+	cache := FromBackend(cacheConfig, NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	cache.UseChainMetadataStore(chainMetadataStore)
+	chainMetadataStore.Start()
+
+	if headBlockHeight > 0 {
+		chainMetadataStore.ProcessBlockHeightUpdate("group1", "upstream1", headBlockHeight)
+		assert.Eventually(t, func() bool {
+			maxBlockHeight, _ := chainMetadataStore.GetGlobalMaxBlockHeights()
+			return maxBlockHeight == headBlockHeight
+		}, time.Second, time.Millisecond)
+	}
+
+	return cache
+}
+
+func TestRPCCache_ResolveLatestBlock(t *testing.T) {
+	cache := newTestResolveLatestCache(t, true, 0x100)
+
+	for _, testCase := range []struct {
+		testName string
+		method   string
+		params   []any
+		expected []any
+	}{
+		{"Latest tag.", "eth_getBalance", []any{"0x01", "latest"}, []any{"0x01", "0x100"}},
+		{"Omitted block param.", "eth_call", []any{map[string]any{"to": "0x01"}}, []any{map[string]any{"to": "0x01"}, "0x100"}},
+		{"Null block param.", "eth_getCode", []any{"0x01", nil}, []any{"0x01", "0x100"}},
+		{
+			"EIP-1898 latest block number.",
+			"eth_getStorageAt",
+			[]any{"0x01", "0x0", map[string]any{"blockNumber": "latest"}},
+			[]any{"0x01", "0x0", map[string]any{"blockNumber": "0x100"}},
+		},
+		{
+			"Logs with omitted range.",
+			"eth_getLogs",
+			[]any{map[string]any{"address": "0x01"}},
+			[]any{map[string]any{"address": "0x01", "fromBlock": "0x100", "toBlock": "0x100"}},
+		},
+		{
+			"Logs with latest toBlock.",
+			"eth_getLogs",
+			[]any{map[string]any{"fromBlock": "0xf0", "toBlock": "latest"}},
+			[]any{map[string]any{"fromBlock": "0xf0", "toBlock": "0x100"}},
+		},
+		{"Block number.", "eth_getBalance", []any{"0x01", "0xff"}, []any{"0x01", "0xff"}},
+		{"Other tag.", "eth_getBalance", []any{"0x01", "pending"}, []any{"0x01", "pending"}},
+		{"Block hash.", "eth_getBlockByHash", []any{"0xabc", false}, []any{"0xabc", false}},
+		{"No block param.", "eth_getTransactionReceipt", []any{"0xabc"}, []any{"0xabc"}},
+		{
+			"Logs by block hash.",
+			"eth_getLogs",
+			[]any{map[string]any{"blockHash": "0xabc"}},
+			[]any{map[string]any{"blockHash": "0xabc"}},
+		},
+	} {
+		t.Run(testCase.testName, func(t *testing.T) {
+			requestBody := &jsonrpc.SingleRequestBody{Method: testCase.method, Params: testCase.params}
+			original := jsonrpc.SingleRequestBody{Method: testCase.method, Params: testCase.params}
+
+			resolved := cache.ResolveLatestBlock(requestBody, 0)
+
+			assert.Equal(t, testCase.expected, resolved.Params)
+			// The original request must be left untouched.
+			assert.Equal(t, original, *requestBody)
+		})
+	}
+}
+
+func TestRPCCache_ResolveLatestBlock_SharesCacheKey(t *testing.T) {
+	cache := newTestResolveLatestCache(t, true, 0x100)
+
+	latestRequest := cache.ResolveLatestBlock(&jsonrpc.SingleRequestBody{Method: "eth_getBalance", Params: []any{"0x01", "latest"}}, 0)
+	omittedRequest := cache.ResolveLatestBlock(&jsonrpc.SingleRequestBody{Method: "eth_getBalance", Params: []any{"0x01"}}, 0)
+	pinnedRequest := &jsonrpc.SingleRequestBody{Method: "eth_getBalance", Params: []any{"0x01", "0x100"}}
+
+	assert.Equal(t, CreateRequestKey("mainnet", *pinnedRequest), CreateRequestKey("mainnet", *latestRequest))
+	assert.Equal(t, CreateRequestKey("mainnet", *pinnedRequest), CreateRequestKey("mainnet", *omittedRequest))
+}
+
+func TestRPCCache_ResolveLatestBlock_MaxBlockHeight(t *testing.T) {
+	cache := newTestResolveLatestCache(t, true, 0x100)
+
+	requestBody := &jsonrpc.SingleRequestBody{Method: "eth_getBalance", Params: []any{"0x01", "latest"}}
+
+	// An upstream behind the chain's head is asked for its own head.
+	assert.Equal(t, []any{"0x01", "0xff"}, cache.ResolveLatestBlock(requestBody, 0xff).Params)
+	// The chain's head caps upstreams that are ahead of it.
+	assert.Equal(t, []any{"0x01", "0x100"}, cache.ResolveLatestBlock(requestBody, 0x101).Params)
+}
+
+func TestRPCCache_ResolveLatestBlock_Disabled(t *testing.T) {
+	for _, testCase := range []struct {
+		testName        string
+		resolveLatest   bool
+		headBlockHeight uint64
+	}{
+		{"Not enabled.", false, 0x100},
+		{"Head not known.", true, 0},
+	} {
+		t.Run(testCase.testName, func(t *testing.T) {
+			cache := newTestResolveLatestCache(t, testCase.resolveLatest, testCase.headBlockHeight)
+			requestBody := &jsonrpc.SingleRequestBody{Method: "eth_getBalance", Params: []any{"0x01", "latest"}}
+
+			assert.Same(t, requestBody, cache.ResolveLatestBlock(requestBody, 0))
+		})
+	}
+}
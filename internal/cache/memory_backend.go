@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	expiresAt time.Time // Zero if the entry never expires.
+	key       string
+	value     json.RawMessage
+}
+
+func (e *memoryEntry) size() int {
+	return len(e.key) + len(e.value)
+}
+
+// MemoryBackend stores results in the gateway's memory, for deployments without Redis. Each entry expires after its
+// own TTL, unless it is NoExpiration, and the least recently used entries are evicted once there are more than maxEntries entries, or their keys
+// and values take more than maxBytes. Expired entries are only removed when they are read or evicted.
+type MemoryBackend struct {
+	entries    map[string]*list.Element
+	lru        *list.List // Most recently used first.
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	lock       sync.Mutex
+}
+
+func NewMemoryBackend(maxEntries, maxBytes int) *MemoryBackend {
+	return &MemoryBackend{
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
+
+func (b *MemoryBackend) Get(_ context.Context, key string) (json.RawMessage, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	element, ok := b.entries[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+
+	entry := element.Value.(*memoryEntry) //nolint:errcheck,forcetypeassert // The list only holds entries.
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		b.remove(element)
+		return nil, ErrCacheMiss
+	}
+
+	b.lru.MoveToFront(element)
+
+	return entry.value, nil
+}
+
+func (b *MemoryBackend) Set(_ context.Context, key string, value json.RawMessage, ttl time.Duration) error {
+	// Copy the value so that the entry does not keep the whole response body it was sliced from in memory.
+	entry := &memoryEntry{key: key, value: bytes.Clone(value)}
+	if ttl != NoExpiration {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	// An entry that does not fit would evict everything else and then itself.
+	if ttl == 0 || entry.size() > b.maxBytes {
+		return nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if element, ok := b.entries[key]; ok {
+		b.remove(element)
+	}
+
+	b.entries[key] = b.lru.PushFront(entry)
+	b.bytes += entry.size()
+
+	for len(b.entries) > b.maxEntries || b.bytes > b.maxBytes {
+		b.remove(b.lru.Back())
+	}
+
+	return nil
+}
+
+// Replace is the same as Set, which always replaces the existing entry of the key.
+func (b *MemoryBackend) Replace(ctx context.Context, key string, value json.RawMessage, ttl time.Duration) error {
+	return b.Set(ctx, key, value, ttl)
+}
+
+func (b *MemoryBackend) Delete(_ context.Context, key string) error {
+	b.DeleteFromLocalCache(key)
+	return nil
+}
+
+func (b *MemoryBackend) DeleteFromLocalCache(key string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if element, ok := b.entries[key]; ok {
+		b.remove(element)
+	}
+}
+
+// remove removes the element's entry. Requires external locking.
+func (b *MemoryBackend) remove(element *list.Element) {
+	entry := b.lru.Remove(element).(*memoryEntry) //nolint:errcheck,forcetypeassert // The list only holds entries.
+	delete(b.entries, entry.key)
+	b.bytes -= entry.size()
+}
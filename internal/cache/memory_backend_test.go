@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBackend_GetSet(t *testing.T) {
+	backend := NewMemoryBackend(10, 1000)
+	ctx := context.Background()
+
+	_, err := backend.Get(ctx, "key")
+	assert.Equal(t, ErrCacheMiss, err)
+
+	assert.NoError(t, backend.Set(ctx, "key", json.RawMessage(`"0x1"`), time.Minute))
+
+	value, err := backend.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`"0x1"`), value)
+
+	// Setting an existing key replaces its value.
+	assert.NoError(t, backend.Set(ctx, "key", json.RawMessage(`"0x2"`), time.Minute))
+
+	value, err = backend.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`"0x2"`), value)
+	assert.Equal(t, len("key")+len(`"0x2"`), backend.bytes)
+
+	backend.DeleteFromLocalCache("key")
+
+	_, err = backend.Get(ctx, "key")
+	assert.Equal(t, ErrCacheMiss, err)
+	assert.Equal(t, 0, backend.bytes)
+}
+
+func TestMemoryBackend_TTL(t *testing.T) {
+	backend := NewMemoryBackend(10, 1000)
+	ctx := context.Background()
+
+	assert.NoError(t, backend.Set(ctx, "short", json.RawMessage(`1`), 20*time.Millisecond))
+	assert.NoError(t, backend.Set(ctx, "long", json.RawMessage(`2`), time.Minute))
+	assert.NoError(t, backend.Set(ctx, "never", json.RawMessage(`3`), NoExpiration))
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := backend.Get(ctx, "short")
+	assert.Equal(t, ErrCacheMiss, err)
+
+	_, err = backend.Get(ctx, "long")
+	assert.NoError(t, err)
+
+	_, err = backend.Get(ctx, "never")
+	assert.NoError(t, err)
+	assert.Len(t, backend.entries, 2)
+}
+
+func TestMemoryBackend_MaxEntries(t *testing.T) {
+	backend := NewMemoryBackend(2, 1000)
+	ctx := context.Background()
+
+	assert.NoError(t, backend.Set(ctx, "a", json.RawMessage(`1`), time.Minute))
+	assert.NoError(t, backend.Set(ctx, "b", json.RawMessage(`2`), time.Minute))
+
+	// Reading "a" makes "b" the least recently used entry.
+	_, err := backend.Get(ctx, "a")
+	assert.NoError(t, err)
+
+	assert.NoError(t, backend.Set(ctx, "c", json.RawMessage(`3`), time.Minute))
+
+	_, err = backend.Get(ctx, "b")
+	assert.Equal(t, ErrCacheMiss, err)
+
+	_, err = backend.Get(ctx, "a")
+	assert.NoError(t, err)
+
+	_, err = backend.Get(ctx, "c")
+	assert.NoError(t, err)
+}
+
+func TestMemoryBackend_MaxBytes(t *testing.T) {
+	backend := NewMemoryBackend(10, 10)
+	ctx := context.Background()
+
+	assert.NoError(t, backend.Set(ctx, "a", json.RawMessage(`1234`), time.Minute))
+	assert.NoError(t, backend.Set(ctx, "b", json.RawMessage(`1234`), time.Minute))
+	assert.NoError(t, backend.Set(ctx, "c", json.RawMessage(`1234`), time.Minute))
+
+	// Each entry takes 5 bytes, so only the two most recent ones fit.
+	_, err := backend.Get(ctx, "a")
+	assert.Equal(t, ErrCacheMiss, err)
+	assert.Equal(t, 10, backend.bytes)
+
+	// An entry larger than the bound is not cached, and does not evict anything.
+	assert.NoError(t, backend.Set(ctx, "d", json.RawMessage(`1234567890`), time.Minute))
+
+	_, err = backend.Get(ctx, "d")
+	assert.Equal(t, ErrCacheMiss, err)
+	assert.Len(t, backend.entries, 2)
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+)
+
+var nullResult = json.RawMessage("null")
+
+// negativeEntry is where the null result of a request is cached, if it can be.
+type negativeEntry struct {
+	key             string
+	headBlockHeight uint64
+}
+
+func isNullResult(result json.RawMessage) bool {
+	return bytes.Equal(result, nullResult)
+}
+
+// getNegativeEntry returns where the null result of the request is cached, or false if null results of the request
+// are not cached. The key includes the current head, so that null results are no longer served once a new block
+// arrives, e.g. because the transaction they were looked up for is included in it. Requests for a block number are
+// only eligible if the block is below the head, since null results at or above the head are expected to change.
+func (c *RPCCache) getNegativeEntry(key string, reqBody *jsonrpc.SingleRequestBody) (negativeEntry, bool) {
+	if c.cacheConfig.GetNegativeTTLForMethod(reqBody.Method) == 0 || c.chainMetadataStore == nil {
+		return negativeEntry{}, false
+	}
+
+	headBlockHeight, _ := c.chainMetadataStore.GetGlobalMaxBlockHeights()
+	if headBlockHeight == 0 {
+		return negativeEntry{}, false
+	}
+
+	if reference, ok := getBlockReference(reqBody); ok && !reference.isBlockHash {
+		if reference.tag != "" || reference.blockHeight >= headBlockHeight {
+			return negativeEntry{}, false
+		}
+	}
+
+	return negativeEntry{
+		key:             key + ":null:" + strconv.FormatUint(headBlockHeight, 10),
+		headBlockHeight: headBlockHeight,
+	}, true
+}
+
+// isNegativelyCached returns whether the null result of the request is cached.
+func (c *RPCCache) isNegativelyCached(ctx context.Context, entry negativeEntry, jsonRPCMethod string) bool {
+	result, err := c.get(ctx, entry.key, jsonRPCMethod)
+	return err == nil && isNullResult(result)
+}
+
+// setNegativeAsync caches the null result of a request without blocking the response.
+func (c *RPCCache) setNegativeAsync(ctx context.Context, entry negativeEntry, jsonRPCMethod string) {
+	ttl := c.cacheConfig.GetNegativeTTLForMethod(jsonRPCMethod)
+
+	go func() {
+		// A reorg of the head may include the transaction the null result was looked up for.
+		if c.finality != nil {
+			c.finality.reorgIndex.add(entry.headBlockHeight, entry.key, ttl)
+		}
+
+		c.set(ctx, entry.key, jsonRPCMethod, nullResult, ttl, false)
+	}()
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestNegativeCache(methodNegativeTTLs map[string]time.Duration) (*RPCCache, *metadata.ChainMetadataStore) {
+	cacheConfig := config.ChainCacheConfig{TTL: time.Minute, MethodNegativeTTLs: methodNegativeTTLs}
+	cache := FromBackend(cacheConfig, NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	cache.UseChainMetadataStore(chainMetadataStore)
+	chainMetadataStore.Start()
+
+	return cache, chainMetadataStore
+}
+
+func processHeadBlockHeight(t *testing.T, chainMetadataStore *metadata.ChainMetadataStore, headBlockHeight uint64) {
+	chainMetadataStore.ProcessBlockHeightUpdate("group1", "upstream1", headBlockHeight)
+	assert.Eventually(t, func() bool {
+		maxBlockHeight, _ := chainMetadataStore.GetGlobalMaxBlockHeights()
+		return maxBlockHeight == headBlockHeight
+	}, time.Second, time.Millisecond)
+}
+
+func TestHandleRequestParallel_NegativeTTL(t *testing.T) {
+	cache, chainMetadataStore := newTestNegativeCache(map[string]time.Duration{"eth_getTransactionReceipt": time.Minute})
+	processHeadBlockHeight(t, chainMetadataStore, 100)
+
+	reqBody := jsonrpc.SingleRequestBody{Method: "eth_getTransactionReceipt", Params: []any{"0xabc"}}
+
+	var originRequests atomic.Int32
+
+	originFunc := func() (*jsonrpc.SingleResponseBody, error) {
+		originRequests.Add(1)
+		return &jsonrpc.SingleResponseBody{Result: json.RawMessage("null")}, nil
+	}
+
+	result, cached, err := cache.HandleRequestParallel("mainnet", reqBody, originFunc, nil)
+
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, json.RawMessage("null"), result)
+
+	// The null result is served from the cache until the next block.
+	assert.Eventually(t, func() bool {
+		_, cached, _ := cache.HandleRequestParallel("mainnet", reqBody, originFunc, nil)
+		return cached
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), originRequests.Load())
+
+	processHeadBlockHeight(t, chainMetadataStore, 101)
+
+	_, cached, err = cache.HandleRequestParallel("mainnet", reqBody, originFunc, nil)
+
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, int32(2), originRequests.Load())
+
+	// Null results are never cached as regular entries.
+	_, err = cache.backend.Get(context.Background(), CreateRequestKey("mainnet", reqBody))
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestRPCCache_GetNegativeEntry(t *testing.T) {
+	cache, chainMetadataStore := newTestNegativeCache(map[string]time.Duration{
+		"eth_getTransactionReceipt": time.Minute,
+		"eth_getBlockByNumber":      time.Minute,
+	})
+	processHeadBlockHeight(t, chainMetadataStore, 100)
+
+	for _, testCase := range []struct {
+		testName string
+		method   string
+		params   []any
+		expected bool
+	}{
+		{"Method without block param.", "eth_getTransactionReceipt", []any{"0xabc"}, true},
+		{"Block below the head.", "eth_getBlockByNumber", []any{"0x63", false}, true},
+		{"Block at the head.", "eth_getBlockByNumber", []any{"0x64", false}, false},
+		{"Block above the head.", "eth_getBlockByNumber", []any{"0x65", false}, false},
+		{"Block tag.", "eth_getBlockByNumber", []any{"pending", false}, false},
+		{"Method without negative TTL.", "eth_getBalance", []any{"0x01", "0x63"}, false},
+	} {
+		t.Run(testCase.testName, func(t *testing.T) {
+			reqBody := jsonrpc.SingleRequestBody{Method: testCase.method, Params: testCase.params}
+			entry, ok := cache.getNegativeEntry(CreateRequestKey("mainnet", reqBody), &reqBody)
+
+			assert.Equal(t, testCase.expected, ok)
+
+			if ok {
+				assert.Equal(t, uint64(100), entry.headBlockHeight)
+			}
+		})
+	}
+}
+
+func TestRPCCache_GetNegativeEntry_Disabled(t *testing.T) {
+	cache, chainMetadataStore := newTestNegativeCache(nil)
+	processHeadBlockHeight(t, chainMetadataStore, 100)
+
+	reqBody := jsonrpc.SingleRequestBody{Method: "eth_getTransactionReceipt", Params: []any{"0xabc"}}
+	_, ok := cache.getNegativeEntry(CreateRequestKey("mainnet", reqBody), &reqBody)
+
+	assert.False(t, ok)
+}
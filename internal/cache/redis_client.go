@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/satsuma-data/node-gateway/internal/config"
+)
+
+// getRedisTopologyURL identifies the Sentinel or Cluster nodes in logs and metrics.
+func getRedisTopologyURL(redisConfig *config.RedisConfig) string {
+	if redisConfig.Sentinel != nil {
+		return redisConfig.Sentinel.MasterName + "@" + strings.Join(redisConfig.Sentinel.Addresses, ",")
+	}
+
+	return strings.Join(redisConfig.Cluster.Addresses, ",")
+}
+
+// newRedisClient creates the client of the given type, "reader" or "writer". Without Sentinel or Cluster, it connects
+// to the given endpoint. With Sentinel, the writer connects to the master and the reader to a random replica, or to
+// the master if there is none. With Cluster, the writer connects to the masters and the reader to the closest node.
+func newRedisClient(
+	redisConfig *config.RedisConfig,
+	url, clientType string,
+	onConnect func(ctx context.Context, conn *redis.Conn) error,
+) (redis.UniversalClient, error) {
+	if redisConfig == nil {
+		redisConfig = &config.RedisConfig{}
+	}
+
+	password, err := redisConfig.GetPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := redisConfig.TLS.GetTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	isReader := clientType == "reader"
+
+	switch {
+	case redisConfig.Sentinel != nil:
+		sentinelPassword, err := redisConfig.Sentinel.Credentials.GetPassword()
+		if err != nil {
+			return nil, err
+		}
+
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       redisConfig.Sentinel.MasterName,
+			SentinelAddrs:    redisConfig.Sentinel.Addresses,
+			SentinelUsername: redisConfig.Sentinel.Credentials.Username,
+			SentinelPassword: sentinelPassword,
+			ReplicaOnly:      isReader,
+			Username:         redisConfig.Username,
+			Password:         password,
+			DB:               redisConfig.DB,
+			TLSConfig:        tlsConfig,
+			DialTimeout:      redisDialTimeout,
+			ReadTimeout:      redisReadTimeout,
+			WriteTimeout:     redisWriteTimeout,
+			OnConnect:        onConnect,
+		}), nil
+	case redisConfig.Cluster != nil:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:          redisConfig.Cluster.Addresses,
+			RouteByLatency: isReader,
+			Username:       redisConfig.Username,
+			Password:       password,
+			TLSConfig:      tlsConfig,
+			DialTimeout:    redisDialTimeout,
+			ReadTimeout:    redisReadTimeout,
+			WriteTimeout:   redisWriteTimeout,
+			OnConnect:      onConnect,
+		}), nil
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:         url,
+			Username:     redisConfig.Username,
+			Password:     password,
+			DB:           redisConfig.DB,
+			TLSConfig:    tlsConfig,
+			DialTimeout:  redisDialTimeout,
+			ReadTimeout:  redisReadTimeout,
+			WriteTimeout: redisWriteTimeout,
+			OnConnect:    onConnect,
+		}), nil
+	}
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRedisClient(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	assert.NoError(t, os.WriteFile(passwordFile, []byte("secret\n"), 0o600))
+
+	credentials := config.RedisCredentialsConfig{Username: "gateway", PasswordFile: passwordFile}
+
+	for _, testCase := range []struct {
+		redisConfig *config.RedisConfig
+		testName    string
+		clientType  string
+		isCluster   bool
+	}{
+		{nil, "Endpoint without options.", "reader", false},
+		{&config.RedisConfig{RedisCredentialsConfig: credentials, DB: 2, TLS: &config.RedisTLSConfig{}}, "Endpoint with options.", "writer", false},
+		{&config.RedisConfig{Sentinel: &config.RedisSentinelConfig{MasterName: "mymaster", Addresses: []string{"sentinel:26379"}}}, "Sentinel.", "reader", false},
+		{&config.RedisConfig{Cluster: &config.RedisClusterConfig{Addresses: []string{"node:6379"}}}, "Cluster.", "reader", true},
+	} {
+		t.Run(testCase.testName, func(t *testing.T) {
+			rdb, err := newRedisClient(testCase.redisConfig, "localhost:6379", testCase.clientType, nil)
+
+			assert.NoError(t, err)
+
+			defer rdb.Close()
+
+			_, isCluster := rdb.(*redis.ClusterClient)
+			assert.Equal(t, testCase.isCluster, isCluster)
+
+			if client, ok := rdb.(*redis.Client); ok && testCase.redisConfig != nil && testCase.redisConfig.Sentinel == nil {
+				assert.Equal(t, "gateway", client.Options().Username)
+				assert.Equal(t, "secret", client.Options().Password)
+				assert.Equal(t, 2, client.Options().DB)
+				assert.NotNil(t, client.Options().TLSConfig)
+			}
+		})
+	}
+}
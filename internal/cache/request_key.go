@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
+)
+
+// requestKeyVersion prefixes every cache key. Bump it whenever the key format, the normalization of params or the
+// format of entries changes, so that entries written in the old format are never read back.
+const requestKeyVersion = "v2"
+
+// CreateRequestKey returns the cache key of a request. Params are normalized so that equivalent requests share a key,
+// and hashed so that the key has a fixed size regardless of the size of the params.
+func CreateRequestKey(chainName string, requestBody jsonrpc.SingleRequestBody) string {
+	// json.Marshal sorts the keys of maps, so objects are encoded the same way regardless of their key order.
+	encodedParams, err := json.Marshal(normalizeParams(requestBody.Method, requestBody.Params))
+	if err != nil {
+		// Params were decoded from JSON, so they can always be encoded again.
+		encodedParams = []byte(fmt.Sprintf("%v", requestBody.Params))
+	}
+
+	paramsHash := sha256.Sum256(encodedParams)
+
+	return fmt.Sprintf("%s:%s:%s:%s", requestKeyVersion, chainName, requestBody.Method, hex.EncodeToString(paramsHash[:]))
+}
+
+// normalizeParams returns a canonical copy of the params of a request: hex strings are lowercased, null fields of
+// objects are dropped, omitted block params are filled with their default, and block numbers are re-encoded without
+// leading zeros.
+func normalizeParams(method string, params []any) []any {
+	normalized, _ := normalizeValue(params).([]any)
+	if normalized == nil {
+		normalized = []any{}
+	}
+
+	if method == "eth_getLogs" {
+		if len(normalized) == 1 {
+			if filter, ok := normalized[0].(map[string]any); ok {
+				normalizeLogsFilter(filter)
+			}
+		}
+
+		return normalized
+	}
+
+	paramIndex, ok := blockParamIndexByMethod[method]
+	if !ok {
+		return normalized
+	}
+
+	switch {
+	case paramIndex == len(normalized):
+		// An omitted trailing block param defaults to the latest block.
+		normalized = append(normalized, string(metadata.LatestBlockTag))
+	case paramIndex < len(normalized):
+		normalized[paramIndex] = normalizeBlockParam(normalized[paramIndex])
+	}
+
+	return normalized
+}
+
+func normalizeLogsFilter(filter map[string]any) {
+	if _, ok := filter["blockHash"]; ok {
+		return
+	}
+
+	for _, field := range []string{"fromBlock", "toBlock"} {
+		filter[field] = normalizeBlockParam(filter[field])
+	}
+}
+
+func normalizeBlockParam(param any) any {
+	switch value := param.(type) {
+	case nil:
+		return string(metadata.LatestBlockTag)
+	case string:
+		if blockHeight, ok := parseHexQuantity(value); ok {
+			return "0x" + strconv.FormatUint(blockHeight, 16)
+		}
+	case map[string]any:
+		if blockNumber, ok := value["blockNumber"]; ok {
+			value["blockNumber"] = normalizeBlockParam(blockNumber)
+		}
+	}
+
+	return param
+}
+
+func normalizeValue(value any) any {
+	switch value := value.(type) {
+	case string:
+		if isHexString(value) {
+			return strings.ToLower(value)
+		}
+
+		return value
+	case []any:
+		normalized := make([]any, len(value))
+		for i, element := range value {
+			normalized[i] = normalizeValue(element)
+		}
+
+		return normalized
+	case map[string]any:
+		normalized := make(map[string]any, len(value))
+
+		for key, element := range value {
+			if element != nil {
+				normalized[key] = normalizeValue(element)
+			}
+		}
+
+		return normalized
+	default:
+		return value
+	}
+}
+
+// parseHexQuantity parses a hex-encoded quantity, tolerating the leading zeros some clients send.
+func parseHexQuantity(value string) (uint64, bool) {
+	if !isHexString(value) {
+		return 0, false
+	}
+
+	quantity, err := strconv.ParseUint(value[2:], 16, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return quantity, true
+}
+
+func isHexString(value string) bool {
+	if len(value) < 3 || (value[:2] != "0x" && value[:2] != "0X") {
+		return false
+	}
+
+	for _, c := range value[2:] {
+		isDigit := c >= '0' && c <= '9'
+		isHexLetter := (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+
+		if !isDigit && !isHexLetter {
+			return false
+		}
+	}
+
+	return true
+}
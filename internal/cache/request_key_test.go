@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRequestKey_EquivalentRequests(t *testing.T) {
+	for _, tc := range []struct {
+		testName string
+		method   string
+		params1  []any
+		params2  []any
+	}{
+		{
+			testName: "Hex case is ignored.",
+			method:   "eth_getBalance",
+			params1:  []any{"0xAbCdEf0123456789aBcDeF0123456789AbCdEf01", "0xA"},
+			params2:  []any{"0xabcdef0123456789abcdef0123456789abcdef01", "0xa"},
+		},
+		{
+			testName: "Object key order is ignored.",
+			method:   "eth_call",
+			params1:  []any{map[string]any{"to": "0x01", "data": "0x02"}, "latest"},
+			params2:  []any{map[string]any{"data": "0x02", "to": "0x01"}, "latest"},
+		},
+		{
+			testName: "Null object fields are ignored.",
+			method:   "eth_call",
+			params1:  []any{map[string]any{"to": "0x01", "value": nil}, "latest"},
+			params2:  []any{map[string]any{"to": "0x01"}, "latest"},
+		},
+		{
+			testName: "Omitted block param defaults to latest.",
+			method:   "eth_call",
+			params1:  []any{map[string]any{"to": "0x01"}},
+			params2:  []any{map[string]any{"to": "0x01"}, "latest"},
+		},
+		{
+			testName: "Block number leading zeros are ignored.",
+			method:   "eth_getBlockByNumber",
+			params1:  []any{"0x00ff", false},
+			params2:  []any{"0xFF", false},
+		},
+		{
+			testName: "EIP-1898 block number leading zeros are ignored.",
+			method:   "eth_getBalance",
+			params1:  []any{"0x01", map[string]any{"blockNumber": "0x0a"}},
+			params2:  []any{"0x01", map[string]any{"blockNumber": "0xa"}},
+		},
+		{
+			testName: "Omitted getLogs range defaults to latest.",
+			method:   "eth_getLogs",
+			params1:  []any{map[string]any{"address": "0x01"}},
+			params2:  []any{map[string]any{"address": "0x01", "fromBlock": "latest", "toBlock": "latest"}},
+		},
+	} {
+		t.Run(tc.testName, func(t *testing.T) {
+			key1 := CreateRequestKey("mainnet", jsonrpc.SingleRequestBody{Method: tc.method, Params: tc.params1})
+			key2 := CreateRequestKey("mainnet", jsonrpc.SingleRequestBody{Method: tc.method, Params: tc.params2})
+
+			assert.Equal(t, key1, key2)
+		})
+	}
+}
+
+func TestCreateRequestKey_DistinctRequests(t *testing.T) {
+	for _, tc := range []struct {
+		testName string
+		method   string
+		params1  []any
+		params2  []any
+	}{
+		{
+			testName: "Different block numbers.",
+			method:   "eth_getBlockByNumber",
+			params1:  []any{"0x1", false},
+			params2:  []any{"0x10", false},
+		},
+		{
+			testName: "Nested objects are not flattened.",
+			method:   "eth_call",
+			params1:  []any{map[string]any{"to": "0x01", "data": "0x02"}, "latest"},
+			params2:  []any{map[string]any{"to": "0x0102"}, "latest"},
+		},
+		{
+			testName: "Non-hex strings keep their case.",
+			method:   "eth_call",
+			params1:  []any{map[string]any{"to": "Vitalik"}, "latest"},
+			params2:  []any{map[string]any{"to": "vitalik"}, "latest"},
+		},
+	} {
+		t.Run(tc.testName, func(t *testing.T) {
+			key1 := CreateRequestKey("mainnet", jsonrpc.SingleRequestBody{Method: tc.method, Params: tc.params1})
+			key2 := CreateRequestKey("mainnet", jsonrpc.SingleRequestBody{Method: tc.method, Params: tc.params2})
+
+			assert.NotEqual(t, key1, key2)
+		})
+	}
+}
+
+func TestCreateRequestKey_FixedSize(t *testing.T) {
+	addresses := make([]any, 1000)
+	for i := range addresses {
+		addresses[i] = "0x000000000000000000000000000000000000dead"
+	}
+
+	key := CreateRequestKey("mainnet", jsonrpc.SingleRequestBody{
+		Method: "eth_getLogs",
+		Params: []any{map[string]any{"address": addresses, "fromBlock": "0x1", "toBlock": "0x2"}},
+	})
+
+	assert.True(t, strings.HasPrefix(key, requestKeyVersion+":mainnet:eth_getLogs:"))
+	assert.Len(t, key, len(requestKeyVersion+":mainnet:eth_getLogs:")+64)
+}
+
+func TestCreateRequestKey_DoesNotModifyParams(t *testing.T) {
+	filter := map[string]any{"address": "0xABCD", "toBlock": nil}
+	requestBody := jsonrpc.SingleRequestBody{Method: "eth_getLogs", Params: []any{filter}}
+
+	CreateRequestKey("mainnet", requestBody)
+
+	assert.Equal(t, map[string]any{"address": "0xABCD", "toBlock": nil}, filter)
+	assert.Len(t, requestBody.Params, 1)
+}
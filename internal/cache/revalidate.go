@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"go.uber.org/zap"
+)
+
+// getStaleWhileRevalidate returns the stale-while-revalidate window of the method's entries with the given TTL.
+// Entries that never expire are never refreshed.
+func (c *RPCCache) getStaleWhileRevalidate(jsonRPCMethod string, ttl finalityTTL) time.Duration {
+	if ttl.ttl <= 0 {
+		return 0
+	}
+
+	return c.cacheConfig.GetStaleWhileRevalidateForMethod(jsonRPCMethod)
+}
+
+// revalidateAsync refreshes the cached entry of the request in the background. Concurrent requesters of the same key
+// trigger a single refresh.
+func (c *RPCCache) revalidateAsync(
+	key string,
+	reqBody jsonrpc.SingleRequestBody,
+	revalidateFunc func() (*jsonrpc.SingleResponseBody, error),
+) {
+	if _, isRevalidating := c.revalidatingKeys.LoadOrStore(key, struct{}{}); isRevalidating {
+		return
+	}
+
+	ttl := finalityTTL{ttl: c.cacheConfig.GetTTLForMethod(reqBody.Method)}
+	if c.finality != nil {
+		ttl = c.finality.getTTL(&reqBody, ttl.ttl)
+	}
+
+	go func() {
+		defer c.revalidatingKeys.Delete(key)
+
+		ctx := context.Background()
+
+		respBody, err := revalidateFunc()
+		if err != nil || respBody == nil || !isCacheableResponse(respBody) {
+			c.metricsContainer.CacheRevalidations.WithLabelValues(reqBody.Method, "false").Inc()
+			zap.L().Debug("cache revalidation failed", zap.String("key", key), zap.Any("respBody", respBody), zap.Error(err))
+
+			return
+		}
+
+		c.metricsContainer.CacheRevalidations.WithLabelValues(reqBody.Method, "true").Inc()
+		// The outdated entry is replaced in place, so readers are served it until the refreshed one is set. Set
+		// synchronously, so that no other refresh of the key starts before the refreshed entry is set.
+		c.setEntry(ctx, pendingEntry{key: key, ttl: ttl, replace: true}, reqBody.Method, respBody.Result)
+	}()
+}
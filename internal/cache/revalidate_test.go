@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// needsRevalidation returns whether the cached entry of the key is still served, but no longer fresh.
+func needsRevalidation(cache *RPCCache, key string) bool {
+	entry, err := cache.getEntry(context.Background(), key, "")
+	now := time.Now()
+
+	return err == nil && !entry.isFresh(now) && entry.isServed(now)
+}
+
+func TestHandleRequestParallel_StaleWhileRevalidate(t *testing.T) {
+	cacheConfig := config.ChainCacheConfig{
+		MethodTTLs:                  map[string]time.Duration{"eth_gasPrice": time.Minute},
+		MethodStaleWhileRevalidates: map[string]time.Duration{"eth_gasPrice": time.Minute},
+	}
+	cache := FromBackend(cacheConfig, NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+
+	reqBody := jsonrpc.SingleRequestBody{Method: "eth_gasPrice"}
+	key := CreateRequestKey("mainnet", reqBody)
+
+	// Cache an entry whose TTL has passed, but not its stale-while-revalidate window.
+	cache.setEntry(context.Background(), pendingEntry{key: key, ttl: finalityTTL{ttl: time.Millisecond}}, reqBody.Method, json.RawMessage(`"0x1"`))
+	assert.Eventually(t, func() bool {
+		return needsRevalidation(cache, key)
+	}, time.Second, time.Millisecond)
+
+	originFunc := func() (*jsonrpc.SingleResponseBody, error) {
+		t.Fatal("origin must not be called while the entry can be served")
+		return nil, nil
+	}
+
+	var revalidations atomic.Int32
+
+	releaseRevalidation := make(chan struct{})
+	revalidateFunc := func() (*jsonrpc.SingleResponseBody, error) {
+		revalidations.Add(1)
+		<-releaseRevalidation
+
+		return &jsonrpc.SingleResponseBody{Result: json.RawMessage(`"0x2"`)}, nil
+	}
+
+	var wg sync.WaitGroup
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			result, cached, err := cache.HandleRequestParallel("mainnet", reqBody, originFunc, revalidateFunc)
+
+			assert.NoError(t, err)
+			assert.True(t, cached)
+			assert.Equal(t, json.RawMessage(`"0x1"`), result)
+		}()
+	}
+
+	wg.Wait()
+	close(releaseRevalidation)
+
+	assert.Eventually(t, func() bool {
+		result, _, _ := cache.HandleRequestParallel("mainnet", reqBody, originFunc, revalidateFunc)
+		return string(result) == `"0x2"`
+	}, time.Second, time.Millisecond)
+
+	// Concurrent requesters trigger a single refresh, and the refreshed entry is fresh.
+	assert.Equal(t, int32(1), revalidations.Load())
+	assert.False(t, needsRevalidation(cache, key))
+}
+
+func TestHandleRequestParallel_StaleWhileRevalidate_FailedRefresh(t *testing.T) {
+	cacheConfig := config.ChainCacheConfig{
+		MethodTTLs:                  map[string]time.Duration{"eth_gasPrice": time.Minute},
+		MethodStaleWhileRevalidates: map[string]time.Duration{"eth_gasPrice": time.Minute},
+	}
+	cache := FromBackend(cacheConfig, NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+
+	reqBody := jsonrpc.SingleRequestBody{Method: "eth_gasPrice"}
+	key := CreateRequestKey("mainnet", reqBody)
+
+	cache.setEntry(context.Background(), pendingEntry{key: key, ttl: finalityTTL{ttl: time.Millisecond}}, reqBody.Method, json.RawMessage(`"0x1"`))
+	assert.Eventually(t, func() bool {
+		return needsRevalidation(cache, key)
+	}, time.Second, time.Millisecond)
+
+	var revalidations atomic.Int32
+
+	revalidateFunc := func() (*jsonrpc.SingleResponseBody, error) {
+		revalidations.Add(1)
+		return &jsonrpc.SingleResponseBody{Error: &jsonrpc.Error{Code: -32000, Message: "error"}}, nil
+	}
+
+	result, cached, err := cache.HandleRequestParallel("mainnet", reqBody, nil, revalidateFunc)
+
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, json.RawMessage(`"0x1"`), result)
+
+	// The entry is kept when the refresh fails, and the next requester retries it.
+	assert.Eventually(t, func() bool {
+		_, _, _ = cache.HandleRequestParallel("mainnet", reqBody, nil, revalidateFunc)
+		return revalidations.Load() >= 2
+	}, time.Second, time.Millisecond)
+
+	entry, err := cache.getEntry(context.Background(), key, reqBody.Method)
+
+	assert.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`"0x1"`), entry.Result)
+}
+
+func TestHandleBatchRequestParallel_StaleWhileRevalidate(t *testing.T) {
+	cacheConfig := config.ChainCacheConfig{
+		MethodTTLs:                  map[string]time.Duration{"eth_gasPrice": time.Minute},
+		MethodStaleWhileRevalidates: map[string]time.Duration{"eth_gasPrice": time.Minute},
+	}
+	cache := FromBackend(cacheConfig, NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+
+	reqBody := newTestSubRequest(1, "eth_gasPrice")
+	key := CreateRequestKey("mainnet", reqBody)
+
+	cache.setEntry(context.Background(), pendingEntry{key: key, ttl: finalityTTL{ttl: time.Millisecond}}, reqBody.Method, json.RawMessage(`"0x1"`))
+	assert.Eventually(t, func() bool {
+		return needsRevalidation(cache, key)
+	}, time.Second, time.Millisecond)
+
+	originFunc := func([]jsonrpc.SingleRequestBody) ([]jsonrpc.SingleResponseBody, error) {
+		t.Fatal("origin must not be called while the entry can be served")
+		return nil, nil
+	}
+	revalidateFunc := func(subRequest jsonrpc.SingleRequestBody) (*jsonrpc.SingleResponseBody, error) {
+		assert.Equal(t, reqBody, subRequest)
+		return &jsonrpc.SingleResponseBody{Result: json.RawMessage(`"0x2"`)}, nil
+	}
+
+	respBodies, cached, err := cache.HandleBatchRequestParallel("mainnet", []jsonrpc.SingleRequestBody{reqBody}, originFunc, revalidateFunc)
+
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, json.RawMessage(`"0x1"`), respBodies[0].Result)
+
+	// The entry is refreshed in the background, and the refreshed entry is fresh.
+	assert.Eventually(t, func() bool {
+		entry, err := cache.getEntry(context.Background(), key, reqBody.Method)
+		return err == nil && string(entry.Result) == `"0x2"` && entry.isFresh(time.Now())
+	}, time.Second, time.Millisecond)
+}
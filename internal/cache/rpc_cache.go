@@ -4,9 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-redis/cache/v9"
@@ -15,6 +15,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/satsuma-data/node-gateway/internal/config"
 	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
 	"github.com/satsuma-data/node-gateway/internal/metrics"
 	"go.uber.org/zap"
 )
@@ -26,30 +27,34 @@ var redisWriteTimeout = 500 * time.Millisecond
 var localCacheSize = 1000
 var defaultLocalCacheTTL = 10 * time.Second
 
-func CreateRedisReaderClient(url string) *redis.Client {
-	return createRedisClient(url, "reader")
+func CreateRedisReaderClient(cacheConfig *config.CacheConfig) redis.UniversalClient {
+	readerAddr, _ := cacheConfig.GetRedisAddresses()
+	return createRedisClient(cacheConfig, readerAddr, "reader")
 }
 
-func CreateRedisWriterClient(url string) *redis.Client {
-	return createRedisClient(url, "writer")
+func CreateRedisWriterClient(cacheConfig *config.CacheConfig) redis.UniversalClient {
+	_, writerAddr := cacheConfig.GetRedisAddresses()
+	return createRedisClient(cacheConfig, writerAddr, "writer")
 }
 
-func createRedisClient(url, clientType string) *redis.Client {
+func createRedisClient(cacheConfig *config.CacheConfig, url, clientType string) redis.UniversalClient {
+	if cacheConfig.HasRedisTopology() {
+		url = getRedisTopologyURL(cacheConfig.RedisOptions)
+	}
+
 	if url == "" {
 		return nil
 	}
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr:         url,
-		DialTimeout:  redisDialTimeout,
-		ReadTimeout:  redisReadTimeout,
-		WriteTimeout: redisWriteTimeout,
-		OnConnect: func(_ context.Context, _ *redis.Conn) error {
-			zap.L().Info("established new connection to redis", zap.String("url", url))
-			metrics.CacheConnections.WithLabelValues(url).Inc()
-			return nil
-		},
+	rdb, err := newRedisClient(cacheConfig.RedisOptions, url, clientType, func(_ context.Context, _ *redis.Conn) error {
+		zap.L().Info("established new connection to redis", zap.String("url", url))
+		metrics.CacheConnections.WithLabelValues(url).Inc()
+		return nil
 	})
+	if err != nil {
+		zap.L().Error("failed to create redis client", zap.String("clientType", clientType), zap.Error(err))
+		return nil
+	}
 
 	collector := redisprometheus.NewCollector(
 		metrics.MetricsNamespace,
@@ -62,7 +67,7 @@ func createRedisClient(url, clientType string) *redis.Client {
 	return rdb
 }
 
-func FromClients(cacheConfig config.ChainCacheConfig, reader, writer *redis.Client, metricsContainer *metrics.Container) *RPCCache {
+func FromClients(cacheConfig config.ChainCacheConfig, reader, writer redis.UniversalClient, metricsContainer *metrics.Container) *RPCCache {
 	if reader == nil || writer == nil {
 		return nil
 	}
@@ -77,14 +82,27 @@ func FromClients(cacheConfig config.ChainCacheConfig, reader, writer *redis.Clie
 		}),
 		// Wrap the redis clients in a cache.Cache to use the go-redis/cache library
 		// The library offers faster serialization/deserialization, and local caching.
-		cacheRead: cache.New(&cache.Options{
-			Redis:      reader,
-			LocalCache: localCache,
-		}),
-		cacheWrite: cache.New(&cache.Options{
-			Redis:      writer,
-			LocalCache: localCache,
-		}),
+		backend: &redisBackend{
+			cacheRead: cache.New(&cache.Options{
+				Redis:      reader,
+				LocalCache: localCache,
+			}),
+			cacheWrite: cache.New(&cache.Options{
+				Redis:      writer,
+				LocalCache: localCache,
+			}),
+			redisWrite: writer,
+		},
+		metricsContainer: metricsContainer,
+		cacheConfig:      cacheConfig,
+	}
+}
+
+// FromBackend creates an RPCCache that stores results in the given backend instead of Redis, e.g. a MemoryBackend.
+func FromBackend(cacheConfig config.ChainCacheConfig, backend Backend, metricsContainer *metrics.Container) *RPCCache {
+	return &RPCCache{
+		cache:            cache.New(&cache.Options{}),
+		backend:          backend,
 		metricsContainer: metricsContainer,
 		cacheConfig:      cacheConfig,
 	}
@@ -105,26 +123,26 @@ func getMinimumTTL(cacheConfig config.ChainCacheConfig) time.Duration {
 	return minimumTTL
 }
 
-func FromClient(cacheConfig config.ChainCacheConfig, rdb *redis.Client, metricsContainer *metrics.Container) *RPCCache {
+func FromClient(cacheConfig config.ChainCacheConfig, rdb redis.UniversalClient, metricsContainer *metrics.Container) *RPCCache {
 	return FromClients(cacheConfig, rdb, rdb, metricsContainer)
 }
 
 type RPCCache struct {
-	cache            *cache.Cache // Legacy
-	cacheRead        *cache.Cache
-	cacheWrite       *cache.Cache
-	metricsContainer *metrics.Container
-	cacheConfig      config.ChainCacheConfig
+	cache              *cache.Cache // Legacy
+	backend            Backend
+	finality           *finalityTracker             // Nil unless finality is tracked.
+	chainMetadataStore *metadata.ChainMetadataStore // Nil unless set with UseChainMetadataStore.
+	metricsContainer   *metrics.Container
+	cacheConfig        config.ChainCacheConfig
+	revalidatingKeys   sync.Map // Keys being refreshed in the background.
 }
 
 func (c *RPCCache) get(ctx context.Context, key, jsonRPCMethod string) (json.RawMessage, error) {
-	var result json.RawMessage
-
 	start := time.Now()
-	err := c.cacheRead.Get(ctx, key, &result)
+	result, err := c.backend.Get(ctx, key)
 	duration := time.Since(start)
 
-	cacheMiss := err == cache.ErrCacheMiss
+	cacheMiss := err == ErrCacheMiss
 
 	// Record metrics
 	c.metricsContainer.CacheReadDuration.
@@ -156,15 +174,17 @@ func (c *RPCCache) get(ctx context.Context, key, jsonRPCMethod string) (json.Raw
 	return result, nil
 }
 
-func (c *RPCCache) set(ctx context.Context, key, jsonRPCMethod string, value json.RawMessage, ttl time.Duration) {
+// set sets the key, or replaces it if replace is set.
+func (c *RPCCache) set(ctx context.Context, key, jsonRPCMethod string, value json.RawMessage, ttl time.Duration, replace bool) {
 	start := time.Now()
-	err := c.cacheWrite.Set(&cache.Item{
-		Ctx:   ctx,
-		Key:   key,
-		Value: value,
-		SetNX: true,
-		TTL:   ttl,
-	})
+
+	var err error
+	if replace {
+		err = c.backend.Replace(ctx, key, value, ttl)
+	} else {
+		err = c.backend.Set(ctx, key, value, ttl)
+	}
+
 	duration := time.Since(start)
 
 	// Record metrics
@@ -190,31 +210,98 @@ func (c *RPCCache) set(ctx context.Context, key, jsonRPCMethod string, value jso
 	}
 }
 
-func (c *RPCCache) Marshal(value interface{}) ([]byte, error) {
-	return c.cache.Marshal(value)
+// cachedEntry is how a result is stored. Entries with a stale-while-revalidate window or a stale-if-error grace period
+// outlive their TTL, so the times at which they stop being fresh and stop being served are stored with them.
+type cachedEntry struct {
+	Result json.RawMessage `json:"result"`
+	// Unix milliseconds after which the entry is refreshed. Zero if it is fresh until it expires.
+	FreshUntil int64 `json:"freshUntil,omitempty"`
+	// Unix milliseconds after which the entry is only served if the request cannot be routed. Zero if it is served
+	// until it expires.
+	ServedUntil int64 `json:"servedUntil,omitempty"`
 }
 
-func (c *RPCCache) ShouldCacheMethod(method string) bool {
-	return c.cacheConfig.GetTTLForMethod(method) > 0
+func (e *cachedEntry) isFresh(now time.Time) bool {
+	return e.FreshUntil == 0 || now.UnixMilli() < e.FreshUntil
 }
 
-// Use for testing
-func (c *RPCCache) DeleteFromLocalCache(key string) {
-	c.cacheRead.DeleteFromLocalCache(key)
-	c.cacheWrite.DeleteFromLocalCache(key)
+func (e *cachedEntry) isServed(now time.Time) bool {
+	return e.ServedUntil == 0 || now.UnixMilli() < e.ServedUntil
 }
 
-func CreateRequestKey(chainName string, requestBody jsonrpc.SingleRequestBody) string {
-	var paramsStr string
+// getEntry returns the cached entry of the key, or an error if it is not cached or cannot be decoded.
+func (c *RPCCache) getEntry(ctx context.Context, key, jsonRPCMethod string) (cachedEntry, error) {
+	value, err := c.get(ctx, key, jsonRPCMethod)
+	if err != nil {
+		return cachedEntry{}, err
+	}
 
-	elements := make([]string, len(requestBody.Params))
-	for i, v := range requestBody.Params {
-		elements[i] = fmt.Sprintf("%v", v)
+	var entry cachedEntry
+	if err := json.Unmarshal(value, &entry); err != nil {
+		c.metricsContainer.CacheErrors.WithLabelValues("decode").Inc()
+		zap.L().Error("cache_decode error", zap.Error(err), zap.String("key", key))
+
+		return cachedEntry{}, err
 	}
 
-	paramsStr = "[" + strings.Join(elements, ",") + "]"
+	return entry, nil
+}
 
-	return fmt.Sprintf("%s:%s:%s", chainName, requestBody.Method, paramsStr)
+// pendingEntry is the cache entry of a request that missed the cache, to be set once origin responds. If the request
+// has a stale entry, it is replaced.
+type pendingEntry struct {
+	key     string
+	ttl     finalityTTL
+	replace bool
+}
+
+// setAsync sets the entry without blocking the response.
+func (c *RPCCache) setAsync(ctx context.Context, entry pendingEntry, jsonRPCMethod string, result json.RawMessage) {
+	go c.setEntry(ctx, entry, jsonRPCMethod, result)
+}
+
+// setEntry indexes the entry for reorg invalidation if needed, and sets it. The entry outlives its TTL by the
+// stale-while-revalidate window, in which it is served and refreshed, and then by the stale-if-error grace period, in
+// which it is only served if the request cannot be routed.
+func (c *RPCCache) setEntry(ctx context.Context, entry pendingEntry, jsonRPCMethod string, result json.RawMessage) {
+	staleWhileRevalidate := c.getStaleWhileRevalidate(jsonRPCMethod, entry.ttl)
+	staleIfError := c.getStaleIfError(entry.ttl)
+	ttl := entry.ttl.ttl + staleWhileRevalidate + staleIfError
+
+	value := cachedEntry{Result: result}
+	if staleWhileRevalidate > 0 || staleIfError > 0 {
+		now := time.Now()
+		value.FreshUntil = now.Add(entry.ttl.ttl).UnixMilli()
+		value.ServedUntil = now.Add(entry.ttl.ttl + staleWhileRevalidate).UnixMilli()
+	}
+
+	encodedValue, err := json.Marshal(&value)
+	if err != nil {
+		c.metricsContainer.CacheErrors.WithLabelValues("encode").Inc()
+		zap.L().Error("cache_encode error", zap.Error(err), zap.String("key", entry.key))
+
+		return
+	}
+
+	// Index the key before setting it, so that any reorg after it is set deletes it.
+	if entry.ttl.isReorgable {
+		c.finality.reorgIndex.add(entry.ttl.blockHeight, entry.key, ttl)
+	}
+
+	c.set(ctx, entry.key, jsonRPCMethod, encodedValue, ttl, entry.replace)
+}
+
+func (c *RPCCache) Marshal(value interface{}) ([]byte, error) {
+	return c.cache.Marshal(value)
+}
+
+func (c *RPCCache) ShouldCacheMethod(method string) bool {
+	return c.cacheConfig.GetTTLForMethod(method) > 0
+}
+
+// Use for testing
+func (c *RPCCache) DeleteFromLocalCache(key string) {
+	c.backend.DeleteFromLocalCache(key)
 }
 
 // Uses the go-redis/cache library
@@ -261,11 +348,14 @@ func (c *RPCCache) HandleRequest(chainName string, ttl time.Duration, reqBody js
 
 // Non coalesced requests
 // Uses the redis clients instead of the go-redis/cache library
+// revalidateFunc refreshes entries in their stale-while-revalidate window in the background. It must not depend on
+// the lifetime of the request, and may be nil if entries are not refreshed.
 func (c *RPCCache) HandleRequestParallel(
 	chainName string,
 	// ttl time.Duration,
 	reqBody jsonrpc.SingleRequestBody,
 	originFunc func() (*jsonrpc.SingleResponseBody, error),
+	revalidateFunc func() (*jsonrpc.SingleResponseBody, error),
 ) (json.RawMessage, bool, error) {
 	var (
 		cached = true
@@ -274,10 +364,29 @@ func (c *RPCCache) HandleRequestParallel(
 	ctx := context.Background()
 
 	key := CreateRequestKey(chainName, reqBody)
-	result, err := c.get(ctx, key, reqBody.Method) // Attempt to fetch from cache
+	entry, err := c.getEntry(ctx, key, reqBody.Method) // Attempt to fetch from cache
 
-	if err == nil {
-		return result, cached, nil
+	if now := time.Now(); err == nil && entry.isServed(now) {
+		if revalidateFunc != nil && !entry.isFresh(now) {
+			c.revalidateAsync(key, reqBody, revalidateFunc)
+		}
+
+		return entry.Result, cached, nil
+	}
+
+	// An entry that is only kept to be served if the request cannot be routed is replaced by the new result.
+	hasStaleEntry := err == nil
+
+	negativeEntry, isNegativeCacheable := c.getNegativeEntry(key, &reqBody)
+	if isNegativeCacheable && c.isNegativelyCached(ctx, negativeEntry, reqBody.Method) {
+		return nullResult, cached, nil
+	}
+
+	// Decide the TTL before the request to origin, so that a block is never considered more final than it was when
+	// its result was requested.
+	ttl := finalityTTL{ttl: c.cacheConfig.GetTTLForMethod(reqBody.Method)}
+	if c.finality != nil {
+		ttl = c.finality.getTTL(&reqBody, ttl.ttl)
 	}
 
 	// Cache miss or error, proceed with the request to origin
@@ -288,21 +397,16 @@ func (c *RPCCache) HandleRequestParallel(
 		return nil, cached, err
 	}
 
-	result = respBody.Result
-
-	ttl := c.cacheConfig.GetTTLForMethod(reqBody.Method)
-
-	if result != nil {
-		// Perform cache set asynchronously
-		go func() {
-			c.set(
-				ctx,
-				key,
-				reqBody.Method,
-				result,
-				ttl,
-			)
-		}()
+	result := respBody.Result
+
+	switch {
+	case isNullResult(result):
+		// Null results are only cached until a new block arrives, e.g. the receipt of a pending transaction.
+		if isNegativeCacheable {
+			c.setNegativeAsync(ctx, negativeEntry, reqBody.Method)
+		}
+	case result != nil:
+		c.setAsync(ctx, pendingEntry{key: key, ttl: ttl, replace: hasStaleEntry}, reqBody.Method, result)
 	}
 
 	return result, cached, nil
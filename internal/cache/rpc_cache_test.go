@@ -2,6 +2,7 @@ package cache
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 
@@ -83,7 +84,7 @@ func TestCreateRequestKeyGetTransactionReceipt(t *testing.T) {
 		Method: "eth_getTransactionReceipt",
 		Params: []any{"0x3a6f67beb73d07b1dd10c12de79767b6009f7b351ba1fe6282040aa6c57afef1"},
 	}
-	assert.Equal(t, "mainnet:eth_getTransactionReceipt:[0x3a6f67beb73d07b1dd10c12de79767b6009f7b351ba1fe6282040aa6c57afef1]", CreateRequestKey("mainnet", singleRequestBody))
+	assert.Equal(t, "v2:mainnet:eth_getTransactionReceipt:adfa101388a8c932d81ce142450d0f9087a12da5bec678cad14c7943bb22cf61", CreateRequestKey("mainnet", singleRequestBody))
 }
 
 func TestCreateRequestKeyGetBlockByHash(t *testing.T) {
@@ -91,7 +92,7 @@ func TestCreateRequestKeyGetBlockByHash(t *testing.T) {
 		Method: "eth_getBlockByHash",
 		Params: []any{"0x3a6f67beb73d07b1dd10c12de79767b6009f7b351ba1fe6282040aa6c57afef1", false},
 	}
-	assert.Equal(t, "mainnet:eth_getBlockByHash:[0x3a6f67beb73d07b1dd10c12de79767b6009f7b351ba1fe6282040aa6c57afef1,false]", CreateRequestKey("mainnet", singleRequestBody))
+	assert.Equal(t, "v2:mainnet:eth_getBlockByHash:2d797d028c8227400440ac7fd823378126150ca7fa8750055f89a4ef6f7223d8", CreateRequestKey("mainnet", singleRequestBody))
 }
 
 func TestHandleRequestParallel(t *testing.T) {
@@ -111,7 +112,8 @@ func TestHandleRequestParallel(t *testing.T) {
 	}
 	cacheKey := CreateRequestKey(chainName, reqBody)
 	expectedResult := json.RawMessage(`{"test":"value"}`)
-	expectedResultBytes, _ := cache.Marshal(expectedResult)
+	// Results are cached inside an entry.
+	expectedResultBytes, _ := cache.Marshal(json.RawMessage(`{"result":{"test":"value"}}`))
 
 	tests := []struct {
 		mockSetup      func()
@@ -129,8 +131,7 @@ func TestHandleRequestParallel(t *testing.T) {
 				redisReadClientMock.ExpectGet(cacheKey).SetVal(bytes.NewBuffer(expectedResultBytes).String())
 			},
 			after: func() {
-				cache.cacheRead.DeleteFromLocalCache(cacheKey)
-				cache.cacheWrite.DeleteFromLocalCache(cacheKey)
+				cache.DeleteFromLocalCache(cacheKey)
 			},
 			wantCached:  true,
 			wantError:   false,
@@ -143,8 +144,7 @@ func TestHandleRequestParallel(t *testing.T) {
 				redisWriteClientMock.ExpectSetNX(cacheKey, expectedResultBytes, ttl).SetVal(true)
 			},
 			after: func() {
-				cache.cacheRead.DeleteFromLocalCache(cacheKey)
-				cache.cacheWrite.DeleteFromLocalCache(cacheKey)
+				cache.DeleteFromLocalCache(cacheKey)
 			},
 			originResponse: &jsonrpc.SingleResponseBody{
 				Result: expectedResult,
@@ -182,7 +182,7 @@ func TestHandleRequestParallel(t *testing.T) {
 				return tt.originResponse, nil
 			}
 
-			result, cached, err := cache.HandleRequestParallel(chainName, reqBody, originFunc)
+			result, cached, err := cache.HandleRequestParallel(chainName, reqBody, originFunc, nil)
 
 			// Add small sleep to allow async cache set to complete
 			time.Sleep(5 * time.Millisecond)
@@ -216,3 +216,87 @@ func TestHandleRequestParallel(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleRequestParallel_MemoryBackend(t *testing.T) {
+	cacheConfig := config.ChainCacheConfig{
+		TTL:        time.Minute,
+		MethodTTLs: map[string]time.Duration{"eth_blockNumber": 20 * time.Millisecond},
+	}
+	cache := FromBackend(cacheConfig, NewMemoryBackend(10, 1000), metrics.NewContainer(config.TestChainName))
+
+	originCalls := 0
+	originFunc := func() (*jsonrpc.SingleResponseBody, error) {
+		originCalls++
+		return &jsonrpc.SingleResponseBody{Result: json.RawMessage(`"0x1"`)}, nil
+	}
+
+	for _, method := range []string{"eth_chainId", "eth_blockNumber"} {
+		reqBody := jsonrpc.SingleRequestBody{Method: method}
+
+		result, cached, err := cache.HandleRequestParallel("mainnet", reqBody, originFunc, nil)
+		assert.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, json.RawMessage(`"0x1"`), result)
+
+		// Allow the async cache set to complete.
+		time.Sleep(5 * time.Millisecond)
+
+		result, cached, err = cache.HandleRequestParallel("mainnet", reqBody, originFunc, nil)
+		assert.NoError(t, err)
+		assert.True(t, cached)
+		assert.Equal(t, json.RawMessage(`"0x1"`), result)
+	}
+
+	assert.Equal(t, 2, originCalls)
+
+	// The method's own TTL applies.
+	time.Sleep(20 * time.Millisecond)
+
+	_, cached, err := cache.HandleRequestParallel("mainnet", jsonrpc.SingleRequestBody{Method: "eth_blockNumber"}, originFunc, nil)
+	assert.NoError(t, err)
+	assert.False(t, cached)
+
+	_, cached, err = cache.HandleRequestParallel("mainnet", jsonrpc.SingleRequestBody{Method: "eth_chainId"}, originFunc, nil)
+	assert.NoError(t, err)
+	assert.True(t, cached)
+}
+
+func TestRedisBackend_SetNoExpiration(t *testing.T) {
+	redisReadClient, _ := redismock.NewClientMock()
+	redisWriteClient, redisWriteClientMock := redismock.NewClientMock()
+	cache := FromClients(config.ChainCacheConfig{TTL: time.Minute}, redisReadClient, redisWriteClient, metrics.NewContainer(config.TestChainName))
+
+	ctx := context.Background()
+	value := json.RawMessage(`{"test":"value"}`)
+	valueBytes, _ := cache.Marshal(value)
+
+	// Results that never expire are written to Redis without an expiry.
+	redisWriteClientMock.ExpectSetNX("key", valueBytes, 0).SetVal(true)
+
+	assert.NoError(t, cache.backend.Set(ctx, "key", value, NoExpiration))
+	assert.NoError(t, redisWriteClientMock.ExpectationsWereMet())
+
+	// They are also served from the local cache.
+	result, err := cache.backend.Get(ctx, "key")
+
+	assert.NoError(t, err)
+	assert.Equal(t, value, result)
+}
+
+func TestRedisBackend_Replace(t *testing.T) {
+	redisReadClient, _ := redismock.NewClientMock()
+	redisWriteClient, redisWriteClientMock := redismock.NewClientMock()
+	cache := FromClients(config.ChainCacheConfig{TTL: time.Minute}, redisReadClient, redisWriteClient, metrics.NewContainer(config.TestChainName))
+
+	ctx := context.Background()
+	value := json.RawMessage(`{"test":"value"}`)
+	valueBytes, _ := cache.Marshal(value)
+
+	// Replaced entries overwrite the existing one, instead of only being set if the key is missing.
+	redisWriteClientMock.ExpectSet("key", valueBytes, time.Minute).SetVal("OK")
+	redisWriteClientMock.ExpectSet("never", valueBytes, 0).SetVal("OK")
+
+	assert.NoError(t, cache.backend.Replace(ctx, "key", value, time.Minute))
+	assert.NoError(t, cache.backend.Replace(ctx, "never", value, NoExpiration))
+	assert.NoError(t, redisWriteClientMock.ExpectationsWereMet())
+}
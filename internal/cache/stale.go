@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+)
+
+// getStaleIfError returns how long entries with the given TTL are kept after they are no longer served, to be served
+// if the request cannot be routed. Entries that never expire are always served.
+func (c *RPCCache) getStaleIfError(ttl finalityTTL) time.Duration {
+	if ttl.ttl <= 0 {
+		return 0
+	}
+
+	return c.cacheConfig.StaleIfError
+}
+
+// GetStaleResponse returns the cached response of the request, even if it expired within the stale-if-error grace
+// period, to be served when the request could not be routed. Batches are only answered if every sub-request is.
+// Returns false if stale-if-error is not enabled or the response is not cached.
+func (c *RPCCache) GetStaleResponse(chainName string, requestBody jsonrpc.RequestBody) (jsonrpc.ResponseBody, bool) {
+	if c.cacheConfig.StaleIfError <= 0 {
+		return nil, false
+	}
+
+	subRequests := requestBody.GetSubRequests()
+	respBodies := make([]jsonrpc.SingleResponseBody, 0, len(subRequests))
+
+	for i := range subRequests {
+		subRequest := &subRequests[i]
+
+		// Notifications have no response.
+		if subRequest.ID == nil {
+			continue
+		}
+
+		result, ok := c.getStale(chainName, subRequest)
+		if !ok {
+			return nil, false
+		}
+
+		respBodies = append(respBodies, jsonrpc.SingleResponseBody{
+			ID:      *subRequest.ID,
+			JSONRPC: subRequest.JSONRPCVersion,
+			Result:  result,
+		})
+	}
+
+	if len(respBodies) == 0 {
+		return nil, false
+	}
+
+	for i := range subRequests {
+		c.metricsContainer.CacheStaleResponses.WithLabelValues(subRequests[i].Method).Inc()
+	}
+
+	if _, ok := requestBody.(*jsonrpc.BatchRequestBody); ok {
+		return &jsonrpc.BatchResponseBody{Responses: respBodies}, true
+	}
+
+	return &respBodies[0], true
+}
+
+func (c *RPCCache) getStale(chainName string, requestBody *jsonrpc.SingleRequestBody) (json.RawMessage, bool) {
+	if !c.ShouldCacheMethod(requestBody.Method) {
+		return nil, false
+	}
+
+	// Resolve "latest" as when the entry was cached. The head does not move while no upstream can be routed to.
+	key := CreateRequestKey(chainName, *c.ResolveLatestBlock(requestBody, 0))
+
+	// The entry may still be fresh if routing failed before the cache was checked.
+	entry, err := c.getEntry(context.Background(), key, requestBody.Method)
+
+	return entry.Result, err == nil
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStaleCache(staleIfError time.Duration) *RPCCache {
+	cacheConfig := config.ChainCacheConfig{
+		MethodTTLs:   map[string]time.Duration{"eth_getTransactionReceipt": time.Minute},
+		StaleIfError: staleIfError,
+	}
+
+	return FromBackend(cacheConfig, NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+}
+
+// setExpiringEntry caches the result of the request for a millisecond, and waits for it to only be kept to be served
+// if the request cannot be routed.
+func setExpiringEntry(t *testing.T, cache *RPCCache, requestBody jsonrpc.SingleRequestBody, result string) {
+	t.Helper()
+
+	key := CreateRequestKey("mainnet", requestBody)
+	cache.setAsync(context.Background(), pendingEntry{key: key, ttl: finalityTTL{ttl: time.Millisecond}}, requestBody.Method, json.RawMessage(result))
+
+	assert.Eventually(t, func() bool {
+		entry, err := cache.getEntry(context.Background(), key, requestBody.Method)
+		return err == nil && !entry.isServed(time.Now())
+	}, time.Second, time.Millisecond)
+}
+
+func TestRPCCache_GetStaleResponse(t *testing.T) {
+	cache := newTestStaleCache(time.Minute)
+	requestBody := newTestSubRequest(1, "eth_getTransactionReceipt", "0x01")
+
+	_, ok := cache.GetStaleResponse("mainnet", &requestBody)
+	assert.False(t, ok)
+
+	setExpiringEntry(t, cache, requestBody, `"receipt"`)
+
+	requestBody.ID = lo.ToPtr[int64](5)
+	respBody, ok := cache.GetStaleResponse("mainnet", &requestBody)
+
+	assert.True(t, ok)
+	assert.Equal(t, &jsonrpc.SingleResponseBody{ID: 5, JSONRPC: "2.0", Result: json.RawMessage(`"receipt"`)}, respBody)
+}
+
+func TestRPCCache_GetStaleResponse_Batch(t *testing.T) {
+	cache := newTestStaleCache(time.Minute)
+
+	first := newTestSubRequest(1, "eth_getTransactionReceipt", "0x01")
+	second := newTestSubRequest(2, "eth_getTransactionReceipt", "0x02")
+
+	setExpiringEntry(t, cache, first, `"first"`)
+
+	// Batches are only answered if every sub-request is.
+	_, ok := cache.GetStaleResponse("mainnet", &jsonrpc.BatchRequestBody{Requests: []jsonrpc.SingleRequestBody{first, second}})
+	assert.False(t, ok)
+
+	setExpiringEntry(t, cache, second, `"second"`)
+
+	respBody, ok := cache.GetStaleResponse("mainnet", &jsonrpc.BatchRequestBody{Requests: []jsonrpc.SingleRequestBody{second, first}})
+
+	assert.True(t, ok)
+	assert.Equal(t, &jsonrpc.BatchResponseBody{Responses: []jsonrpc.SingleResponseBody{
+		{ID: 2, JSONRPC: "2.0", Result: json.RawMessage(`"second"`)},
+		{ID: 1, JSONRPC: "2.0", Result: json.RawMessage(`"first"`)},
+	}}, respBody)
+}
+
+func TestRPCCache_GetStaleResponse_Disabled(t *testing.T) {
+	cache := newTestStaleCache(0)
+	requestBody := newTestSubRequest(1, "eth_getTransactionReceipt", "0x01")
+
+	key := CreateRequestKey("mainnet", requestBody)
+	cache.setAsync(context.Background(), pendingEntry{key: key, ttl: finalityTTL{ttl: time.Minute}}, requestBody.Method, json.RawMessage(`"receipt"`))
+
+	assert.Eventually(t, func() bool {
+		_, err := cache.backend.Get(context.Background(), key)
+		return err == nil
+	}, time.Second, time.Millisecond)
+
+	// Without stale-if-error, not even fresh entries are served when routing fails.
+	_, ok := cache.GetStaleResponse("mainnet", &requestBody)
+	assert.False(t, ok)
+
+	// Without stale-if-error, the entry does not outlive its TTL.
+	entry, err := cache.getEntry(context.Background(), key, requestBody.Method)
+	assert.NoError(t, err)
+	assert.Equal(t, cachedEntry{Result: json.RawMessage(`"receipt"`)}, entry)
+}
+
+func TestHandleRequestParallel_StaleIfError_ReplacesStaleEntry(t *testing.T) {
+	cache := newTestStaleCache(time.Minute)
+	requestBody := newTestSubRequest(1, "eth_getTransactionReceipt", "0x01")
+	key := CreateRequestKey("mainnet", requestBody)
+
+	setExpiringEntry(t, cache, requestBody, `"stale"`)
+
+	originFunc := func() (*jsonrpc.SingleResponseBody, error) {
+		return &jsonrpc.SingleResponseBody{Result: json.RawMessage(`"fresh"`)}, nil
+	}
+
+	// The stale entry is not served to routed requests, and is replaced by the new result.
+	result, cached, err := cache.HandleRequestParallel("mainnet", requestBody, originFunc, nil)
+
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, json.RawMessage(`"fresh"`), result)
+
+	assert.Eventually(t, func() bool {
+		entry, err := cache.getEntry(context.Background(), key, requestBody.Method)
+		return err == nil && string(entry.Result) == `"fresh"` && entry.isFresh(time.Now())
+	}, time.Second, time.Millisecond)
+}
@@ -3,8 +3,14 @@ package checks
 import (
 	"context"
 	"errors"
+	"math/big"
+	"math/rand/v2"
+	"sync"
+	"time"
 
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
 	internalTypes "github.com/satsuma-data/node-gateway/internal/types"
 
 	"github.com/satsuma-data/node-gateway/internal/client"
@@ -13,21 +19,41 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	WSResubscribeInitialBackoff = time.Second
+	WSResubscribeMaxBackoff     = time.Minute
+	WSResubscribeJitterFactor   = 0.25 // Each backoff is randomized by up to this fraction in either direction.
+)
+
 type BlockHeightCheck struct {
-	httpClient          client.EthClient
-	webSocketError      error
-	blockHeightError    error
-	clientGetter        client.EthClientGetter
-	upstreamConfig      *conf.UpstreamConfig
-	blockHeightObserver BlockHeightObserver
-	metricsContainer    *metrics.Container
-	logger              *zap.Logger
-	blockHeight         uint64
+	wsFallbackStartedAt       time.Time
+	resubscribeChannel        chan struct{}
+	httpClient                client.EthClient
+	wsClient                  client.EthClient
+	webSocketError            error
+	blockHeightError          error
+	clientGetter              client.EthClientGetter
+	upstreamConfig            *conf.UpstreamConfig
+	blockHeightObserver       BlockHeightObserver
+	metricsContainer          *metrics.Container
+	logger                    *zap.Logger
+	resubscribeInitialBackoff time.Duration
+	resubscribeMaxBackoff     time.Duration
+	blockHeight               uint64
+	safeBlockHeight           uint64
+	finalizedBlockHeight      uint64
+	// Guards the Websockets state and the block height and error, which are written by the newHeads subscription.
+	mu                  sync.RWMutex
 	useWSForBlockHeight bool
+	shouldCheckFinality bool
 }
 
 type BlockHeightObserver interface {
 	ProcessBlockHeightUpdate(groupID string, upstreamID string, blockHeight uint64)
+	ProcessSafeBlockHeightUpdate(groupID string, upstreamID string, blockHeight uint64)
+	ProcessFinalizedBlockHeightUpdate(groupID string, upstreamID string, blockHeight uint64)
+	ProcessBlockTimestampUpdate(groupID string, upstreamID string, blockTimestamp time.Time)
+	ProcessBlockHeaderUpdate(groupID string, upstreamID string, header *ethTypes.Header)
 	ProcessErrorUpdate(groupID string, upstreamID string, err error)
 }
 
@@ -38,24 +64,39 @@ func NewBlockHeightChecker(
 	metricsContainer *metrics.Container,
 	logger *zap.Logger,
 ) internalTypes.BlockHeightChecker {
-	c := &BlockHeightCheck{
-		upstreamConfig:      config,
-		clientGetter:        clientGetter,
-		blockHeightObserver: blockHeightObserver,
-		metricsContainer:    metricsContainer,
-		logger:              logger,
-	}
+	c := newBlockHeightCheck(config, clientGetter, blockHeightObserver, metricsContainer, logger)
 
 	c.Initialize()
 
 	return c
 }
 
+func newBlockHeightCheck(
+	config *conf.UpstreamConfig,
+	clientGetter client.EthClientGetter,
+	blockHeightObserver BlockHeightObserver,
+	metricsContainer *metrics.Container,
+	logger *zap.Logger,
+) *BlockHeightCheck {
+	return &BlockHeightCheck{
+		upstreamConfig:            config,
+		clientGetter:              clientGetter,
+		blockHeightObserver:       blockHeightObserver,
+		metricsContainer:          metricsContainer,
+		logger:                    logger,
+		shouldCheckFinality:       config.HealthCheckConfig.CheckFinality != nil && *config.HealthCheckConfig.CheckFinality,
+		resubscribeChannel:        make(chan struct{}, 1),
+		resubscribeInitialBackoff: WSResubscribeInitialBackoff,
+		resubscribeMaxBackoff:     WSResubscribeMaxBackoff,
+	}
+}
+
 func (c *BlockHeightCheck) Initialize() {
 	c.logger.Debug("Initializing BlockHeightCheck.", zap.Any("config", c.upstreamConfig))
 
 	if err := c.initializeWebsockets(); err != nil {
 		c.logger.Error("Encountered error when calling SubscribeNewHead over Websockets, falling back to using HTTP polling for BlockHeightCheck.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("WSURL", c.upstreamConfig.WSURL))
+		c.startWSFallback()
 	}
 
 	c.initializeHTTP()
@@ -68,6 +109,8 @@ func (c *BlockHeightCheck) initializeWebsockets() error {
 
 		c.useWSForBlockHeight = true
 
+		go c.superviseNewHeadSubscription()
+
 		return c.subscribeNewHead()
 	}
 
@@ -89,7 +132,7 @@ func (c *BlockHeightCheck) initializeHTTP() {
 }
 
 func (c *BlockHeightCheck) RunCheck() {
-	if !c.useWSForBlockHeight || (c.useWSForBlockHeight && c.webSocketError != nil) {
+	if !c.useWSForBlockHeight || (c.useWSForBlockHeight && c.getWebSocketError() != nil) {
 		if c.httpClient == nil {
 			c.initializeHTTP()
 		}
@@ -98,6 +141,15 @@ func (c *BlockHeightCheck) RunCheck() {
 	} else {
 		c.logger.Debug("Not running BlockHeightCheck over HTTP, Websockets subscription still active.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("WSURL", c.upstreamConfig.WSURL))
 	}
+
+	// The newHeads subscription only reports the latest head, so the safe and finalized heads are always polled over HTTP.
+	if c.shouldCheckFinality {
+		if c.httpClient == nil {
+			c.initializeHTTP()
+		}
+
+		c.runFinalityCheckHTTP()
+	}
 }
 
 func (c *BlockHeightCheck) runCheckHTTP() {
@@ -106,23 +158,25 @@ func (c *BlockHeightCheck) runCheckHTTP() {
 	}
 
 	runCheck := func() {
-		ctx, cancel := context.WithTimeout(context.Background(), RPCRequestTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), c.upstreamConfig.HealthCheckConfig.GetTimeout())
 		defer cancel()
 
 		header, err := c.httpClient.HeaderByNumber(ctx, nil)
 
-		if c.blockHeightError = err; c.blockHeightError != nil {
-			c.logger.Debug("BlockHeightCheck request failed.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("httpURL", c.upstreamConfig.HTTPURL), zap.Error(c.blockHeightError))
+		if c.setBlockHeightError(err); err != nil {
+			c.logger.Debug("BlockHeightCheck request failed.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("httpURL", c.upstreamConfig.HTTPURL), zap.Error(err))
 			c.metricsContainer.BlockHeightCheckErrors.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, metrics.HTTPRequest).Inc()
 
 			return
 		}
 
 		c.SetBlockHeight(header.Number.Uint64())
+		c.setBlockTimestamp(header.Time)
+		c.setBlockHeader(header)
 
-		c.metricsContainer.BlockHeight.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL).Set(float64(c.blockHeight))
+		c.metricsContainer.BlockHeight.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL).Set(float64(header.Number.Uint64()))
 
-		c.logger.Debug("Ran BlockHeightCheck over HTTP.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("httpURL", c.upstreamConfig.HTTPURL), zap.Uint64("blockHeight", c.blockHeight))
+		c.logger.Debug("Ran BlockHeightCheck over HTTP.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("httpURL", c.upstreamConfig.HTTPURL), zap.Uint64("blockHeight", header.Number.Uint64()))
 	}
 
 	runCheckWithMetrics(runCheck,
@@ -130,9 +184,49 @@ func (c *BlockHeightCheck) runCheckHTTP() {
 		c.metricsContainer.BlockHeightCheckDuration.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL))
 }
 
+// runFinalityCheckHTTP polls the safe and finalized heads. Failures are recorded but do not affect IsPassing, since
+// many chains do not support these block tags. An upstream whose finality heads are unknown or stale will simply not
+// be routed requests that reference them.
+func (c *BlockHeightCheck) runFinalityCheckHTTP() {
+	if c.httpClient == nil {
+		return
+	}
+
+	for _, tag := range metadata.FinalityBlockTags {
+		blockNumber := rpc.FinalizedBlockNumber
+		if tag == metadata.SafeBlockTag {
+			blockNumber = rpc.SafeBlockNumber
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.upstreamConfig.HealthCheckConfig.GetTimeout())
+		header, err := c.httpClient.HeaderByNumber(ctx, big.NewInt(blockNumber.Int64()))
+
+		cancel()
+
+		if err != nil {
+			c.logger.Debug("BlockHeightCheck finality request failed.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("httpURL", c.upstreamConfig.HTTPURL), zap.Any("blockTag", tag), zap.Error(err))
+			c.metricsContainer.BlockHeightCheckErrors.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, metrics.HTTPFinalityRequest).Inc()
+
+			continue
+		}
+
+		if tag == metadata.SafeBlockTag {
+			c.SetSafeBlockHeight(header.Number.Uint64())
+		} else {
+			c.SetFinalizedBlockHeight(header.Number.Uint64())
+		}
+	}
+
+	c.logger.Debug("Ran BlockHeightCheck finality request over HTTP.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("httpURL", c.upstreamConfig.HTTPURL), zap.Uint64("safeBlockHeight", c.safeBlockHeight), zap.Uint64("finalizedBlockHeight", c.finalizedBlockHeight))
+}
+
 func (c *BlockHeightCheck) IsPassing(maxBlockHeight uint64) bool {
-	if c.blockHeightError != nil || c.blockHeight < maxBlockHeight {
-		c.logger.Debug("BlockHeightCheck is not passing.", zap.String("upstreamID", c.upstreamConfig.ID), zap.Any("blockHeight", c.blockHeight), zap.Error(c.blockHeightError))
+	c.mu.RLock()
+	blockHeight, blockHeightError := c.blockHeight, c.blockHeightError
+	c.mu.RUnlock()
+
+	if blockHeightError != nil || blockHeight < maxBlockHeight {
+		c.logger.Debug("BlockHeightCheck is not passing.", zap.String("upstreamID", c.upstreamConfig.ID), zap.Any("blockHeight", blockHeight), zap.Error(blockHeightError))
 
 		return false
 	}
@@ -140,32 +234,177 @@ func (c *BlockHeightCheck) IsPassing(maxBlockHeight uint64) bool {
 	return true
 }
 
+// getObservedGroupID returns the group the upstream's heads are tracked under. Reference-only upstreams are tracked
+// outside of any group, so that they raise the global max heights without holding routed upstreams in the same group
+// to their heads.
+func (c *BlockHeightCheck) getObservedGroupID() string {
+	if c.upstreamConfig.ReferenceOnly {
+		return metadata.ReferenceGroupID
+	}
+
+	return c.upstreamConfig.GroupID
+}
+
 func (c *BlockHeightCheck) GetBlockHeight() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return c.blockHeight
 }
 
 func (c *BlockHeightCheck) SetBlockHeight(blockHeight uint64) {
+	c.mu.Lock()
 	c.blockHeight = blockHeight
-	c.blockHeightObserver.ProcessBlockHeightUpdate(c.upstreamConfig.GroupID, c.upstreamConfig.ID, blockHeight)
+	c.mu.Unlock()
+
+	c.blockHeightObserver.ProcessBlockHeightUpdate(c.getObservedGroupID(), c.upstreamConfig.ID, blockHeight)
+}
+
+// setBlockTimestamp records the timestamp (in seconds since the epoch) of the latest block, which is how stale heads
+// are detected when all upstreams stall at the same height.
+func (c *BlockHeightCheck) setBlockTimestamp(blockTimestamp uint64) {
+	c.blockHeightObserver.ProcessBlockTimestampUpdate(c.getObservedGroupID(), c.upstreamConfig.ID, time.Unix(int64(blockTimestamp), 0)) //nolint:gosec // Block timestamps fit in an int64.
+	c.metricsContainer.HeadBlockTimestamp.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL).Set(float64(blockTimestamp))
+}
+
+// setBlockHeader records the header of the latest block, which is how reorgs are detected.
+func (c *BlockHeightCheck) setBlockHeader(header *ethTypes.Header) {
+	c.blockHeightObserver.ProcessBlockHeaderUpdate(c.getObservedGroupID(), c.upstreamConfig.ID, header)
+}
+
+func (c *BlockHeightCheck) GetSafeBlockHeight() uint64 {
+	return c.safeBlockHeight
+}
+
+func (c *BlockHeightCheck) SetSafeBlockHeight(blockHeight uint64) {
+	c.safeBlockHeight = blockHeight
+	c.blockHeightObserver.ProcessSafeBlockHeightUpdate(c.getObservedGroupID(), c.upstreamConfig.ID, blockHeight)
+	c.metricsContainer.SafeBlockHeight.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL).Set(float64(blockHeight))
+}
+
+func (c *BlockHeightCheck) GetFinalizedBlockHeight() uint64 {
+	return c.finalizedBlockHeight
+}
+
+func (c *BlockHeightCheck) SetFinalizedBlockHeight(blockHeight uint64) {
+	c.finalizedBlockHeight = blockHeight
+	c.blockHeightObserver.ProcessFinalizedBlockHeightUpdate(c.getObservedGroupID(), c.upstreamConfig.ID, blockHeight)
+	c.metricsContainer.FinalizedBlockHeight.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL).Set(float64(blockHeight))
+
+	if headBlockHeight := c.GetBlockHeight(); headBlockHeight >= blockHeight {
+		c.metricsContainer.FinalityLag.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL).Set(float64(headBlockHeight - blockHeight))
+	}
 }
 
 func (c *BlockHeightCheck) GetError() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return c.blockHeightError
 }
 
 func (c *BlockHeightCheck) setError(err error) {
+	c.setBlockHeightError(err)
+	c.blockHeightObserver.ProcessErrorUpdate(c.getObservedGroupID(), c.upstreamConfig.ID, err)
+}
+
+func (c *BlockHeightCheck) setBlockHeightError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.blockHeightError = err
-	c.blockHeightObserver.ProcessErrorUpdate(c.upstreamConfig.GroupID, c.upstreamConfig.ID, err)
+}
+
+func (c *BlockHeightCheck) getWebSocketError() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.webSocketError
+}
+
+func (c *BlockHeightCheck) setWebSocketError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.webSocketError = err
+}
+
+// startWSFallback records that the block height is polled over HTTP until the newHeads subscription recovers, and
+// wakes up the subscription supervisor.
+func (c *BlockHeightCheck) startWSFallback() {
+	c.mu.Lock()
+	if c.wsFallbackStartedAt.IsZero() {
+		c.wsFallbackStartedAt = time.Now()
+		c.metricsContainer.WSFallback.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.WSURL).Set(1)
+	}
+	c.mu.Unlock()
+
+	// The channel is buffered, so a resubscription is already pending if the send would block.
+	select {
+	case c.resubscribeChannel <- struct{}{}:
+	default:
+	}
+}
+
+// stopWSFallback is called once a new head is received over Websockets, at which point HTTP polling stops.
+func (c *BlockHeightCheck) stopWSFallback() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.wsFallbackStartedAt.IsZero() {
+		return
+	}
+
+	c.logger.Info("NewHead Websockets subscription recovered, no longer polling over HTTP for BlockHeightCheck.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("WSURL", c.upstreamConfig.WSURL))
+	c.metricsContainer.WSFallbackDuration.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.WSURL).Add(time.Since(c.wsFallbackStartedAt).Seconds())
+	c.metricsContainer.WSFallback.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.WSURL).Set(0)
+	c.wsFallbackStartedAt = time.Time{}
+}
+
+// superviseNewHeadSubscription resubscribes to newHeads, with exponential backoff, every time the subscription fails.
+func (c *BlockHeightCheck) superviseNewHeadSubscription() {
+	for range c.resubscribeChannel {
+		backoff := c.resubscribeInitialBackoff
+
+		for {
+			time.Sleep(withJitter(backoff, WSResubscribeJitterFactor))
+
+			c.logger.Debug("Resubscribing over Websockets for BlockHeightCheck.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("WSURL", c.upstreamConfig.WSURL), zap.Duration("backoff", backoff))
+			c.metricsContainer.WSResubscribeAttempts.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.WSURL).Inc()
+
+			err := c.subscribeNewHead()
+			if err == nil {
+				break
+			}
+
+			c.logger.Debug("Failed to resubscribe over Websockets for BlockHeightCheck.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("WSURL", c.upstreamConfig.WSURL), zap.Error(err))
+
+			backoff = min(2*backoff, c.resubscribeMaxBackoff)
+		}
+	}
+}
+
+// withJitter randomizes the given duration by up to the given fraction of it in either direction.
+func withJitter(d time.Duration, jitterFactor float64) time.Duration {
+	jitter := time.Duration(float64(d) * jitterFactor)
+	if jitter <= 0 {
+		return d
+	}
+
+	return d - jitter + rand.N(2*jitter)
 }
 
 func (c *BlockHeightCheck) subscribeNewHead() error {
 	onNewHead := func(header *ethTypes.Header) {
 		c.SetBlockHeight(header.Number.Uint64())
+		c.setBlockTimestamp(header.Time)
+		c.setBlockHeader(header)
 
-		c.logger.Debug("Received blockheight over Websockets.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("httpURL", c.upstreamConfig.HTTPURL), zap.Uint64("blockHeight", c.blockHeight))
-		c.metricsContainer.BlockHeight.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL).Set(float64(c.blockHeight))
+		c.logger.Debug("Received blockheight over Websockets.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("httpURL", c.upstreamConfig.HTTPURL), zap.Uint64("blockHeight", header.Number.Uint64()))
+		c.metricsContainer.BlockHeight.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL).Set(float64(header.Number.Uint64()))
 
-		c.webSocketError = nil
+		c.stopWSFallback()
+		c.setWebSocketError(nil)
 		c.setError(nil)
 	}
 
@@ -173,33 +412,55 @@ func (c *BlockHeightCheck) subscribeNewHead() error {
 		c.logger.Error("Encountered error in NewHead Websockets subscription.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("WSURL", c.upstreamConfig.WSURL))
 
 		c.metricsContainer.BlockHeightCheckErrors.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, metrics.WSError).Inc()
-		c.webSocketError = errors.New(failure)
-		c.setError(c.webSocketError)
+		webSocketError := errors.New(failure)
+		c.setWebSocketError(webSocketError)
+		c.setError(webSocketError)
+		c.startWSFallback()
 	}
 
+	// The previous subscription has failed by now, so its connection is no longer needed.
+	c.closeWSClient()
+
 	wsClient, err := c.clientGetter(c.upstreamConfig.WSURL, &c.upstreamConfig.BasicAuthConfig, &c.upstreamConfig.RequestHeadersConfig)
 	if err != nil {
-		c.webSocketError = err
+		c.setWebSocketError(err)
 		return err
 	}
 
-	if err = subscribeNewHeads(wsClient, &newHeadHandler{onNewHead: onNewHead, onError: onError}); err != nil {
+	if err = subscribeNewHeads(wsClient, c.upstreamConfig.HealthCheckConfig.GetTimeout(), &newHeadHandler{onNewHead: onNewHead, onError: onError}); err != nil {
 		c.metricsContainer.BlockHeightCheckErrors.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, metrics.WSSubscribe).Inc()
-		c.webSocketError = err
+		c.setWebSocketError(err)
+		wsClient.Close()
 
 		return err
 	}
 
+	c.mu.Lock()
+	c.wsClient = wsClient
+	c.mu.Unlock()
+
 	return nil
 }
 
+// closeWSClient closes the client of the current newHeads subscription, if any.
+func (c *BlockHeightCheck) closeWSClient() {
+	c.mu.Lock()
+	wsClient := c.wsClient
+	c.wsClient = nil
+	c.mu.Unlock()
+
+	if wsClient != nil {
+		wsClient.Close()
+	}
+}
+
 type newHeadHandler struct {
 	onNewHead func(header *ethTypes.Header)
 	onError   func(failure string)
 }
 
-func subscribeNewHeads(wsClient client.EthClient, handler *newHeadHandler) error {
-	ctx, cancel := context.WithTimeout(context.Background(), RPCRequestTimeout)
+func subscribeNewHeads(wsClient client.EthClient, timeout time.Duration, handler *newHeadHandler) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	ch := make(chan *ethTypes.Header)
@@ -217,10 +478,15 @@ func subscribeNewHeads(wsClient client.EthClient, handler *newHeadHandler) error
 				handler.onNewHead(header)
 
 			case err := <-subscription.Err():
+				// The channel is also closed without an error if the connection is closed, in which case the
+				// subscription is gone all the same.
+				failure := "newHeads subscription closed"
 				if err != nil {
-					handler.onError(err.Error())
+					failure = err.Error()
 				}
 
+				handler.onError(failure)
+
 				return
 			}
 		}
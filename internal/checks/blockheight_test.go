@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/satsuma-data/node-gateway/internal/metadata"
 	"github.com/satsuma-data/node-gateway/internal/metrics"
 	"go.uber.org/zap"
 
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/samber/lo"
 	"github.com/satsuma-data/node-gateway/internal/client"
 	"github.com/satsuma-data/node-gateway/internal/config"
 	"github.com/satsuma-data/node-gateway/internal/mocks"
@@ -35,7 +38,7 @@ func TestBlockHeightChecker_WS(t *testing.T) {
 		return ethClient, nil
 	}
 
-	chainMetadataStore := metadata.NewChainMetadataStore()
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
 	chainMetadataStore.Start()
 
 	checker := NewBlockHeightChecker(defaultUpstreamConfig, mockEthClientGetter, chainMetadataStore, metrics.NewContainer(config.TestChainName), zap.L())
@@ -47,7 +50,7 @@ func TestBlockHeightChecker_WS(t *testing.T) {
 
 	// Websockets encounters an error. Now RunCheck should use HTTP.
 	//nolint:errcheck // ignore error
-	checker.(*BlockHeightCheck).webSocketError = errors.New("some error")
+	checker.(*BlockHeightCheck).setWebSocketError(errors.New("some error"))
 	assert.False(t, checker.IsPassing(maxBlockHeight))
 
 	checker.RunCheck()
@@ -59,17 +62,19 @@ func TestBlockHeightChecker_WSSubscribeFailed(t *testing.T) {
 	ethClient := mocks.NewEthClient(t)
 	ethClient.On("SubscribeNewHead", mock.Anything, mock.Anything).Return(nil, errors.New("some error"))
 	ethClient.On("HeaderByNumber", mock.Anything, mock.Anything).Return(&types.Header{Number: big.NewInt(int64(50000))}, nil)
+	ethClient.On("Close").Return()
 
 	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
 		return ethClient, nil
 	}
 
-	chainMetadataStore := metadata.NewChainMetadataStore()
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
 	chainMetadataStore.Start()
 
 	checker := NewBlockHeightChecker(defaultUpstreamConfig, mockEthClientGetter, chainMetadataStore, metrics.NewContainer(config.TestChainName), zap.L())
 
 	ethClient.AssertNumberOfCalls(t, "SubscribeNewHead", 1)
+	ethClient.AssertNumberOfCalls(t, "Close", 1)
 	assert.False(t, checker.IsPassing(maxBlockHeight))
 
 	checker.RunCheck()
@@ -77,6 +82,87 @@ func TestBlockHeightChecker_WSSubscribeFailed(t *testing.T) {
 	assert.True(t, checker.IsPassing(maxBlockHeight))
 }
 
+type failingSubscription struct {
+	errChannel chan error
+}
+
+func (m *failingSubscription) Unsubscribe() {}
+
+func (m *failingSubscription) Err() <-chan error { return m.errChannel }
+
+func TestBlockHeightChecker_WSResubscribe(t *testing.T) {
+	subscription := &failingSubscription{errChannel: make(chan error)}
+	resubscribed := make(chan chan<- *types.Header, 1)
+
+	ethClient := mocks.NewEthClient(t)
+	ethClient.On("SubscribeNewHead", mock.Anything, mock.Anything).Return(subscription, nil).Once()
+	ethClient.On("SubscribeNewHead", mock.Anything, mock.Anything).Return(nil, errors.New("some error")).Once()
+	ethClient.On("SubscribeNewHead", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		resubscribed <- args.Get(1).(chan<- *types.Header) //nolint:errcheck // ignore error
+	}).Return(&mockSubscription{}, nil).Once()
+	ethClient.On("HeaderByNumber", mock.Anything, mock.Anything).Return(&types.Header{Number: big.NewInt(int64(maxBlockHeight))}, nil)
+	ethClient.On("Close").Return()
+
+	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
+		return ethClient, nil
+	}
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	chainMetadataStore.Start()
+
+	checker := newBlockHeightCheck(defaultUpstreamConfig, mockEthClientGetter, chainMetadataStore, metrics.NewContainer(config.TestChainName), zap.L())
+	checker.resubscribeInitialBackoff = time.Millisecond
+	checker.resubscribeMaxBackoff = time.Millisecond
+	checker.Initialize()
+
+	ethClient.AssertNumberOfCalls(t, "SubscribeNewHead", 1)
+
+	// The subscription fails, so RunCheck falls back to HTTP while the check resubscribes in the background.
+	subscription.errChannel <- errors.New("connection reset")
+
+	assert.Eventually(t, func() bool {
+		return checker.getWebSocketError() != nil
+	}, time.Second, time.Millisecond)
+
+	checker.RunCheck()
+	ethClient.AssertNumberOfCalls(t, "HeaderByNumber", 1)
+
+	// The first resubscription fails, the second one (after a backoff) succeeds.
+	var headerChannel chan<- *types.Header
+	select {
+	case headerChannel = <-resubscribed:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the resubscription.")
+	}
+
+	// The clients of the failed subscription and of the failed resubscription are closed before redialing.
+	ethClient.AssertNumberOfCalls(t, "Close", 2)
+
+	// Polling over HTTP continues until a new head is received over Websockets.
+	checker.RunCheck()
+	ethClient.AssertNumberOfCalls(t, "HeaderByNumber", 2)
+
+	headerChannel <- &types.Header{Number: big.NewInt(int64(maxBlockHeight + 1))}
+
+	assert.Eventually(t, func() bool {
+		return checker.GetBlockHeight() == maxBlockHeight+1
+	}, time.Second, time.Millisecond)
+
+	checker.RunCheck()
+	ethClient.AssertNumberOfCalls(t, "HeaderByNumber", 2)
+	ethClient.AssertNumberOfCalls(t, "SubscribeNewHead", 3)
+}
+
+func TestWithJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := withJitter(time.Second, 0.25)
+		assert.GreaterOrEqual(t, d, 750*time.Millisecond)
+		assert.Less(t, d, 1250*time.Millisecond)
+	}
+
+	assert.Equal(t, time.Second, withJitter(time.Second, 0))
+}
+
 func TestBlockHeightChecker_HTTP(t *testing.T) {
 	for _, upstreamConfig := range []*config.UpstreamConfig{
 		{
@@ -99,7 +185,7 @@ func TestBlockHeightChecker_HTTP(t *testing.T) {
 			return ethClient, nil
 		}
 
-		chainMetadataStore := metadata.NewChainMetadataStore()
+		chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
 		chainMetadataStore.Start()
 
 		checker := NewBlockHeightChecker(upstreamConfig, mockEthClientGetter, chainMetadataStore, metrics.NewContainer(config.TestChainName), zap.L())
@@ -111,16 +197,137 @@ func TestBlockHeightChecker_HTTP(t *testing.T) {
 	}
 }
 
+func TestBlockHeightChecker_Finality(t *testing.T) {
+	ethClient := mocks.NewEthClient(t)
+	ethClient.On("SubscribeNewHead", mock.Anything, mock.Anything).Return(&mockSubscription{}, nil)
+	ethClient.On("HeaderByNumber", mock.Anything, big.NewInt(rpc.SafeBlockNumber.Int64())).Return(&types.Header{Number: big.NewInt(maxBlockHeight - 32)}, nil)
+	ethClient.On("HeaderByNumber", mock.Anything, big.NewInt(rpc.FinalizedBlockNumber.Int64())).Return(&types.Header{Number: big.NewInt(maxBlockHeight - 64)}, nil)
+
+	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
+		return ethClient, nil
+	}
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	chainMetadataStore.Start()
+
+	upstreamConfig := &config.UpstreamConfig{
+		ID:      "eth_mainnet",
+		HTTPURL: "http://alchemy",
+		WSURL:   "wss://alchemy",
+		GroupID: "primary",
+		HealthCheckConfig: config.HealthCheckConfig{
+			CheckFinality: lo.ToPtr(true),
+		},
+	}
+
+	checker := NewBlockHeightChecker(upstreamConfig, mockEthClientGetter, chainMetadataStore, metrics.NewContainer(config.TestChainName), zap.L())
+
+	// The latest head comes from the Websockets subscription, but the finality heads are still polled over HTTP.
+	checker.RunCheck()
+	ethClient.AssertNumberOfCalls(t, "HeaderByNumber", 2)
+
+	//nolint:errcheck // ignore error
+	blockHeightCheck := checker.(*BlockHeightCheck)
+	assert.Equal(t, uint64(maxBlockHeight-32), blockHeightCheck.GetSafeBlockHeight())
+	assert.Equal(t, uint64(maxBlockHeight-64), blockHeightCheck.GetFinalizedBlockHeight())
+
+	status := chainMetadataStore.GetBlockHeightStatus("primary", "eth_mainnet")
+	assert.Equal(t, uint64(maxBlockHeight-32), status.SafeBlockHeight)
+	assert.Equal(t, uint64(maxBlockHeight-64), status.FinalizedBlockHeight)
+}
+
+func TestBlockHeightChecker_FinalityNotSupported(t *testing.T) {
+	ethClient := mocks.NewEthClient(t)
+	ethClient.On("HeaderByNumber", mock.Anything, (*big.Int)(nil)).Return(&types.Header{Number: big.NewInt(maxBlockHeight)}, nil)
+	ethClient.On("HeaderByNumber", mock.Anything, mock.Anything).Return(nil, errors.New("invalid block tag"))
+
+	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
+		return ethClient, nil
+	}
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	chainMetadataStore.Start()
+
+	upstreamConfig := &config.UpstreamConfig{
+		ID:      "eth_mainnet",
+		HTTPURL: "http://alchemy",
+		HealthCheckConfig: config.HealthCheckConfig{
+			CheckFinality: lo.ToPtr(true),
+		},
+	}
+
+	checker := NewBlockHeightChecker(upstreamConfig, mockEthClientGetter, chainMetadataStore, metrics.NewContainer(config.TestChainName), zap.L())
+
+	// Failing to retrieve the finality heads does not fail the check.
+	checker.RunCheck()
+	ethClient.AssertNumberOfCalls(t, "HeaderByNumber", 3)
+	assert.True(t, checker.IsPassing(maxBlockHeight))
+	assert.Equal(t, uint64(0), checker.(*BlockHeightCheck).GetFinalizedBlockHeight()) //nolint:errcheck // ignore error
+}
+
+func TestBlockHeightChecker_BlockTimestamp(t *testing.T) {
+	blockTimestamp := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	ethClient := mocks.NewEthClient(t)
+	ethClient.On("HeaderByNumber", mock.Anything, (*big.Int)(nil)).Return(&types.Header{Number: big.NewInt(maxBlockHeight), Time: uint64(blockTimestamp.Unix())}, nil)
+
+	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
+		return ethClient, nil
+	}
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	chainMetadataStore.Start()
+
+	upstreamConfig := &config.UpstreamConfig{
+		ID:      "eth_mainnet",
+		HTTPURL: "http://alchemy",
+	}
+
+	checker := NewBlockHeightChecker(upstreamConfig, mockEthClientGetter, chainMetadataStore, metrics.NewContainer(config.TestChainName), zap.L())
+	checker.RunCheck()
+
+	status := chainMetadataStore.GetBlockHeightStatus(upstreamConfig.GroupID, upstreamConfig.ID)
+	assert.Equal(t, blockTimestamp, status.BlockTimestamp)
+	assert.Equal(t, blockTimestamp, chainMetadataStore.GetGlobalMaxBlockTimestamp())
+}
+
+func TestBlockHeightChecker_ReferenceOnly(t *testing.T) {
+	ethClient := mocks.NewEthClient(t)
+	ethClient.On("HeaderByNumber", mock.Anything, (*big.Int)(nil)).Return(&types.Header{Number: big.NewInt(maxBlockHeight)}, nil)
+
+	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
+		return ethClient, nil
+	}
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	chainMetadataStore.Start()
+	chainMetadataStore.ProcessBlockHeightUpdate("", "eth_mainnet", maxBlockHeight-1)
+
+	referenceConfig := &config.UpstreamConfig{
+		ID:            "reference",
+		HTTPURL:       "http://public",
+		ReferenceOnly: true,
+	}
+
+	checker := NewBlockHeightChecker(referenceConfig, mockEthClientGetter, chainMetadataStore, metrics.NewContainer(config.TestChainName), zap.L())
+	checker.RunCheck()
+
+	// The reference head raises the global max height, but not the max height of the routed upstream's group.
+	status := chainMetadataStore.GetBlockHeightStatus("", "eth_mainnet")
+	assert.Equal(t, uint64(maxBlockHeight-1), status.GroupMaxBlockHeight)
+	assert.Equal(t, uint64(maxBlockHeight), status.GlobalMaxBlockHeight)
+}
+
 func TestBlockHeightChecker_IsPassing(t *testing.T) {
 	for _, testCase := range []struct {
 		name             string
-		blockHeightCheck BlockHeightCheck
+		blockHeightCheck *BlockHeightCheck
 		blockHeight      uint64
 		isPassing        bool
 	}{
 		{
 			name: "No errors, block height high enough.",
-			blockHeightCheck: BlockHeightCheck{
+			blockHeightCheck: &BlockHeightCheck{
 				logger:         zap.L(),
 				upstreamConfig: defaultUpstreamConfig,
 				blockHeight:    3,
@@ -130,7 +337,7 @@ func TestBlockHeightChecker_IsPassing(t *testing.T) {
 		},
 		{
 			name: "No errors, block height too low.",
-			blockHeightCheck: BlockHeightCheck{
+			blockHeightCheck: &BlockHeightCheck{
 				logger:         zap.L(),
 				upstreamConfig: defaultUpstreamConfig,
 				blockHeight:    2,
@@ -140,7 +347,7 @@ func TestBlockHeightChecker_IsPassing(t *testing.T) {
 		},
 		{
 			name: "Errors found, block height high enough.",
-			blockHeightCheck: BlockHeightCheck{
+			blockHeightCheck: &BlockHeightCheck{
 				logger:           zap.L(),
 				blockHeightError: errors.New("an error"),
 				upstreamConfig:   defaultUpstreamConfig,
@@ -10,8 +10,6 @@ import (
 
 const (
 	JSONRPCErrCodeMethodNotFound = -32601
-	MinimumPeerCount             = 3
-	RPCRequestTimeout            = 10 * time.Second
 )
 
 func isMethodNotSupportedErr(err error) bool {
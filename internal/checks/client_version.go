@@ -0,0 +1,191 @@
+package checks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/client"
+	conf "github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/types"
+	"github.com/satsuma-data/node-gateway/internal/util"
+	"go.uber.org/zap"
+)
+
+// Upstreams rarely change their client software, so there is no need to check it on every health check run.
+const clientVersionCheckInterval = 10 * time.Minute
+
+// ClientVersion is the client software of an upstream, parsed from web3_clientVersion.
+type ClientVersion struct {
+	Name          string // Lowercase, e.g. "geth".
+	Version       string // As reported, e.g. "v1.13.14-stable-2bd6bd01".
+	ParsedVersion [3]int
+}
+
+// ClientVersionCheck periodically records the client software of an upstream, so that requests for methods whose
+// behavior differs between clients can be routed to the right ones. It is informational and always passes.
+type ClientVersionCheck struct {
+	lastRunAt        time.Time
+	client           client.EthClient
+	Err              error
+	clientGetter     client.EthClientGetter
+	metricsContainer *metrics.Container
+	logger           *zap.Logger
+	upstreamConfig   *conf.UpstreamConfig
+	clientVersion    *ClientVersion
+	lock             sync.RWMutex
+}
+
+func NewClientVersionChecker(
+	upstreamConfig *conf.UpstreamConfig,
+	clientGetter client.EthClientGetter,
+	metricsContainer *metrics.Container,
+	logger *zap.Logger,
+) types.ClientVersionChecker {
+	return &ClientVersionCheck{
+		upstreamConfig:   upstreamConfig,
+		clientGetter:     clientGetter,
+		metricsContainer: metricsContainer,
+		logger:           logger,
+	}
+}
+
+func (c *ClientVersionCheck) Initialize() error {
+	c.logger.Debug("Initializing ClientVersionCheck.", zap.Any("config", c.upstreamConfig))
+
+	httpClient, err := c.clientGetter(c.upstreamConfig.HTTPURL, &c.upstreamConfig.BasicAuthConfig, &c.upstreamConfig.RequestHeadersConfig)
+	if err != nil {
+		c.Err = err
+		return c.Err
+	}
+
+	c.client = httpClient
+
+	return nil
+}
+
+func (c *ClientVersionCheck) RunCheck() {
+	if time.Since(c.lastRunAt) < clientVersionCheckInterval {
+		return
+	}
+
+	if c.client == nil {
+		if err := c.Initialize(); err != nil {
+			c.logger.Error("Error initializing ClientVersionCheck.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.Error(err))
+			c.metricsContainer.ClientVersionCheckErrors.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, metrics.HTTPInit).Inc()
+
+			return
+		}
+	}
+
+	runCheck := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.upstreamConfig.HealthCheckConfig.GetTimeout())
+		defer cancel()
+
+		result, err := c.client.CallRaw(ctx, "web3_clientVersion", nil)
+		if c.Err = err; c.Err != nil {
+			c.logger.Debug("ClientVersionCheck request failed.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.Error(c.Err))
+			c.metricsContainer.ClientVersionCheckErrors.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, metrics.HTTPRequest).Inc()
+
+			return
+		}
+
+		var rawClientVersion string
+		if c.Err = json.Unmarshal(result, &rawClientVersion); c.Err == nil {
+			var clientVersion *ClientVersion
+			if clientVersion, c.Err = parseClientVersion(rawClientVersion); c.Err == nil {
+				c.setClientVersion(clientVersion)
+			}
+		}
+
+		if c.Err != nil {
+			c.logger.Warn("ClientVersionCheck could not parse the client version.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.ByteString("clientVersion", result), zap.Error(c.Err))
+			c.metricsContainer.ClientVersionCheckErrors.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, metrics.UnparsableClientVersion).Inc()
+
+			return
+		}
+
+		// Failed checks are retried on the next run instead of after the interval.
+		c.lastRunAt = time.Now()
+
+		c.logger.Debug("Ran ClientVersionCheck.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("clientVersion", rawClientVersion))
+	}
+
+	runCheckWithMetrics(runCheck,
+		c.metricsContainer.ClientVersionCheckRequests.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL),
+		c.metricsContainer.ClientVersionCheckDuration.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL))
+}
+
+func (c *ClientVersionCheck) setClientVersion(clientVersion *ClientVersion) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.clientVersion != nil {
+		c.metricsContainer.ClientVersion.DeleteLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, c.clientVersion.Name, c.clientVersion.Version)
+	}
+
+	c.clientVersion = clientVersion
+	c.metricsContainer.ClientVersion.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, clientVersion.Name, clientVersion.Version).Set(1)
+}
+
+// GetClientVersion returns the upstream's client software, or nil if it is not known yet.
+func (c *ClientVersionCheck) GetClientVersion() *ClientVersion {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.clientVersion
+}
+
+func (c *ClientVersionCheck) IsPassing() bool {
+	return true
+}
+
+// MeetsRequirement returns true iff the upstream's client is known and meets the requirement.
+func (c *ClientVersionCheck) MeetsRequirement(requirement *conf.ClientRequirementConfig) bool {
+	clientVersion := c.GetClientVersion()
+
+	return clientVersion != nil && clientVersion.MeetsRequirement(requirement)
+}
+
+// parseClientVersion parses a web3_clientVersion such as "Geth/v1.13.14-stable-2bd6bd01/linux-amd64/go1.21.7". Some
+// clients are configured to include a node name after the client name, e.g. "Geth/my-node/v1.13.14-stable", so the
+// version is the first part after the client name that parses as one.
+func parseClientVersion(rawClientVersion string) (*ClientVersion, error) {
+	parts := strings.Split(rawClientVersion, "/")
+	if len(parts) < 2 || parts[0] == "" {
+		return nil, fmt.Errorf("unexpected client version format %q", rawClientVersion)
+	}
+
+	for _, part := range parts[1:] {
+		if parsedVersion, err := util.ParseVersion(part); err == nil {
+			return &ClientVersion{Name: strings.ToLower(parts[0]), Version: part, ParsedVersion: parsedVersion}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no version in client version %q", rawClientVersion)
+}
+
+// MeetsRequirement returns true iff the client is one of the requirement's clients, at or above its minimum version.
+func (v *ClientVersion) MeetsRequirement(requirement *conf.ClientRequirementConfig) bool {
+	for _, constraint := range requirement.Clients {
+		if !strings.EqualFold(constraint.Name, v.Name) {
+			continue
+		}
+
+		if constraint.MinVersion == "" {
+			return true
+		}
+
+		// The config validation ensures the minimum version parses.
+		minVersion, _ := util.ParseVersion(constraint.MinVersion)
+		if util.CompareVersions(v.ParsedVersion, minVersion) >= 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,108 @@
+package checks
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/satsuma-data/node-gateway/internal/client"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func newTestClientVersionChecker(t *testing.T, result string, err error) (*ClientVersionCheck, *mocks.EthClient) {
+	t.Helper()
+
+	ethClient := mocks.NewEthClient(t)
+	ethClient.EXPECT().CallRaw(mock.Anything, "web3_clientVersion", mock.Anything).Return(json.RawMessage(result), err).Maybe()
+
+	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
+		return ethClient, nil
+	}
+
+	checker := NewClientVersionChecker(defaultUpstreamConfig, mockEthClientGetter, metrics.NewContainer(config.TestChainName), zap.L())
+
+	return checker.(*ClientVersionCheck), ethClient //nolint:errcheck // ignore error
+}
+
+func TestClientVersionChecker(t *testing.T) {
+	checker, ethClient := newTestClientVersionChecker(t, `"Geth/v1.13.14-stable-2bd6bd01/linux-amd64/go1.21.7"`, nil)
+
+	assert.Nil(t, checker.GetClientVersion())
+
+	checker.RunCheck()
+	checker.RunCheck()
+
+	// The second run is within the interval, so the client version is not requested again.
+	ethClient.AssertNumberOfCalls(t, "CallRaw", 1)
+	assert.Equal(t, &ClientVersion{Name: "geth", Version: "v1.13.14-stable-2bd6bd01", ParsedVersion: [3]int{1, 13, 14}}, checker.GetClientVersion())
+	assert.True(t, checker.IsPassing())
+	assert.Equal(t, 1.0, testutil.ToFloat64(checker.metricsContainer.ClientVersion.WithLabelValues(
+		defaultUpstreamConfig.ID, defaultUpstreamConfig.HTTPURL, "geth", "v1.13.14-stable-2bd6bd01")))
+}
+
+func TestClientVersionChecker_Errors(t *testing.T) {
+	for _, testCase := range []struct {
+		name   string
+		result string
+		err    error
+	}{
+		{"Request error", "", errors.New("the method web3_clientVersion does not exist")},
+		{"Not a string", `{"client":"geth"}`, nil},
+		{"No version", `"Geth/my-node"`, nil},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			checker, ethClient := newTestClientVersionChecker(t, testCase.result, testCase.err)
+
+			checker.RunCheck()
+			checker.RunCheck()
+
+			// Failed checks are retried without waiting for the interval.
+			ethClient.AssertNumberOfCalls(t, "CallRaw", 2)
+			assert.Error(t, checker.Err)
+			assert.Nil(t, checker.GetClientVersion())
+			assert.True(t, checker.IsPassing())
+		})
+	}
+}
+
+func TestParseClientVersion(t *testing.T) {
+	for _, testCase := range []struct {
+		rawClientVersion string
+		expected         *ClientVersion
+	}{
+		{"Geth/v1.13.14-stable-2bd6bd01/linux-amd64/go1.21.7", &ClientVersion{"geth", "v1.13.14-stable-2bd6bd01", [3]int{1, 13, 14}}},
+		{"Geth/my-node/v1.13.14-stable/linux-amd64/go1.21.7", &ClientVersion{"geth", "v1.13.14-stable", [3]int{1, 13, 14}}},
+		{"erigon/2.59.3/linux-amd64/go1.21.5", &ClientVersion{"erigon", "2.59.3", [3]int{2, 59, 3}}},
+		{"Nethermind/v1.25.4+20b10b35/linux-x64/dotnet8.0.2", &ClientVersion{"nethermind", "v1.25.4+20b10b35", [3]int{1, 25, 4}}},
+		{"reth/v0.2.0-beta.5-54f75cdcc/x86_64-unknown-linux-gnu", &ClientVersion{"reth", "v0.2.0-beta.5-54f75cdcc", [3]int{0, 2, 0}}},
+	} {
+		t.Run(testCase.rawClientVersion, func(t *testing.T) {
+			clientVersion, err := parseClientVersion(testCase.rawClientVersion)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, clientVersion)
+		})
+	}
+
+	_, err := parseClientVersion("Geth")
+	assert.Error(t, err)
+}
+
+func TestClientVersion_MeetsRequirement(t *testing.T) {
+	requirement := &config.ClientRequirementConfig{
+		Methods: []string{"trace_*"},
+		Clients: []config.ClientConstraintConfig{{Name: "erigon", MinVersion: "2.59"}, {Name: "Reth"}},
+	}
+
+	assert.True(t, (&ClientVersion{Name: "erigon", ParsedVersion: [3]int{2, 59, 0}}).MeetsRequirement(requirement))
+	assert.True(t, (&ClientVersion{Name: "erigon", ParsedVersion: [3]int{3, 0, 0}}).MeetsRequirement(requirement))
+	assert.False(t, (&ClientVersion{Name: "erigon", ParsedVersion: [3]int{2, 58, 9}}).MeetsRequirement(requirement))
+	assert.True(t, (&ClientVersion{Name: "reth", ParsedVersion: [3]int{0, 1, 0}}).MeetsRequirement(requirement))
+	assert.False(t, (&ClientVersion{Name: "geth", ParsedVersion: [3]int{1, 13, 14}}).MeetsRequirement(requirement))
+}
@@ -20,13 +20,24 @@ func NewCircuitBreaker(
 	errorRate float64,
 	detectionWindow time.Duration,
 	banWindow time.Duration,
+) circuitbreaker.CircuitBreaker[any] {
+	return NewCircuitBreakerWithMinRequests(errorRate, MinNumRequestsForRate, detectionWindow, banWindow)
+}
+
+// NewCircuitBreakerWithMinRequests is like NewCircuitBreaker, but with the given minimum number of requests in the
+// detection window required to compute the error rate.
+func NewCircuitBreakerWithMinRequests(
+	errorRate float64,
+	minRequests uint,
+	detectionWindow time.Duration,
+	banWindow time.Duration,
 ) circuitbreaker.CircuitBreaker[any] {
 	// TODO(polsar): Check that `0.0 < errorRate <= 1.0` holds.
 	return circuitbreaker.Builder[any]().
 		HandleResult(false). // The false return value of the wrapped call will be interpreted as a failure.
 		WithFailureRateThreshold(
 			uint(math.Floor(errorRate*PercentPerFrac)), // Minimum percentage of failed requests to open the breaker.
-			MinNumRequestsForRate,
+			minRequests,
 			detectionWindow,
 		).
 		WithDelay(banWindow).
@@ -2,13 +2,15 @@ package checks
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"go.uber.org/zap"
 
-	"github.com/failsafe-go/failsafe-go/circuitbreaker"
 	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
 	"github.com/satsuma-data/node-gateway/internal/metrics"
 	"github.com/satsuma-data/node-gateway/internal/types"
 )
@@ -22,24 +24,34 @@ type ErrorCheck struct {
 	upstreamConfig      *config.UpstreamConfig
 	routingConfig       *config.RoutingConfig
 	errorCircuitBreaker ErrorCircuitBreaker
+	methodErrorBreakers map[string]ErrorCircuitBreaker // Configured RPC method or pattern -> ErrorCircuitBreaker
+	lock                sync.Mutex
 	isCheckEnabled      bool
 }
 
 type ErrorCircuitBreaker interface {
 	RecordResponse(isError bool)
 	IsOpen() bool
+	IsAdmitted() bool
 }
 
 type ErrorStats struct {
-	circuitBreaker circuitbreaker.CircuitBreaker[any]
+	circuitBreaker *RecoveringCircuitBreaker
 }
 
 func NewErrorStats(routingConfig *config.RoutingConfig) ErrorCircuitBreaker {
+	return newErrorStatsWithRate(routingConfig, getErrorsRate(routingConfig))
+}
+
+func newErrorStatsWithRate(routingConfig *config.RoutingConfig, errorRate float64) ErrorCircuitBreaker {
 	return &ErrorStats{
-		circuitBreaker: NewCircuitBreaker(
-			getErrorsRate(routingConfig),
-			getDetectionWindow(routingConfig),
-			getBanWindow(routingConfig),
+		circuitBreaker: NewRecoveringCircuitBreaker(
+			NewCircuitBreaker(
+				errorRate,
+				getDetectionWindow(routingConfig),
+				getBanWindow(routingConfig),
+			),
+			routingConfig.Recovery,
 		),
 	}
 }
@@ -56,10 +68,76 @@ func NewErrorChecker(
 		metricsContainer:    metricsContainer,
 		logger:              logger,
 		errorCircuitBreaker: NewErrorStats(routingConfig),
+		methodErrorBreakers: make(map[string]ErrorCircuitBreaker),
 		isCheckEnabled:      routingConfig.IsEnabled,
 	}
 }
 
+// Returns the circuit breaker that records the errors of the specified RPC method: the breaker of the first per-method
+// config matching the method, or the upstream-wide breaker if there is none.
+// This method is thread-safe.
+func (c *ErrorCheck) getErrorCircuitBreaker(method string) ErrorCircuitBreaker {
+	if c.routingConfig.Errors == nil {
+		return c.errorCircuitBreaker
+	}
+
+	methodConfig := c.routingConfig.Errors.GetMethodConfig(method)
+	if methodConfig == nil {
+		return c.errorCircuitBreaker
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	breaker, exists := c.methodErrorBreakers[methodConfig.Name]
+
+	if !exists {
+		// This is the first error check for the method config, so initialize its circuit breaker.
+		breaker = newErrorStatsWithRate(c.routingConfig, methodConfig.Rate)
+		c.methodErrorBreakers[methodConfig.Name] = breaker
+	}
+
+	return breaker
+}
+
+// Returns the distinct circuit breakers of the methods of a batch's subrequests, or of the given method if the request
+// is not a batch.
+// This method is thread-safe.
+func (c *ErrorCheck) getErrorCircuitBreakers(method string, methodsByID map[int64]string) []ErrorCircuitBreaker {
+	if len(methodsByID) == 0 {
+		return []ErrorCircuitBreaker{c.getErrorCircuitBreaker(method)}
+	}
+
+	breakers := make([]ErrorCircuitBreaker, 0, 1)
+
+	for _, subRequestMethod := range methodsByID {
+		if breaker := c.getErrorCircuitBreaker(subRequestMethod); !slices.Contains(breakers, breaker) {
+			breakers = append(breakers, breaker)
+		}
+	}
+
+	return breakers
+}
+
+// Returns the methods of a batch's subrequests by their IDs, or nil if the request is not a batch.
+func getBatchMethodsByID(requestBody jsonrpc.RequestBody) map[int64]string {
+	batchRequestBody, ok := requestBody.(*jsonrpc.BatchRequestBody)
+	if !ok {
+		return nil
+	}
+
+	methodsByID := make(map[int64]string, len(batchRequestBody.Requests))
+
+	for _, subRequest := range batchRequestBody.Requests {
+		// Subrequests without an ID do not have a response.
+		if subRequest.ID != nil {
+			methodsByID[*subRequest.ID] = subRequest.Method
+		}
+	}
+
+	return methodsByID
+}
+
 func (c *ErrorCheck) isError(httpCode, jsonRPCCode, errorMsg string) bool {
 	if isMatchForPatterns(httpCode, c.routingConfig.Errors.HTTPCodes) ||
 		isMatchForPatterns(jsonRPCCode, c.routingConfig.Errors.JSONRPCCodes) ||
@@ -145,22 +223,22 @@ func (e *ErrorStats) RecordResponse(isError bool) {
 }
 
 func (e *ErrorStats) IsOpen() bool {
-	// TODO(polsar): We should be able to check `e.circuitBreaker.IsOpen()`,
-	//  but it appears to remain open forever, regardless of the configured delay.
-	//  We also must reset the circuit breaker manually if it is not supposed to be open.
-	isOpen := e.circuitBreaker.RemainingDelay() > 0
-	if !isOpen {
-		e.circuitBreaker.Close()
-	}
+	return e.circuitBreaker.IsOpen()
+}
 
-	return isOpen
+func (e *ErrorStats) IsAdmitted() bool {
+	return e.circuitBreaker.IsAdmitted()
 }
 
-func (c *ErrorCheck) IsPassing([]string) bool {
+func (c *ErrorCheck) IsPassing(methods []string) bool {
 	if !c.isCheckEnabled {
 		return true
 	}
 
+	if !c.isPassingForMethods(methods) {
+		return false
+	}
+
 	if c.errorCircuitBreaker != nil && c.errorCircuitBreaker.IsOpen() {
 		c.logger.Debug(
 			"ErrorCheck is not passing due to too many errors.",
@@ -174,6 +252,46 @@ func (c *ErrorCheck) IsPassing([]string) bool {
 	return true
 }
 
+// IsAdmitted randomly admits requests of the passed methods in proportion to the upstream's share of traffic while it
+// recovers from too many errors. It should be called after IsPassing.
+func (c *ErrorCheck) IsAdmitted(methods []string) bool {
+	if !c.isCheckEnabled {
+		return true
+	}
+
+	for _, method := range methods {
+		// Methods without their own circuit breaker are covered by the upstream-wide one.
+		if breaker := c.getErrorCircuitBreaker(method); breaker != c.errorCircuitBreaker && !breaker.IsAdmitted() {
+			return false
+		}
+	}
+
+	return c.errorCircuitBreaker == nil || c.errorCircuitBreaker.IsAdmitted()
+}
+
+// Returns false if the per-method circuit breaker of any of the passed methods is open.
+func (c *ErrorCheck) isPassingForMethods(methods []string) bool {
+	for _, method := range methods {
+		// Methods without their own circuit breaker are covered by the upstream-wide one.
+		breaker := c.getErrorCircuitBreaker(method)
+		if breaker == c.errorCircuitBreaker {
+			continue
+		}
+
+		if breaker.IsOpen() {
+			c.logger.Debug(
+				"ErrorCheck is not passing due to too many errors of an RPC method.",
+				zap.String("upstreamID", c.upstreamConfig.ID),
+				zap.String("method", method),
+			)
+
+			return false
+		}
+	}
+
+	return true
+}
+
 // RecordRequest records the request data for error checking. It returns true if we recorded an error.
 // Note that a request may have an error which we do not record, in which case this method returns false.
 func (c *ErrorCheck) RecordRequest(data *types.RequestData) bool {
@@ -182,6 +300,7 @@ func (c *ErrorCheck) RecordRequest(data *types.RequestData) bool {
 	}
 
 	isError := false
+	methodsByID := getBatchMethodsByID(data.RequestBody)
 
 	errorString := ""
 	if data.Error != nil {
@@ -191,15 +310,28 @@ func (c *ErrorCheck) RecordRequest(data *types.RequestData) bool {
 	if data.HTTPResponseCode >= http.StatusBadRequest || data.ResponseBody == nil {
 		// No RPC responses are available since the HTTP request errored out or does not contain a JSON RPC response.
 		// TODO(polsar): We might want to emit a Prometheus stat like we do for an RPC error below.
-		c.errorCircuitBreaker.RecordResponse(c.isError(
+		isRecordedError := c.isError(
 			strconv.Itoa(data.HTTPResponseCode), // Note that this CAN be 200 OK.
 			"",
 			errorString,
-		))
+		)
+
+		// A failed batch counts once against each of its methods' circuit breakers.
+		for _, errorCircuitBreaker := range c.getErrorCircuitBreakers(data.Method, methodsByID) {
+			errorCircuitBreaker.RecordResponse(isRecordedError)
+		}
 
 		isError = true
 	} else { // data.ResponseBody != nil
 		for _, resp := range data.ResponseBody.GetSubResponses() {
+			// Each response of a batch is recorded against the circuit breaker of its subrequest's method.
+			method := data.Method
+			if subRequestMethod, ok := methodsByID[resp.ID]; ok {
+				method = subRequestMethod
+			}
+
+			errorCircuitBreaker := c.getErrorCircuitBreaker(method)
+
 			if resp.Error != nil {
 				// Do not ignore this response even if it does not correspond to an RPC request.
 				if c.isError("", strconv.Itoa(resp.Error.Code), resp.Error.Message) {
@@ -207,23 +339,23 @@ func (c *ErrorCheck) RecordRequest(data *types.RequestData) bool {
 						c.upstreamConfig.ID,
 						c.upstreamConfig.HTTPURL,
 						metrics.HTTPRequest,
-						data.Method,
+						method,
 					).Inc()
 
 					isError = true
 
 					// Even though this is a single HTTP request, we count each RPC JSON subresponse error.
-					c.errorCircuitBreaker.RecordResponse(true) // JSON RPC subrequest error
+					errorCircuitBreaker.RecordResponse(true) // JSON RPC subrequest error
 				} else {
 					// We have an error, but it is not one we are interested in.
 					c.metricsContainer.ErrorCheckNoErrors.WithLabelValues(
 						c.upstreamConfig.ID,
 						c.upstreamConfig.HTTPURL,
 						metrics.HTTPRequest,
-						data.Method,
+						method,
 					).Inc()
 
-					c.errorCircuitBreaker.RecordResponse(false) // JSON RPC subrequest OK
+					errorCircuitBreaker.RecordResponse(false) // JSON RPC subrequest OK
 				}
 			} else {
 				// We don't have an error.
@@ -231,10 +363,10 @@ func (c *ErrorCheck) RecordRequest(data *types.RequestData) bool {
 					c.upstreamConfig.ID,
 					c.upstreamConfig.HTTPURL,
 					metrics.HTTPRequest,
-					data.Method,
+					method,
 				).Inc()
 
-				c.errorCircuitBreaker.RecordResponse(false) // JSON RPC subrequest OK
+				errorCircuitBreaker.RecordResponse(false) // JSON RPC subrequest OK
 			}
 		}
 	}
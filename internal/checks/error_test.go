@@ -1,9 +1,17 @@
 package checks
 
 import (
+	"encoding/json"
+	"net/http"
 	"testing"
+	"time"
 
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/types"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 )
 
 func Test_isMatchForPatterns_True(t *testing.T) {
@@ -83,3 +91,81 @@ func Test_isErrorMatches_False(t *testing.T) {
 	Assert.False(isErrorMatches("a", []string{"aa"}))
 	Assert.False(isErrorMatches("aa", []string{"aba"}))
 }
+
+func TestErrorCheck_PerMethodCircuitBreakers(t *testing.T) {
+	routingConfig := &config.RoutingConfig{
+		Errors: &config.ErrorsConfig{
+			Rate:    config.DefaultErrorRate,
+			Methods: []config.ErrorMethodConfig{{Name: "debug_*", Rate: 0.5}},
+		},
+		DetectionWindow: config.NewDuration(time.Minute),
+		BanWindow:       config.NewDuration(time.Minute),
+		IsEnabled:       true,
+	}
+	errorCheck := NewErrorChecker(defaultUpstreamConfig, routingConfig, metrics.NewContainer(config.TestChainName), zap.L())
+
+	for i := 0; i < MinNumRequestsForRate; i++ {
+		assert.True(t, errorCheck.RecordRequest(&types.RequestData{Method: "debug_traceTransaction", HTTPResponseCode: http.StatusGatewayTimeout}))
+	}
+
+	// Only the methods matching the failing method's pattern are affected.
+	assert.False(t, errorCheck.IsPassing([]string{"debug_traceTransaction"}))
+	assert.False(t, errorCheck.IsPassing([]string{"eth_call", "debug_traceCall"}))
+	assert.True(t, errorCheck.IsPassing([]string{"eth_call"}))
+	assert.True(t, errorCheck.IsPassing(nil))
+
+	// Errors of other methods ban the upstream for every method.
+	for i := 0; i < MinNumRequestsForRate; i++ {
+		errorCheck.RecordRequest(&types.RequestData{Method: "eth_call", HTTPResponseCode: http.StatusInternalServerError})
+	}
+
+	assert.False(t, errorCheck.IsPassing([]string{"eth_call"}))
+	assert.False(t, errorCheck.IsPassing([]string{"eth_blockNumber"}))
+}
+
+func TestErrorCheck_PerMethodCircuitBreakers_Batch(t *testing.T) {
+	routingConfig := &config.RoutingConfig{
+		Errors: &config.ErrorsConfig{
+			Rate:    config.DefaultErrorRate,
+			Methods: []config.ErrorMethodConfig{{Name: "debug_*", Rate: 0.5}},
+		},
+		DetectionWindow: config.NewDuration(time.Minute),
+		BanWindow:       config.NewDuration(time.Minute),
+		IsEnabled:       true,
+	}
+	errorCheck := NewErrorChecker(defaultUpstreamConfig, routingConfig, metrics.NewContainer(config.TestChainName), zap.L())
+
+	traceID, callID := int64(1), int64(2)
+	batchRequestBody := &jsonrpc.BatchRequestBody{Requests: []jsonrpc.SingleRequestBody{
+		{ID: &traceID, Method: "debug_traceTransaction"},
+		{ID: &callID, Method: "eth_call"},
+	}}
+	batchResponseBody := &jsonrpc.BatchResponseBody{Responses: []jsonrpc.SingleResponseBody{
+		{ID: traceID, Error: &jsonrpc.Error{Code: -32000, Message: "execution timeout"}},
+		{ID: callID, Result: json.RawMessage(`"0x"`)},
+	}}
+
+	for i := 0; i < MinNumRequestsForRate; i++ {
+		assert.True(t, errorCheck.RecordRequest(&types.RequestData{
+			Method:           batchRequestBody.GetMethod(),
+			HTTPResponseCode: http.StatusOK,
+			RequestBody:      batchRequestBody,
+			ResponseBody:     batchResponseBody,
+		}))
+	}
+
+	// Only the errors of the failing subrequests are recorded against their method's circuit breaker.
+	assert.False(t, errorCheck.IsPassing([]string{"debug_traceTransaction"}))
+	assert.True(t, errorCheck.IsPassing([]string{"eth_call"}))
+
+	// A failed batch counts against the circuit breakers of all of its methods.
+	for i := 0; i < MinNumRequestsForRate; i++ {
+		errorCheck.RecordRequest(&types.RequestData{
+			Method:           batchRequestBody.GetMethod(),
+			HTTPResponseCode: http.StatusInternalServerError,
+			RequestBody:      batchRequestBody,
+		})
+	}
+
+	assert.False(t, errorCheck.IsPassing([]string{"eth_call"}))
+}
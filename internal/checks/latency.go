@@ -6,12 +6,17 @@ import (
 
 	"go.uber.org/zap"
 
-	"github.com/failsafe-go/failsafe-go/circuitbreaker"
 	"github.com/satsuma-data/node-gateway/internal/config"
 	"github.com/satsuma-data/node-gateway/internal/metrics"
 	"github.com/satsuma-data/node-gateway/internal/types"
 )
 
+const (
+	latencyWindowStatsInterval = time.Second
+	// The evaluated window stats are also recomputed once the window has recorded this fraction of its size since.
+	latencyWindowStatsRecomputeDivisor = 16
+)
+
 type LatencyCheck struct {
 	Err                  error
 	metricsContainer     *metrics.Container
@@ -19,6 +24,8 @@ type LatencyCheck struct {
 	upstreamConfig       *config.UpstreamConfig
 	routingConfig        *config.RoutingConfig
 	methodLatencyBreaker map[string]LatencyCircuitBreaker // RPC method -> LatencyCircuitBreaker
+	statsReportedAt      map[string]time.Time             // RPC method -> when its window stats were last reported
+	baseline             *LatencyBaseline                 // Shared by all upstreams of the chain. Nil unless adaptive.
 	lock                 sync.RWMutex
 	isCheckEnabled       bool
 }
@@ -26,33 +33,111 @@ type LatencyCheck struct {
 type LatencyCircuitBreaker interface {
 	RecordLatency(latency time.Duration)
 	IsOpen() bool
+	IsAdmitted() bool
 	GetThreshold() time.Duration
+	GetWindowStats() LatencyWindowStats
 }
 
 type LatencyStats struct {
-	circuitBreaker circuitbreaker.CircuitBreaker[any]
-	threshold      time.Duration
+	statsComputedAt time.Time
+	circuitBreaker  *RecoveringCircuitBreaker
+	window          *LatencyWindow
+	baseline        *LatencyBaseline // Nil unless latency thresholds are adaptive.
+	aggregation     config.LatencyAggregation
+	method          string
+	stats           LatencyWindowStats // The stats last evaluated against the threshold.
+	threshold       time.Duration
+	minSamples      uint
+	recordedSince   uint // Latencies recorded since the stats were last computed.
+	lock            sync.Mutex
 }
 
 func (l *LatencyStats) RecordLatency(latency time.Duration) {
-	if latency >= l.threshold {
-		l.circuitBreaker.RecordFailure()
+	l.window.Record(latency)
+
+	if l.aggregation == config.RateAggregation {
+		if latency >= l.GetThreshold() {
+			l.circuitBreaker.RecordFailure()
+		} else {
+			l.circuitBreaker.RecordSuccess()
+		}
+
+		return
+	}
+
+	stats := l.getEvaluatedStats()
+	if stats.Count >= l.minSamples && stats.Get(l.aggregation) >= l.GetThreshold() {
+		l.circuitBreaker.Open()
+
+		// Start over, so that the latencies that opened the circuit breaker do not open it again once it closes.
+		l.window.Reset()
+		l.resetEvaluatedStats()
 	} else {
 		l.circuitBreaker.RecordSuccess()
 	}
 }
 
+// getEvaluatedStats returns the window stats to evaluate against the threshold. Computing them sorts the window's
+// latencies, so they are only recomputed once latencyWindowStatsInterval has passed or the window has grown by a
+// sixteenth since they were last computed. This bounds the cost per request while delaying the detection of high
+// latencies by a few samples at most.
+func (l *LatencyStats) getEvaluatedStats() LatencyWindowStats {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.recordedSince++
+
+	if time.Since(l.statsComputedAt) >= latencyWindowStatsInterval ||
+		l.recordedSince > l.stats.Count/latencyWindowStatsRecomputeDivisor {
+		l.stats = l.window.GetStats()
+		l.statsComputedAt = time.Now()
+		l.recordedSince = 0
+	}
+
+	return l.stats
+}
+
+func (l *LatencyStats) resetEvaluatedStats() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.stats = LatencyWindowStats{}
+	l.statsComputedAt = time.Time{}
+	l.recordedSince = 0
+}
+
+// GetThreshold returns the learned threshold if latency thresholds are adaptive and it has been learned, or the
+// configured one otherwise.
 func (l *LatencyStats) GetThreshold() time.Duration {
+	if l.baseline != nil {
+		if threshold, isLearned := l.baseline.GetThreshold(l.method); isLearned {
+			return threshold
+		}
+	}
+
 	return l.threshold
 }
 
-func NewLatencyStats(routingConfig *config.RoutingConfig, method string) LatencyCircuitBreaker {
+func (l *LatencyStats) GetWindowStats() LatencyWindowStats {
+	return l.window.GetStats()
+}
+
+func NewLatencyStats(routingConfig *config.RoutingConfig, method string, baseline *LatencyBaseline) LatencyCircuitBreaker {
 	return &LatencyStats{
-		threshold: getLatencyThreshold(routingConfig, method),
-		circuitBreaker: NewCircuitBreaker(
-			config.DefaultLatencyTooHighRate,
-			getDetectionWindow(routingConfig),
-			getBanWindow(routingConfig),
+		baseline:    baseline,
+		method:      method,
+		threshold:   getLatencyThreshold(routingConfig, method),
+		aggregation: getLatencyAggregation(routingConfig),
+		minSamples:  getLatencyMinSamples(routingConfig),
+		window:      NewLatencyWindow(getDetectionWindow(routingConfig)),
+		circuitBreaker: NewRecoveringCircuitBreaker(
+			NewCircuitBreakerWithMinRequests(
+				getLatencyTooHighRate(routingConfig),
+				getLatencyMinSamples(routingConfig),
+				getDetectionWindow(routingConfig),
+				getBanWindow(routingConfig),
+			),
+			routingConfig.Recovery,
 		),
 	}
 }
@@ -60,15 +145,18 @@ func NewLatencyStats(routingConfig *config.RoutingConfig, method string) Latency
 func NewLatencyChecker(
 	upstreamConfig *config.UpstreamConfig,
 	routingConfig *config.RoutingConfig,
+	baseline *LatencyBaseline,
 	metricsContainer *metrics.Container,
 	logger *zap.Logger,
 ) types.ErrorLatencyChecker {
 	return &LatencyCheck{
+		baseline:             baseline,
 		upstreamConfig:       upstreamConfig,
 		routingConfig:        routingConfig,
 		metricsContainer:     metricsContainer,
 		logger:               logger,
 		methodLatencyBreaker: make(map[string]LatencyCircuitBreaker),
+		statsReportedAt:      make(map[string]time.Time),
 		isCheckEnabled:       routingConfig.IsEnabled,
 	}
 }
@@ -83,7 +171,7 @@ func (c *LatencyCheck) getLatencyCircuitBreaker(method string) LatencyCircuitBre
 
 	if !exists {
 		// This is the first time we are checking this method so initialize its LatencyStats instance.
-		stats = NewLatencyStats(c.routingConfig, method)
+		stats = NewLatencyStats(c.routingConfig, method, c.baseline)
 		c.methodLatencyBreaker[method] = stats
 	}
 
@@ -102,16 +190,36 @@ func getLatencyThreshold(routingConfig *config.RoutingConfig, method string) tim
 	return config.DefaultMaxLatency
 }
 
-func (l *LatencyStats) IsOpen() bool {
-	// TODO(polsar): We should be able to check `l.circuitBreaker.IsOpen()`,
-	//  but it appears to remain open forever, regardless of the configured delay.
-	//  We also must reset the circuit breaker manually if it is not supposed to be open.
-	isOpen := l.circuitBreaker.RemainingDelay() > 0
-	if !isOpen {
-		l.circuitBreaker.Close()
+func getLatencyAggregation(routingConfig *config.RoutingConfig) config.LatencyAggregation {
+	if routingConfig != nil && routingConfig.Latency != nil && routingConfig.Latency.Aggregation != "" {
+		return routingConfig.Latency.Aggregation
+	}
+
+	return config.RateAggregation
+}
+
+func getLatencyTooHighRate(routingConfig *config.RoutingConfig) float64 {
+	if routingConfig != nil && routingConfig.Latency != nil && routingConfig.Latency.TooHighRate > 0 {
+		return routingConfig.Latency.TooHighRate
+	}
+
+	return config.DefaultLatencyTooHighRate
+}
+
+func getLatencyMinSamples(routingConfig *config.RoutingConfig) uint {
+	if routingConfig != nil && routingConfig.Latency != nil && routingConfig.Latency.MinSamples > 0 {
+		return routingConfig.Latency.MinSamples
 	}
 
-	return isOpen
+	return config.DefaultLatencyMinSamples
+}
+
+func (l *LatencyStats) IsOpen() bool {
+	return l.circuitBreaker.IsOpen()
+}
+
+func (l *LatencyStats) IsAdmitted() bool {
+	return l.circuitBreaker.IsAdmitted()
 }
 
 func (c *LatencyCheck) IsPassing(methods []string) bool {
@@ -148,6 +256,53 @@ func (c *LatencyCheck) IsPassing(methods []string) bool {
 	return true
 }
 
+// IsAdmitted randomly admits requests of the passed methods in proportion to the upstream's share of traffic while it
+// recovers from high latency. It should be called after IsPassing.
+func (c *LatencyCheck) IsAdmitted(methods []string) bool {
+	if !c.isCheckEnabled {
+		return true
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, method := range methods {
+		if breaker, exists := c.methodLatencyBreaker[method]; exists && !breaker.IsAdmitted() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Sets the window stats metrics of the specified RPC method, at most once per latencyWindowStatsInterval since
+// computing them requires sorting the window's latencies.
+// This method is thread-safe.
+func (c *LatencyCheck) reportWindowStats(method string, latencyCircuitBreaker LatencyCircuitBreaker) {
+	c.lock.Lock()
+
+	if time.Since(c.statsReportedAt[method]) < latencyWindowStatsInterval {
+		c.lock.Unlock()
+		return
+	}
+
+	c.statsReportedAt[method] = time.Now()
+	c.lock.Unlock()
+
+	stats := latencyCircuitBreaker.GetWindowStats()
+
+	for statistic, value := range map[string]time.Duration{
+		string(config.MeanAggregation): stats.Mean,
+		string(config.P50Aggregation):  stats.P50,
+		string(config.P90Aggregation):  stats.P90,
+		string(config.P99Aggregation):  stats.P99,
+	} {
+		c.metricsContainer.LatencyWindow.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, method, statistic).Set(value.Seconds())
+	}
+
+	c.metricsContainer.LatencyWindowSamples.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, method).Set(float64(stats.Count))
+}
+
 // RecordRequest records the request data for latency checking. It returns true if we recorded a high latency.
 func (c *LatencyCheck) RecordRequest(data *types.RequestData) bool {
 	if !c.isCheckEnabled {
@@ -158,8 +313,14 @@ func (c *LatencyCheck) RecordRequest(data *types.RequestData) bool {
 
 	// Record the request latency if latency checking is enabled.
 	if c.methodLatencyBreaker != nil {
+		// Cache hits are far faster than any upstream, and would drag the baseline down.
+		if c.baseline != nil && !data.Cached {
+			c.baseline.Record(data.Method, data.Latency)
+		}
+
 		latencyCircuitBreaker := c.getLatencyCircuitBreaker(data.Method)
 		latencyCircuitBreaker.RecordLatency(data.Latency)
+		c.reportWindowStats(data.Method, latencyCircuitBreaker)
 
 		if data.Latency >= latencyCircuitBreaker.GetThreshold() {
 			c.metricsContainer.LatencyCheckHighLatencies.WithLabelValues(
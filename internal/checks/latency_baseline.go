@@ -0,0 +1,206 @@
+package checks
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	conf "github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+)
+
+const (
+	// A learned baseline is recomputed at most this often. Until it is learned, it is also recomputed as soon as new
+	// latencies are recorded, so that it is used as soon as possible.
+	latencyBaselineRefreshInterval = time.Second
+
+	// Latencies are counted in bins that are each this much wider than the previous one, starting at
+	// latencyHistogramMinLatency, so the estimated median is within about this relative error.
+	latencyHistogramBinGrowth  = 1.1
+	latencyHistogramMinLatency = 100 * time.Microsecond
+	latencyHistogramBins       = 170 // Up to about 1000s, above which latencies are counted in the last bin.
+	// The window is split into this many slices, which expire one at a time.
+	latencyHistogramSlices = 10
+)
+
+type cachedLatencyBaseline struct {
+	computedAt time.Time
+	median     time.Duration
+	recorded   uint64 // The number of latencies the histogram had recorded when the baseline was computed.
+	isLearned  bool
+}
+
+// LatencyBaseline learns the median latency of each method across all upstreams of a chain, so that the latency
+// threshold of a method can be a multiple of it instead of being configured by hand.
+// This struct is thread-safe.
+type LatencyBaseline struct {
+	histogramsByMethod map[string]*latencyHistogram
+	baselinesByMethod  map[string]cachedLatencyBaseline
+	config             *conf.AdaptiveLatencyConfig
+	metricsContainer   *metrics.Container
+	lock               sync.Mutex
+}
+
+func NewLatencyBaseline(config *conf.AdaptiveLatencyConfig, metricsContainer *metrics.Container) *LatencyBaseline {
+	return &LatencyBaseline{
+		histogramsByMethod: make(map[string]*latencyHistogram),
+		baselinesByMethod:  make(map[string]cachedLatencyBaseline),
+		config:             config,
+		metricsContainer:   metricsContainer,
+	}
+}
+
+func (b *LatencyBaseline) Record(method string, latency time.Duration) {
+	b.getHistogram(method).Record(latency)
+}
+
+// GetThreshold returns the learned latency threshold of the method, or false if there are not enough latencies to
+// learn it from yet.
+func (b *LatencyBaseline) GetThreshold(method string) (time.Duration, bool) {
+	histogram := b.getHistogram(method)
+
+	b.lock.Lock()
+	baseline, exists := b.baselinesByMethod[method]
+	b.lock.Unlock()
+
+	if !exists || time.Since(baseline.computedAt) >= latencyBaselineRefreshInterval ||
+		(!baseline.isLearned && baseline.recorded != histogram.getRecorded()) {
+		baseline = b.compute(method, histogram)
+	}
+
+	if !baseline.isLearned {
+		return 0, false
+	}
+
+	return time.Duration(float64(baseline.median) * b.config.Multiplier), true
+}
+
+func (b *LatencyBaseline) compute(method string, histogram *latencyHistogram) cachedLatencyBaseline {
+	recorded := histogram.getRecorded()
+	median, count := histogram.getMedian()
+	baseline := cachedLatencyBaseline{
+		computedAt: time.Now(),
+		median:     median,
+		recorded:   recorded,
+		isLearned:  count >= uint64(b.config.MinSamples),
+	}
+
+	if baseline.isLearned {
+		b.metricsContainer.LatencyBaseline.WithLabelValues(method).Set(baseline.median.Seconds())
+	}
+
+	b.lock.Lock()
+	b.baselinesByMethod[method] = baseline
+	b.lock.Unlock()
+
+	return baseline
+}
+
+func (b *LatencyBaseline) getHistogram(method string) *latencyHistogram {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	histogram, exists := b.histogramsByMethod[method]
+	if !exists {
+		histogram = newLatencyHistogram(b.config.Window)
+		b.histogramsByMethod[method] = histogram
+	}
+
+	return histogram
+}
+
+type latencyHistogramSlice struct {
+	counts [latencyHistogramBins]uint64
+	sums   [latencyHistogramBins]time.Duration
+	index  int64 // The number of slice durations since the epoch at which the slice started.
+}
+
+// latencyHistogram counts the latencies of the last window in exponentially sized bins, so that their median is
+// estimated in constant memory however many there are. The window slides one slice at a time.
+// This struct is thread-safe.
+type latencyHistogram struct {
+	slices        [latencyHistogramSlices]latencyHistogramSlice
+	sliceDuration time.Duration
+	recorded      uint64
+	lock          sync.Mutex
+}
+
+func newLatencyHistogram(window time.Duration) *latencyHistogram {
+	return &latencyHistogram{sliceDuration: max(window/latencyHistogramSlices, 1)}
+}
+
+func (h *latencyHistogram) Record(latency time.Duration) {
+	index := time.Now().UnixNano() / int64(h.sliceDuration)
+	bin := getLatencyHistogramBin(latency)
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	slice := &h.slices[index%latencyHistogramSlices]
+	if slice.index != index {
+		*slice = latencyHistogramSlice{index: index}
+	}
+
+	slice.counts[bin]++
+	slice.sums[bin] += latency
+	h.recorded++
+}
+
+func (h *latencyHistogram) getRecorded() uint64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.recorded
+}
+
+// getMedian returns the estimated median of the latencies in the window, which is the mean latency of the bin that
+// the median falls in, and the number of latencies in the window.
+func (h *latencyHistogram) getMedian() (time.Duration, uint64) {
+	var (
+		counts [latencyHistogramBins]uint64
+		sums   [latencyHistogramBins]time.Duration
+		total  uint64
+	)
+
+	index := time.Now().UnixNano() / int64(h.sliceDuration)
+
+	h.lock.Lock()
+
+	for i := range h.slices {
+		slice := &h.slices[i]
+		if slice.index <= index-latencyHistogramSlices {
+			continue
+		}
+
+		for bin, count := range slice.counts {
+			counts[bin] += count
+			sums[bin] += slice.sums[bin]
+			total += count
+		}
+	}
+
+	h.lock.Unlock()
+
+	// The nearest-rank median.
+	rank := (total + 1) / 2
+
+	var seen uint64
+
+	for bin, count := range counts {
+		if seen += count; count > 0 && seen >= rank {
+			return sums[bin] / time.Duration(count), total
+		}
+	}
+
+	return 0, total
+}
+
+func getLatencyHistogramBin(latency time.Duration) int {
+	if latency <= latencyHistogramMinLatency {
+		return 0
+	}
+
+	bin := int(math.Log(float64(latency)/float64(latencyHistogramMinLatency))/math.Log(latencyHistogramBinGrowth)) + 1
+
+	return min(bin, latencyHistogramBins-1)
+}
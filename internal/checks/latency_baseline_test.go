@@ -0,0 +1,125 @@
+package checks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestLatencyBaseline() *LatencyBaseline {
+	return NewLatencyBaseline(
+		&config.AdaptiveLatencyConfig{Multiplier: 3, Window: time.Minute, MinSamples: 10},
+		metrics.NewContainer(config.TestChainName),
+	)
+}
+
+func TestLatencyBaseline_GetThreshold(t *testing.T) {
+	baseline := newTestLatencyBaseline()
+
+	for range 9 {
+		baseline.Record("eth_call", 100*time.Millisecond)
+	}
+
+	_, isLearned := baseline.GetThreshold("eth_call")
+	assert.False(t, isLearned)
+
+	baseline.Record("eth_call", time.Second)
+
+	threshold, isLearned := baseline.GetThreshold("eth_call")
+	assert.True(t, isLearned)
+	assert.Equal(t, 300*time.Millisecond, threshold)
+
+	// Each method has its own baseline.
+	_, isLearned = baseline.GetThreshold("eth_getLogs")
+	assert.False(t, isLearned)
+}
+
+func TestLatencyCheck_AdaptiveThreshold(t *testing.T) {
+	baseline := newTestLatencyBaseline()
+	routingConfig := &config.RoutingConfig{IsEnabled: true, Latency: &config.LatencyConfig{Threshold: 10 * time.Second}}
+	metricsContainer := metrics.NewContainer(config.TestChainName)
+
+	fastUpstreamConfig := &config.UpstreamConfig{ID: "fast", HTTPURL: "http://fast"}
+	slowUpstreamConfig := &config.UpstreamConfig{ID: "slow", HTTPURL: "http://slow"}
+	fastCheck := NewLatencyChecker(fastUpstreamConfig, routingConfig, baseline, metricsContainer, zap.L()).(*LatencyCheck) //nolint:errcheck // ignore error
+	slowCheck := NewLatencyChecker(slowUpstreamConfig, routingConfig, baseline, metricsContainer, zap.L()).(*LatencyCheck) //nolint:errcheck // ignore error
+
+	for range 20 {
+		recordLatencies(fastCheck, "eth_call", 50*time.Millisecond)
+	}
+
+	// The slow upstream's latencies are far below the configured threshold, but not the learned one.
+	recordLatencies(slowCheck, "eth_call", time.Second, time.Second, time.Second)
+
+	assert.True(t, fastCheck.IsPassing([]string{"eth_call"}))
+	assert.False(t, slowCheck.IsPassing([]string{"eth_call"}))
+}
+
+func TestLatencyBaseline_LearnsFromTheWholeWindow(t *testing.T) {
+	baseline := NewLatencyBaseline(
+		&config.AdaptiveLatencyConfig{Multiplier: 3, Window: time.Minute, MinSamples: 1000},
+		metrics.NewContainer(config.TestChainName),
+	)
+
+	for range 800 {
+		baseline.Record("eth_call", 100*time.Millisecond)
+	}
+
+	_, isLearned := baseline.GetThreshold("eth_call")
+	assert.False(t, isLearned)
+
+	for range 600 {
+		baseline.Record("eth_call", time.Second)
+	}
+
+	// The median is over all latencies in the window, not only the most recent ones.
+	threshold, isLearned := baseline.GetThreshold("eth_call")
+	assert.True(t, isLearned)
+	assert.Equal(t, 300*time.Millisecond, threshold)
+}
+
+func TestLatencyHistogram_GetMedian(t *testing.T) {
+	histogram := newLatencyHistogram(time.Minute)
+
+	median, count := histogram.getMedian()
+	assert.Equal(t, time.Duration(0), median)
+	assert.Equal(t, uint64(0), count)
+
+	for _, latency := range []time.Duration{10 * time.Millisecond, 120 * time.Millisecond, 125 * time.Millisecond, time.Second, time.Hour} {
+		histogram.Record(latency)
+	}
+
+	// The median is estimated as the mean of the latencies in its bin.
+	median, count = histogram.getMedian()
+	assert.InEpsilon(t, 122500*time.Microsecond, median, 0.001)
+	assert.Equal(t, uint64(5), count)
+}
+
+func TestLatencyHistogram_Expiry(t *testing.T) {
+	histogram := newLatencyHistogram(10 * time.Millisecond)
+	histogram.Record(time.Second)
+
+	assert.Eventually(t, func() bool {
+		_, count := histogram.getMedian()
+		return count == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestLatencyCheck_AdaptiveThreshold_IgnoresCachedResponses(t *testing.T) {
+	baseline := newTestLatencyBaseline()
+	routingConfig := &config.RoutingConfig{IsEnabled: true, Latency: &config.LatencyConfig{Threshold: 10 * time.Second}}
+	upstreamConfig := &config.UpstreamConfig{ID: "upstream", HTTPURL: "http://upstream"}
+	check := NewLatencyChecker(upstreamConfig, routingConfig, baseline, metrics.NewContainer(config.TestChainName), zap.L()).(*LatencyCheck) //nolint:errcheck // ignore error
+
+	for range 20 {
+		check.RecordRequest(&types.RequestData{Method: "eth_call", Latency: time.Millisecond, Cached: true})
+	}
+
+	_, isLearned := baseline.GetThreshold("eth_call")
+	assert.False(t, isLearned)
+}
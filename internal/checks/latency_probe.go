@@ -0,0 +1,129 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/client"
+	conf "github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/types"
+	"go.uber.org/zap"
+)
+
+// LatencyProbeCheck actively calls the configured RPC methods on an upstream and records their latencies in the
+// upstream's LatencyCheck, so that upstreams without live traffic (e.g. idle fallbacks or banned upstreams) still get
+// a latency signal.
+type LatencyProbeCheck struct {
+	client           client.EthClient
+	Err              error
+	clientGetter     client.EthClientGetter
+	latencyCheck     types.ErrorLatencyChecker
+	metricsContainer *metrics.Container
+	logger           *zap.Logger
+	upstreamConfig   *conf.UpstreamConfig
+	probes           []conf.LatencyProbeMethodConfig
+	lastProbedAt     []time.Time // Index of probe -> time the probe was last sent
+}
+
+func NewLatencyProbeChecker(
+	upstreamConfig *conf.UpstreamConfig,
+	routingConfig *conf.RoutingConfig,
+	clientGetter client.EthClientGetter,
+	latencyCheck types.ErrorLatencyChecker,
+	metricsContainer *metrics.Container,
+	logger *zap.Logger,
+) types.Checker {
+	c := &LatencyProbeCheck{
+		upstreamConfig:   upstreamConfig,
+		clientGetter:     clientGetter,
+		latencyCheck:     latencyCheck,
+		metricsContainer: metricsContainer,
+		logger:           logger,
+	}
+
+	// Probes only make sense if there are latency circuit breakers to feed.
+	if routingConfig.IsEnabled && routingConfig.Latency != nil && routingConfig.Latency.Probes != nil {
+		c.probes = routingConfig.Latency.Probes.Methods
+		c.lastProbedAt = make([]time.Time, len(c.probes))
+	}
+
+	return c
+}
+
+func (c *LatencyProbeCheck) Initialize() error {
+	c.logger.Debug("Initializing LatencyProbeCheck.", zap.Any("config", c.upstreamConfig))
+
+	httpClient, err := c.clientGetter(c.upstreamConfig.HTTPURL, &c.upstreamConfig.BasicAuthConfig, &c.upstreamConfig.RequestHeadersConfig)
+	if err != nil {
+		c.Err = err
+		return c.Err
+	}
+
+	c.client = httpClient
+
+	return nil
+}
+
+func (c *LatencyProbeCheck) RunCheck() {
+	if len(c.probes) == 0 {
+		return
+	}
+
+	if c.client == nil {
+		if err := c.Initialize(); err != nil {
+			c.logger.Error("Error initializing LatencyProbeCheck.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.Error(err))
+			c.metricsContainer.LatencyProbeErrors.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, metrics.HTTPInit, "").Inc()
+
+			return
+		}
+	}
+
+	now := time.Now()
+
+	for i := range c.probes {
+		// Probes are only sent when the health checks run, so intervals shorter than the health check interval
+		// are effectively rounded up to it.
+		if now.Sub(c.lastProbedAt[i]) < c.probes[i].Interval {
+			continue
+		}
+
+		c.lastProbedAt[i] = now
+		c.runProbe(&c.probes[i])
+	}
+}
+
+func (c *LatencyProbeCheck) runProbe(probe *conf.LatencyProbeMethodConfig) {
+	runCheck := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.upstreamConfig.HealthCheckConfig.GetTimeout())
+		defer cancel()
+
+		latency, err := c.client.RecordLatency(ctx, probe.Name, probe.Params)
+		if c.Err = err; c.Err != nil {
+			c.logger.Debug("LatencyProbeCheck request failed.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("method", probe.Name), zap.Error(c.Err))
+			c.metricsContainer.LatencyProbeErrors.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, metrics.HTTPRequest, probe.Name).Inc()
+
+			// A timed out probe is still a (very high) latency signal. Any other error says nothing about latency.
+			if !errors.Is(c.Err, context.DeadlineExceeded) {
+				return
+			}
+		}
+
+		c.latencyCheck.RecordRequest(&types.RequestData{
+			Method:  probe.Name,
+			Latency: latency,
+		})
+
+		c.logger.Debug("Ran LatencyProbeCheck.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("method", probe.Name), zap.Duration("latency", latency))
+	}
+
+	runCheckWithMetrics(runCheck,
+		c.metricsContainer.LatencyProbeRequests.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, probe.Name),
+		c.metricsContainer.LatencyProbeDuration.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, probe.Name))
+}
+
+// IsPassing always returns true. Probe latencies are judged by the upstream's LatencyCheck, like live traffic.
+func (c *LatencyProbeCheck) IsPassing() bool {
+	return true
+}
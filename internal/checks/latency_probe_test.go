@@ -0,0 +1,115 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/client"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func getLatencyProbeRoutingConfig(interval time.Duration) *config.RoutingConfig {
+	return &config.RoutingConfig{
+		IsEnabled: true,
+		Latency: &config.LatencyConfig{
+			MethodLatencyThresholds: map[string]time.Duration{},
+			Threshold:               time.Second,
+			Probes: &config.LatencyProbesConfig{
+				Interval: interval,
+				Methods: []config.LatencyProbeMethodConfig{
+					{Name: "eth_blockNumber", Interval: interval},
+					{Name: "eth_getBalance", Params: []any{"0x0000000000000000000000000000000000000000", "latest"}, Interval: interval},
+				},
+			},
+		},
+	}
+}
+
+func TestLatencyProbeChecker_FeedsLatencyCheck(t *testing.T) {
+	ethClient := mocks.NewEthClient(t)
+	ethClient.EXPECT().RecordLatency(mock.Anything, "eth_blockNumber", []any(nil)).Return(2*time.Second, nil)
+	ethClient.EXPECT().RecordLatency(mock.Anything, "eth_getBalance", mock.Anything).Return(10*time.Millisecond, nil)
+
+	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
+		return ethClient, nil
+	}
+
+	routingConfig := getLatencyProbeRoutingConfig(time.Nanosecond)
+	metricsContainer := metrics.NewContainer(config.TestChainName)
+	latencyCheck := NewLatencyChecker(defaultUpstreamConfig, routingConfig, nil, metricsContainer, zap.L())
+	checker := NewLatencyProbeChecker(defaultUpstreamConfig, routingConfig, mockEthClientGetter, latencyCheck, metricsContainer, zap.L())
+
+	for i := 0; i < MinNumRequestsForRate; i++ {
+		checker.RunCheck()
+	}
+
+	ethClient.AssertNumberOfCalls(t, "RecordLatency", 2*MinNumRequestsForRate)
+	assert.True(t, checker.IsPassing())
+
+	// Only the slow method's circuit breaker is opened.
+	assert.False(t, latencyCheck.IsPassing([]string{"eth_blockNumber"}))
+	assert.True(t, latencyCheck.IsPassing([]string{"eth_getBalance"}))
+}
+
+func TestLatencyProbeChecker_Interval(t *testing.T) {
+	ethClient := mocks.NewEthClient(t)
+	ethClient.EXPECT().RecordLatency(mock.Anything, mock.Anything, mock.Anything).Return(10*time.Millisecond, nil)
+
+	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
+		return ethClient, nil
+	}
+
+	routingConfig := getLatencyProbeRoutingConfig(time.Hour)
+	metricsContainer := metrics.NewContainer(config.TestChainName)
+	latencyCheck := NewLatencyChecker(defaultUpstreamConfig, routingConfig, nil, metricsContainer, zap.L())
+	checker := NewLatencyProbeChecker(defaultUpstreamConfig, routingConfig, mockEthClientGetter, latencyCheck, metricsContainer, zap.L())
+
+	checker.RunCheck()
+	checker.RunCheck()
+
+	// The second run is within the interval, so no probes are sent.
+	ethClient.AssertNumberOfCalls(t, "RecordLatency", 2)
+}
+
+func TestLatencyProbeChecker_Errors(t *testing.T) {
+	ethClient := mocks.NewEthClient(t)
+	ethClient.EXPECT().RecordLatency(mock.Anything, "eth_blockNumber", mock.Anything).Return(config.DefaultHealthCheckTimeout, context.DeadlineExceeded)
+	ethClient.EXPECT().RecordLatency(mock.Anything, "eth_getBalance", mock.Anything).Return(time.Millisecond, errors.New("connection refused"))
+
+	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
+		return ethClient, nil
+	}
+
+	routingConfig := getLatencyProbeRoutingConfig(time.Nanosecond)
+	metricsContainer := metrics.NewContainer(config.TestChainName)
+	latencyCheck := mocks.NewErrorLatencyChecker(t)
+	latencyCheck.EXPECT().RecordRequest(mock.Anything).Return(true)
+
+	checker := NewLatencyProbeChecker(defaultUpstreamConfig, routingConfig, mockEthClientGetter, latencyCheck, metricsContainer, zap.L())
+	checker.RunCheck()
+
+	// Only the timed out probe is recorded.
+	latencyCheck.AssertNumberOfCalls(t, "RecordRequest", 1)
+}
+
+func TestLatencyProbeChecker_NotConfigured(t *testing.T) {
+	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
+		t.Error("No client should be created if no probes are configured.")
+		return nil, nil
+	}
+
+	routingConfig := &config.RoutingConfig{IsEnabled: true, Latency: &config.LatencyConfig{}}
+	metricsContainer := metrics.NewContainer(config.TestChainName)
+	latencyCheck := NewLatencyChecker(defaultUpstreamConfig, routingConfig, nil, metricsContainer, zap.L())
+
+	checker := NewLatencyProbeChecker(defaultUpstreamConfig, routingConfig, mockEthClientGetter, latencyCheck, metricsContainer, zap.L())
+	checker.RunCheck()
+	assert.True(t, checker.IsPassing())
+}
@@ -1 +1,103 @@
 package checks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestLatencyCheck(latencyConfig *config.LatencyConfig) *LatencyCheck {
+	latencyConfig.Threshold = time.Second
+	routingConfig := &config.RoutingConfig{IsEnabled: true, Latency: latencyConfig}
+
+	return NewLatencyChecker(defaultUpstreamConfig, routingConfig, nil, metrics.NewContainer(config.TestChainName), zap.L()).(*LatencyCheck) //nolint:errcheck // ignore error
+}
+
+func recordLatencies(check *LatencyCheck, method string, latencies ...time.Duration) {
+	for _, latency := range latencies {
+		check.RecordRequest(&types.RequestData{Method: method, Latency: latency})
+	}
+}
+
+func TestLatencyCheck_RateAggregation(t *testing.T) {
+	check := newTestLatencyCheck(&config.LatencyConfig{TooHighRate: 0.9, MinSamples: 5})
+
+	// A single fast latency in the detection window keeps the rate of high latencies below 90%.
+	recordLatencies(check, "eth_call", 10*time.Millisecond, 2*time.Second, 2*time.Second, 2*time.Second, 2*time.Second)
+	assert.True(t, check.IsPassing([]string{"eth_call"}))
+
+	recordLatencies(check, "eth_getLogs", 2*time.Second, 2*time.Second, 2*time.Second, 2*time.Second)
+	assert.True(t, check.IsPassing([]string{"eth_getLogs"}))
+
+	recordLatencies(check, "eth_getLogs", 2*time.Second)
+	assert.False(t, check.IsPassing([]string{"eth_getLogs"}))
+}
+
+func TestLatencyCheck_PercentileAggregation(t *testing.T) {
+	check := newTestLatencyCheck(&config.LatencyConfig{Aggregation: config.P90Aggregation, MinSamples: 10})
+
+	// A p90 at the threshold does not ban the upstream before there are enough samples.
+	recordLatencies(check, "eth_call", 2*time.Second)
+	assert.True(t, check.IsPassing([]string{"eth_call"}))
+
+	for range 8 {
+		recordLatencies(check, "eth_call", 10*time.Millisecond)
+	}
+
+	assert.True(t, check.IsPassing([]string{"eth_call"}))
+
+	// 2 out of 10 latencies are high, so the p90 is too.
+	recordLatencies(check, "eth_call", 2*time.Second)
+	assert.False(t, check.IsPassing([]string{"eth_call"}))
+	assert.True(t, check.IsPassing([]string{"eth_getLogs"}))
+}
+
+func TestLatencyCheck_MeanAggregation(t *testing.T) {
+	check := newTestLatencyCheck(&config.LatencyConfig{Aggregation: config.MeanAggregation})
+
+	recordLatencies(check, "eth_call", 100*time.Millisecond, 100*time.Millisecond, 2*time.Second)
+	assert.True(t, check.IsPassing([]string{"eth_call"}))
+
+	recordLatencies(check, "eth_call", 2*time.Second)
+	assert.False(t, check.IsPassing([]string{"eth_call"}))
+}
+
+func TestLatencyCheck_WindowStatsMetrics(t *testing.T) {
+	check := newTestLatencyCheck(&config.LatencyConfig{})
+
+	recordLatencies(check, "eth_chainId", 100*time.Millisecond)
+
+	assert.Equal(t, 0.1, testutil.ToFloat64(check.metricsContainer.LatencyWindow.WithLabelValues(defaultUpstreamConfig.ID, defaultUpstreamConfig.HTTPURL, "eth_chainId", "p50")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(check.metricsContainer.LatencyWindowSamples.WithLabelValues(defaultUpstreamConfig.ID, defaultUpstreamConfig.HTTPURL, "eth_chainId")))
+}
+
+func TestLatencyStats_EvaluatedStatsRecomputation(t *testing.T) {
+	routingConfig := &config.RoutingConfig{IsEnabled: true, Latency: &config.LatencyConfig{Aggregation: config.MeanAggregation, Threshold: time.Second}}
+	//nolint:errcheck // ignore error
+	stats := NewLatencyStats(routingConfig, "eth_call", nil).(*LatencyStats)
+
+	for range 159 {
+		stats.RecordLatency(10 * time.Millisecond)
+	}
+
+	// The stats are recomputed once they are older than latencyWindowStatsInterval.
+	stats.statsComputedAt = time.Now().Add(-latencyWindowStatsInterval)
+	stats.RecordLatency(10 * time.Millisecond)
+	assert.Equal(t, uint(160), stats.stats.Count)
+
+	// The stats of a large window are reused until it has grown by a sixteenth.
+	for range 10 {
+		stats.RecordLatency(10 * time.Millisecond)
+	}
+
+	assert.Equal(t, uint(160), stats.stats.Count)
+
+	stats.RecordLatency(10 * time.Millisecond)
+	assert.Equal(t, uint(171), stats.stats.Count)
+}
@@ -0,0 +1,138 @@
+package checks
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/config"
+)
+
+// Bounds the memory and the cost of computing percentiles for methods with a lot of traffic.
+const maxLatencyWindowSamples = 500
+
+type latencySample struct {
+	recordedAt time.Time
+	latency    time.Duration
+}
+
+// LatencyWindowStats are the statistics of the latencies in a LatencyWindow.
+type LatencyWindowStats struct {
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Count uint
+}
+
+// Get returns the statistic for the given aggregation, or zero for the rate aggregation, which is not a latency.
+func (s *LatencyWindowStats) Get(aggregation config.LatencyAggregation) time.Duration {
+	switch aggregation {
+	case config.MeanAggregation:
+		return s.Mean
+	case config.P50Aggregation:
+		return s.P50
+	case config.P90Aggregation:
+		return s.P90
+	case config.P99Aggregation:
+		return s.P99
+	default:
+		return 0
+	}
+}
+
+// LatencyWindow keeps the latencies recorded in the last detection window, up to maxLatencyWindowSamples of the most
+// recent ones.
+// This struct is thread-safe.
+type LatencyWindow struct {
+	samples []latencySample
+	window  time.Duration
+	lock    sync.Mutex
+}
+
+func NewLatencyWindow(window time.Duration) *LatencyWindow {
+	return &LatencyWindow{window: window}
+}
+
+func (w *LatencyWindow) Record(latency time.Duration) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.prune()
+
+	if len(w.samples) >= maxLatencyWindowSamples {
+		w.samples = w.samples[1:]
+	}
+
+	w.samples = append(w.samples, latencySample{recordedAt: time.Now(), latency: latency})
+}
+
+func (w *LatencyWindow) Reset() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.samples = nil
+}
+
+func (w *LatencyWindow) GetStats() LatencyWindowStats {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.prune()
+
+	if len(w.samples) == 0 {
+		return LatencyWindowStats{}
+	}
+
+	latencies := make([]time.Duration, len(w.samples))
+
+	var total time.Duration
+
+	for i, sample := range w.samples {
+		latencies[i] = sample.latency
+		total += sample.latency
+	}
+
+	slices.Sort(latencies)
+
+	return LatencyWindowStats{
+		Mean:  total / time.Duration(len(latencies)),
+		P50:   getSortedLatencyPercentile(latencies, 0.5),
+		P90:   getSortedLatencyPercentile(latencies, 0.9),
+		P99:   getSortedLatencyPercentile(latencies, 0.99),
+		Count: uint(len(latencies)),
+	}
+}
+
+// prune drops the samples recorded before the window. Requires external locking.
+func (w *LatencyWindow) prune() {
+	cutoff := time.Now().Add(-w.window)
+
+	// Samples are recorded in order, so the expired ones are at the start.
+	expired, _ := slices.BinarySearchFunc(w.samples, cutoff, func(sample latencySample, cutoff time.Time) int {
+		return sample.recordedAt.Compare(cutoff)
+	})
+
+	w.samples = w.samples[expired:]
+}
+
+// getLatencyPercentile returns the given percentile of the latencies, or zero if there are none.
+func getLatencyPercentile(latencies []time.Duration, percentile float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+
+	return getSortedLatencyPercentile(sorted, percentile)
+}
+
+// getSortedLatencyPercentile returns the given percentile of the sorted, non-empty latencies using the nearest-rank
+// method.
+func getSortedLatencyPercentile(sorted []time.Duration, percentile float64) time.Duration {
+	rank := int(math.Ceil(percentile*float64(len(sorted)))) - 1
+
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
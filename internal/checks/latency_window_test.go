@@ -0,0 +1,56 @@
+package checks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyWindow_GetStats(t *testing.T) {
+	window := NewLatencyWindow(time.Minute)
+	assert.Equal(t, LatencyWindowStats{}, window.GetStats())
+
+	for i := 1; i <= 100; i++ {
+		window.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	stats := window.GetStats()
+	assert.Equal(t, uint(100), stats.Count)
+	assert.Equal(t, 50500*time.Microsecond, stats.Mean)
+	assert.Equal(t, 50*time.Millisecond, stats.Get(config.P50Aggregation))
+	assert.Equal(t, 90*time.Millisecond, stats.Get(config.P90Aggregation))
+	assert.Equal(t, 99*time.Millisecond, stats.Get(config.P99Aggregation))
+	assert.Equal(t, time.Duration(0), stats.Get(config.RateAggregation))
+
+	window.Reset()
+	assert.Equal(t, uint(0), window.GetStats().Count)
+}
+
+func TestLatencyWindow_Expiry(t *testing.T) {
+	window := NewLatencyWindow(50 * time.Millisecond)
+
+	window.Record(time.Second)
+	time.Sleep(50 * time.Millisecond)
+	window.Record(time.Millisecond)
+
+	stats := window.GetStats()
+	assert.Equal(t, uint(1), stats.Count)
+	assert.Equal(t, time.Millisecond, stats.P99)
+}
+
+func TestLatencyWindow_MaxSamples(t *testing.T) {
+	window := NewLatencyWindow(time.Minute)
+
+	window.Record(time.Hour)
+
+	for range maxLatencyWindowSamples {
+		window.Record(time.Millisecond)
+	}
+
+	// The oldest sample is dropped.
+	stats := window.GetStats()
+	assert.Equal(t, uint(maxLatencyWindowSamples), stats.Count)
+	assert.Equal(t, time.Millisecond, stats.P99)
+}
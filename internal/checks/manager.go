@@ -2,6 +2,7 @@ package checks
 
 import (
 	"fmt"
+	"math/rand/v2"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,10 +16,6 @@ import (
 	conf "github.com/satsuma-data/node-gateway/internal/config"
 )
 
-const (
-	PeriodicHealthCheckInterval = 5 * time.Second
-)
-
 type NewBlockHeightCheck func(
 	config *conf.UpstreamConfig,
 	clientGetter client.EthClientGetter,
@@ -59,11 +56,35 @@ type healthCheckManager struct {
 	newLatencyCheck func(
 		*conf.UpstreamConfig,
 		*conf.RoutingConfig,
+		*LatencyBaseline,
 		*metrics.Container,
 		*zap.Logger,
 	) types.ErrorLatencyChecker
+	newLatencyProbeCheck func(
+		*conf.UpstreamConfig,
+		*conf.RoutingConfig,
+		client.EthClientGetter,
+		types.ErrorLatencyChecker,
+		*metrics.Container,
+		*zap.Logger,
+	) types.Checker
+	newSyntheticProbeCheck func(
+		*conf.UpstreamConfig,
+		*conf.SyntheticProbeConfig,
+		client.EthClientGetter,
+		*metrics.Container,
+		*zap.Logger,
+	) types.SyntheticProbeChecker
+	newClientVersionCheck func(
+		*conf.UpstreamConfig,
+		client.EthClientGetter,
+		*metrics.Container,
+		*zap.Logger,
+	) types.ClientVersionChecker
+	outlierDetector     *OutlierDetector
+	latencyBaseline     *LatencyBaseline
 	ethClientGetter     client.EthClientGetter
-	healthCheckTicker   *time.Ticker
+	newTicker           func(time.Duration) *time.Ticker
 	metricsContainer    *metrics.Container
 	logger              *zap.Logger
 	globalRoutingConfig conf.RoutingConfig
@@ -78,24 +99,38 @@ func NewHealthCheckManager(
 	routingConfig conf.RoutingConfig,
 	globalRoutingConfig conf.RoutingConfig,
 	blockHeightObserver BlockHeightObserver,
-	healthCheckTicker *time.Ticker,
 	metricsContainer *metrics.Container,
 	logger *zap.Logger,
 ) HealthCheckManager {
+	var outlierDetector *OutlierDetector
+	if routingConfig.OutlierDetection != nil {
+		outlierDetector = NewOutlierDetector(config, routingConfig.OutlierDetection, metricsContainer, logger)
+	}
+
+	var latencyBaseline *LatencyBaseline
+	if routingConfig.Latency != nil && routingConfig.Latency.Adaptive != nil {
+		latencyBaseline = NewLatencyBaseline(routingConfig.Latency.Adaptive, metricsContainer)
+	}
+
 	return &healthCheckManager{
-		upstreamIDToStatus:  make(map[string]*types.UpstreamStatus),
-		ethClientGetter:     ethClientGetter,
-		configs:             config,
-		routingConfig:       routingConfig,
-		globalRoutingConfig: globalRoutingConfig,
-		newBlockHeightCheck: NewBlockHeightChecker,
-		newPeerCheck:        NewPeerChecker,
-		newErrorCheck:       NewErrorChecker,
-		newLatencyCheck:     NewLatencyChecker,
-		blockHeightObserver: blockHeightObserver,
-		healthCheckTicker:   healthCheckTicker,
-		metricsContainer:    metricsContainer,
-		logger:              logger,
+		upstreamIDToStatus:     make(map[string]*types.UpstreamStatus),
+		ethClientGetter:        ethClientGetter,
+		configs:                config,
+		routingConfig:          routingConfig,
+		globalRoutingConfig:    globalRoutingConfig,
+		newBlockHeightCheck:    NewBlockHeightChecker,
+		newPeerCheck:           NewPeerChecker,
+		newErrorCheck:          NewErrorChecker,
+		newLatencyCheck:        NewLatencyChecker,
+		newLatencyProbeCheck:   NewLatencyProbeChecker,
+		newSyntheticProbeCheck: NewSyntheticProbeChecker,
+		newClientVersionCheck:  NewClientVersionChecker,
+		outlierDetector:        outlierDetector,
+		latencyBaseline:        latencyBaseline,
+		blockHeightObserver:    blockHeightObserver,
+		newTicker:              time.NewTicker,
+		metricsContainer:       metricsContainer,
+		logger:                 logger,
 	}
 }
 
@@ -130,6 +165,10 @@ func (h *healthCheckManager) RecordRequest(upstreamID string, data *types.Reques
 	if !isError {
 		h.GetUpstreamStatus(upstreamID).LatencyCheck.RecordRequest(data)
 	}
+
+	if h.outlierDetector != nil {
+		h.outlierDetector.RecordRequest(upstreamID, data, isError)
+	}
 }
 
 func (h *healthCheckManager) setUpstreamStatus(upstreamID string, status *types.UpstreamStatus) {
@@ -208,6 +247,7 @@ func (h *healthCheckManager) initializeChecks() {
 				latencyCheck = h.newLatencyCheck(
 					&config,
 					&h.routingConfig,
+					h.latencyBaseline,
 					h.metricsContainer,
 					h.logger,
 				)
@@ -215,14 +255,51 @@ func (h *healthCheckManager) initializeChecks() {
 
 			innerWG.Wait()
 
+			// The probes feed the latency check, so it must be created first.
+			latencyProbeCheck := h.newLatencyProbeCheck(
+				&config,
+				&h.routingConfig,
+				client.NewEthClient,
+				latencyCheck,
+				h.metricsContainer,
+				h.logger,
+			)
+
+			syntheticProbeChecks := make([]types.SyntheticProbeChecker, len(config.SyntheticProbes))
+			for probeIndex := range config.SyntheticProbes {
+				syntheticProbeChecks[probeIndex] = h.newSyntheticProbeCheck(
+					&config,
+					&config.SyntheticProbes[probeIndex],
+					client.NewEthClient,
+					h.metricsContainer,
+					h.logger,
+				)
+			}
+
+			clientVersionCheck := h.newClientVersionCheck(
+				&config,
+				client.NewEthClient,
+				h.metricsContainer,
+				h.logger,
+			)
+
+			var outlierCheck types.Checker
+			if h.outlierDetector != nil {
+				outlierCheck = NewOutlierChecker(config.ID, h.outlierDetector)
+			}
+
 			mutex.Lock()
 			h.setUpstreamStatus(config.ID, &types.UpstreamStatus{
-				ID:               config.ID,
-				GroupID:          config.GroupID,
-				BlockHeightCheck: blockHeightCheck,
-				PeerCheck:        peerCheck,
-				ErrorCheck:       errorCheck,
-				LatencyCheck:     latencyCheck,
+				ID:                   config.ID,
+				GroupID:              config.GroupID,
+				BlockHeightCheck:     blockHeightCheck,
+				PeerCheck:            peerCheck,
+				ErrorCheck:           errorCheck,
+				LatencyCheck:         latencyCheck,
+				LatencyProbeCheck:    latencyProbeCheck,
+				SyntheticProbeChecks: syntheticProbeChecks,
+				OutlierCheck:         outlierCheck,
+				ClientVersionCheck:   clientVersionCheck,
 			})
 			mutex.Unlock()
 		}()
@@ -234,8 +311,22 @@ func (h *healthCheckManager) initializeChecks() {
 func (h *healthCheckManager) runPeriodicChecks() {
 	h.runChecksOnce()
 
-	for range h.healthCheckTicker.C {
-		h.runChecksOnce()
+	// Each upstream is checked on its own schedule, since the health check policy can differ between upstreams.
+	for i := range h.configs {
+		go h.runPeriodicChecksForUpstream(&h.configs[i])
+	}
+}
+
+func (h *healthCheckManager) runPeriodicChecksForUpstream(config *conf.UpstreamConfig) {
+	ticker := h.newTicker(config.HealthCheckConfig.GetInterval())
+	jitter := config.HealthCheckConfig.GetJitter()
+
+	for range ticker.C {
+		if jitter > 0 {
+			time.Sleep(rand.N(jitter))
+		}
+
+		h.runChecksForUpstream(config)
 	}
 }
 
@@ -243,27 +334,68 @@ func (h *healthCheckManager) runChecksOnce() {
 	var wg sync.WaitGroup
 
 	for i := range h.configs {
-		config := h.configs[i]
-		h.logger.Debug("Running healthchecks on upstream.", zap.String("upstreamID", config.ID))
+		config := &h.configs[i]
 
 		wg.Add(1)
 
-		go func(c types.BlockHeightChecker) {
+		go func() {
 			defer wg.Done()
-			c.RunCheck()
-		}(h.GetUpstreamStatus(config.ID).BlockHeightCheck)
+			h.runChecksForUpstream(config)
+		}()
+	}
+
+	wg.Wait()
+
+	h.isInitialized.Store(true)
+}
 
+func (h *healthCheckManager) runChecksForUpstream(config *conf.UpstreamConfig) {
+	h.logger.Debug("Running healthchecks on upstream.", zap.String("upstreamID", config.ID))
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func(c types.BlockHeightChecker) {
+		defer wg.Done()
+		c.RunCheck()
+	}(h.GetUpstreamStatus(config.ID).BlockHeightCheck)
+
+	wg.Add(1)
+
+	go func(c types.Checker) {
+		defer wg.Done()
+		c.RunCheck()
+	}(h.GetUpstreamStatus(config.ID).PeerCheck)
+
+	wg.Add(1)
+
+	go func(c types.Checker) {
+		defer wg.Done()
+		c.RunCheck()
+	}(h.GetUpstreamStatus(config.ID).LatencyProbeCheck)
+
+	wg.Add(1)
+
+	go func(c types.ClientVersionChecker) {
+		defer wg.Done()
+		c.RunCheck()
+	}(h.GetUpstreamStatus(config.ID).ClientVersionCheck)
+
+	if outlierCheck := h.GetUpstreamStatus(config.ID).OutlierCheck; outlierCheck != nil {
+		outlierCheck.RunCheck()
+	}
+
+	for _, syntheticProbeCheck := range h.GetUpstreamStatus(config.ID).SyntheticProbeChecks {
 		wg.Add(1)
 
-		go func(c types.Checker) {
+		go func(c types.SyntheticProbeChecker) {
 			defer wg.Done()
 			c.RunCheck()
-		}(h.GetUpstreamStatus(config.ID).PeerCheck)
+		}(syntheticProbeCheck)
 	}
 
 	wg.Wait()
-
-	h.isInitialized.Store(true)
 }
 
 func (h *healthCheckManager) IsInitialized() bool {
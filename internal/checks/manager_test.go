@@ -48,10 +48,12 @@ func TestHealthCheckManager(t *testing.T) {
 		routingConfig,
 		globalRoutingConfig,
 		nil,
-		ticker,
 		metricsContainer,
 		zap.L(),
 	)
+	manager.(*healthCheckManager).newTicker = func(time.Duration) *time.Ticker {
+		return ticker
+	}
 	manager.(*healthCheckManager).newBlockHeightCheck = func( //nolint:errcheck // ignore error
 		*config.UpstreamConfig,
 		client.EthClientGetter,
@@ -0,0 +1,253 @@
+package checks
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	conf "github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/types"
+	"go.uber.org/zap"
+)
+
+const (
+	// The median of two upstreams is their average, so a bad upstream cannot be told apart from a good one.
+	minOutlierGroupSize = 3
+	// Latencies are sampled once an upstream has served more requests than this in an interval.
+	maxOutlierLatencySamples = 1000
+)
+
+type outlierStats struct {
+	latencies []time.Duration
+	requests  uint
+	errors    uint
+}
+
+func (s *outlierStats) getErrorRate() float64 {
+	return float64(s.errors) / float64(s.requests)
+}
+
+type outlier struct {
+	upstreamID string
+	reason     string
+	score      float64
+}
+
+// OutlierDetector periodically compares the error rate and latency percentile of each upstream with the median of its
+// group, over the requests since the previous comparison, and ejects the upstreams that are far worse than the
+// median. Since the comparison is relative, a network-wide slowdown ejects nothing, and a single bad upstream is
+// ejected even if it does not exceed any absolute threshold. At most MaxEjectionPercent of a group is ejected at once.
+type OutlierDetector struct {
+	lastEvaluatedAt          time.Time
+	statsByUpstreamID        map[string]*outlierStats
+	ejectedUntilByUpstreamID map[string]time.Time
+	upstreamIDsByGroupID     map[string][]string
+	config                   *conf.OutlierDetectionConfig
+	metricsContainer         *metrics.Container
+	logger                   *zap.Logger
+	lock                     sync.Mutex
+}
+
+func NewOutlierDetector(
+	upstreamConfigs []conf.UpstreamConfig,
+	config *conf.OutlierDetectionConfig,
+	metricsContainer *metrics.Container,
+	logger *zap.Logger,
+) *OutlierDetector {
+	upstreamIDsByGroupID := make(map[string][]string)
+
+	for i := range upstreamConfigs {
+		// Reference-only upstreams are never routed to, so there is nothing to compare.
+		if !upstreamConfigs[i].ReferenceOnly {
+			groupID := upstreamConfigs[i].GroupID
+			upstreamIDsByGroupID[groupID] = append(upstreamIDsByGroupID[groupID], upstreamConfigs[i].ID)
+		}
+	}
+
+	return &OutlierDetector{
+		lastEvaluatedAt:          time.Now(),
+		statsByUpstreamID:        make(map[string]*outlierStats),
+		ejectedUntilByUpstreamID: make(map[string]time.Time),
+		upstreamIDsByGroupID:     upstreamIDsByGroupID,
+		config:                   config,
+		metricsContainer:         metricsContainer,
+		logger:                   logger,
+	}
+}
+
+// RecordRequest records the outcome of a request to the upstream. The latency of failed requests is not recorded, and
+// responses served from the cache are ignored since they say nothing about the upstream.
+func (d *OutlierDetector) RecordRequest(upstreamID string, data *types.RequestData, isError bool) {
+	if data.Cached {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	stats, ok := d.statsByUpstreamID[upstreamID]
+	if !ok {
+		stats = &outlierStats{}
+		d.statsByUpstreamID[upstreamID] = stats
+	}
+
+	stats.requests++
+
+	if isError {
+		stats.errors++
+		return
+	}
+
+	// Reservoir sampling keeps the samples representative of the whole interval.
+	if len(stats.latencies) < maxOutlierLatencySamples {
+		stats.latencies = append(stats.latencies, data.Latency)
+	} else if i := rand.N(stats.requests); i < maxOutlierLatencySamples {
+		stats.latencies[i] = data.Latency
+	}
+}
+
+func (d *OutlierDetector) IsEjected(upstreamID string) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return time.Now().Before(d.ejectedUntilByUpstreamID[upstreamID])
+}
+
+// EvaluateIfDue compares the upstreams if the interval has passed since the previous comparison.
+func (d *OutlierDetector) EvaluateIfDue() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if time.Since(d.lastEvaluatedAt) < d.config.Interval {
+		return
+	}
+
+	d.evaluate()
+}
+
+// evaluate ejects the outliers of each group and starts a new interval. Requires external locking.
+func (d *OutlierDetector) evaluate() {
+	now := time.Now()
+
+	for _, upstreamIDs := range d.upstreamIDsByGroupID {
+		maxEjected := len(upstreamIDs) * int(d.config.MaxEjectionPercent) / 100
+		ejected := 0
+
+		for _, upstreamID := range upstreamIDs {
+			if now.Before(d.ejectedUntilByUpstreamID[upstreamID]) {
+				ejected++
+			}
+		}
+
+		for _, outlier := range d.findOutliers(upstreamIDs, now) {
+			if ejected >= maxEjected {
+				d.logger.Warn("Not ejecting outlier because too many upstreams in its group are ejected.", zap.Any("upstreamID", outlier.upstreamID), zap.String("reason", outlier.reason))
+				continue
+			}
+
+			ejected++
+			d.ejectedUntilByUpstreamID[outlier.upstreamID] = now.Add(d.config.EjectionDuration)
+
+			d.logger.Info("Ejecting outlier upstream.", zap.Any("upstreamID", outlier.upstreamID), zap.String("reason", outlier.reason), zap.Duration("ejectionDuration", d.config.EjectionDuration))
+			d.metricsContainer.OutlierEjections.WithLabelValues(outlier.upstreamID, outlier.reason).Inc()
+		}
+
+		for _, upstreamID := range upstreamIDs {
+			if now.Before(d.ejectedUntilByUpstreamID[upstreamID]) {
+				d.metricsContainer.OutlierEjected.WithLabelValues(upstreamID).Set(1)
+			} else {
+				d.metricsContainer.OutlierEjected.WithLabelValues(upstreamID).Set(0)
+			}
+		}
+	}
+
+	d.lastEvaluatedAt = now
+	d.statsByUpstreamID = make(map[string]*outlierStats)
+}
+
+// findOutliers returns the outliers among the given upstreams of a group that are not already ejected, worst first.
+// Error rate outliers are worse than latency outliers. Requires external locking.
+func (d *OutlierDetector) findOutliers(upstreamIDs []string, now time.Time) []outlier {
+	candidateIDs := make([]string, 0, len(upstreamIDs))
+
+	for _, upstreamID := range upstreamIDs {
+		stats, ok := d.statsByUpstreamID[upstreamID]
+		if ok && stats.requests >= d.config.MinRequests && !now.Before(d.ejectedUntilByUpstreamID[upstreamID]) {
+			candidateIDs = append(candidateIDs, upstreamID)
+		}
+	}
+
+	if len(candidateIDs) < minOutlierGroupSize {
+		return nil
+	}
+
+	errorRates := make([]float64, len(candidateIDs))
+	latencies := make([]float64, len(candidateIDs))
+
+	for i, upstreamID := range candidateIDs {
+		stats := d.statsByUpstreamID[upstreamID]
+		errorRates[i] = stats.getErrorRate()
+		latencies[i] = getLatencyPercentile(stats.latencies, d.config.LatencyPercentile).Seconds()
+	}
+
+	medianErrorRate := getMedian(errorRates)
+	medianLatency := getMedian(latencies)
+
+	var errorRateOutliers, latencyOutliers []outlier
+
+	for i, upstreamID := range candidateIDs {
+		switch {
+		case errorRates[i] > medianErrorRate*d.config.ErrorRateFactor && errorRates[i]-medianErrorRate >= d.config.MinErrorRateDelta:
+			errorRateOutliers = append(errorRateOutliers, outlier{upstreamID, metrics.OutlierErrorRate, errorRates[i]})
+		case medianLatency > 0 && latencies[i] > medianLatency*d.config.LatencyFactor:
+			latencyOutliers = append(latencyOutliers, outlier{upstreamID, metrics.OutlierLatency, latencies[i]})
+		}
+	}
+
+	byScore := func(a, b outlier) int {
+		return cmp.Compare(b.score, a.score)
+	}
+
+	slices.SortFunc(errorRateOutliers, byScore)
+	slices.SortFunc(latencyOutliers, byScore)
+
+	return append(errorRateOutliers, latencyOutliers...)
+}
+
+func getMedian(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+
+	return sorted[middle]
+}
+
+// OutlierCheck exposes whether an upstream is ejected by the chain's OutlierDetector as a health check.
+type OutlierCheck struct {
+	detector   *OutlierDetector
+	upstreamID string
+}
+
+func NewOutlierChecker(upstreamID string, detector *OutlierDetector) types.Checker {
+	return &OutlierCheck{
+		detector:   detector,
+		upstreamID: upstreamID,
+	}
+}
+
+// RunCheck compares the upstreams if it is due. All upstreams share the detector, so it runs at most once per interval
+// no matter which upstream's health checks trigger it.
+func (c *OutlierCheck) RunCheck() {
+	c.detector.EvaluateIfDue()
+}
+
+func (c *OutlierCheck) IsPassing() bool {
+	return !c.detector.IsEjected(c.upstreamID)
+}
@@ -0,0 +1,180 @@
+package checks
+
+import (
+	"cmp"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestOutlierDetector(upstreamCount int, outlierConfig *config.OutlierDetectionConfig) *OutlierDetector {
+	upstreamConfigs := make([]config.UpstreamConfig, 0, upstreamCount+1)
+	for i := range upstreamCount {
+		upstreamConfigs = append(upstreamConfigs, config.UpstreamConfig{ID: fmt.Sprintf("upstream%d", i), GroupID: "primary"})
+	}
+
+	upstreamConfigs = append(upstreamConfigs, config.UpstreamConfig{ID: "reference", ReferenceOnly: true})
+
+	// Use the defaults for anything the test does not set.
+	outlierConfig.Interval = cmp.Or(outlierConfig.Interval, config.DefaultOutlierInterval)
+	outlierConfig.EjectionDuration = cmp.Or(outlierConfig.EjectionDuration, config.DefaultOutlierEjectionDuration)
+	outlierConfig.MinRequests = cmp.Or(outlierConfig.MinRequests, config.DefaultOutlierMinRequests)
+	outlierConfig.ErrorRateFactor = cmp.Or(outlierConfig.ErrorRateFactor, config.DefaultOutlierErrorRateFactor)
+	outlierConfig.MinErrorRateDelta = cmp.Or(outlierConfig.MinErrorRateDelta, config.DefaultOutlierMinErrorRateDelta)
+	outlierConfig.LatencyFactor = cmp.Or(outlierConfig.LatencyFactor, config.DefaultOutlierLatencyFactor)
+	outlierConfig.LatencyPercentile = cmp.Or(outlierConfig.LatencyPercentile, config.DefaultOutlierLatencyPercentile)
+	outlierConfig.MaxEjectionPercent = cmp.Or(outlierConfig.MaxEjectionPercent, config.DefaultOutlierMaxEjectionPercent)
+
+	return NewOutlierDetector(upstreamConfigs, outlierConfig, metrics.NewContainer(config.TestChainName), zap.L())
+}
+
+// recordRequests records 100 requests to the upstream with the given error rate and latency.
+func recordRequests(detector *OutlierDetector, upstreamID string, errorRate float64, latency time.Duration) {
+	for i := range 100 {
+		detector.RecordRequest(upstreamID, &types.RequestData{Latency: latency}, float64(i) < errorRate*100)
+	}
+}
+
+func TestOutlierDetector_ErrorRateOutlier(t *testing.T) {
+	detector := newTestOutlierDetector(4, &config.OutlierDetectionConfig{})
+
+	recordRequests(detector, "upstream0", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream1", 0.01, 100*time.Millisecond)
+	recordRequests(detector, "upstream2", 0.02, 100*time.Millisecond)
+	recordRequests(detector, "upstream3", 0.5, 100*time.Millisecond)
+	detector.evaluate()
+
+	assert.False(t, detector.IsEjected("upstream0"))
+	assert.False(t, detector.IsEjected("upstream1"))
+	assert.False(t, detector.IsEjected("upstream2"))
+	assert.True(t, detector.IsEjected("upstream3"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(detector.metricsContainer.OutlierEjected.WithLabelValues("upstream3")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(detector.metricsContainer.OutlierEjections.WithLabelValues("upstream3", metrics.OutlierErrorRate)))
+}
+
+func TestOutlierDetector_LatencyOutlier(t *testing.T) {
+	detector := newTestOutlierDetector(3, &config.OutlierDetectionConfig{})
+
+	recordRequests(detector, "upstream0", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream1", 0.0, 120*time.Millisecond)
+	recordRequests(detector, "upstream2", 0.0, time.Second)
+	detector.evaluate()
+
+	assert.False(t, detector.IsEjected("upstream0"))
+	assert.False(t, detector.IsEjected("upstream1"))
+	assert.True(t, detector.IsEjected("upstream2"))
+}
+
+func TestOutlierDetector_NetworkWideSlowdown(t *testing.T) {
+	detector := newTestOutlierDetector(3, &config.OutlierDetectionConfig{})
+
+	// Every upstream is slow and failing, so none of them is an outlier.
+	recordRequests(detector, "upstream0", 0.4, 10*time.Second)
+	recordRequests(detector, "upstream1", 0.5, 11*time.Second)
+	recordRequests(detector, "upstream2", 0.6, 12*time.Second)
+	detector.evaluate()
+
+	assert.False(t, detector.IsEjected("upstream0"))
+	assert.False(t, detector.IsEjected("upstream1"))
+	assert.False(t, detector.IsEjected("upstream2"))
+}
+
+func TestOutlierDetector_MaxEjectionPercent(t *testing.T) {
+	detector := newTestOutlierDetector(5, &config.OutlierDetectionConfig{MaxEjectionPercent: 20})
+
+	recordRequests(detector, "upstream0", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream1", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream2", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream3", 0.3, 100*time.Millisecond)
+	recordRequests(detector, "upstream4", 0.6, 100*time.Millisecond)
+	detector.evaluate()
+
+	// Only one of the five upstreams can be ejected, so the worst one is.
+	assert.False(t, detector.IsEjected("upstream3"))
+	assert.True(t, detector.IsEjected("upstream4"))
+
+	// The ejected upstream still counts towards the cap in the next interval.
+	recordRequests(detector, "upstream0", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream1", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream2", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream3", 0.3, 100*time.Millisecond)
+	detector.evaluate()
+
+	assert.False(t, detector.IsEjected("upstream3"))
+
+	// The cap is rounded down, so it can prevent any ejection.
+	detector = newTestOutlierDetector(3, &config.OutlierDetectionConfig{MaxEjectionPercent: 20})
+
+	recordRequests(detector, "upstream0", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream1", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream2", 1.0, 100*time.Millisecond)
+	detector.evaluate()
+
+	assert.False(t, detector.IsEjected("upstream2"))
+}
+
+func TestOutlierDetector_IgnoresCachedResponses(t *testing.T) {
+	detector := newTestOutlierDetector(3, &config.OutlierDetectionConfig{})
+
+	recordRequests(detector, "upstream0", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream1", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream2", 0.0, time.Second)
+
+	// Cached responses would make the slow upstream look as fast as the others.
+	for range 1000 {
+		detector.RecordRequest("upstream2", &types.RequestData{Latency: time.Millisecond, Cached: true}, false)
+	}
+
+	detector.evaluate()
+
+	assert.True(t, detector.IsEjected("upstream2"))
+}
+
+func TestOutlierDetector_NotEnoughData(t *testing.T) {
+	detector := newTestOutlierDetector(3, &config.OutlierDetectionConfig{MinRequests: 200})
+
+	recordRequests(detector, "upstream0", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream1", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream2", 1.0, 100*time.Millisecond)
+	detector.evaluate()
+
+	// No upstream has enough requests to be compared.
+	assert.False(t, detector.IsEjected("upstream2"))
+
+	detector = newTestOutlierDetector(2, &config.OutlierDetectionConfig{})
+
+	recordRequests(detector, "upstream0", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream1", 1.0, 100*time.Millisecond)
+	detector.evaluate()
+
+	// Two upstreams are too few to tell which one is the outlier.
+	assert.False(t, detector.IsEjected("upstream1"))
+}
+
+func TestOutlierCheck(t *testing.T) {
+	ejectionDuration := 50 * time.Millisecond
+	detector := newTestOutlierDetector(3, &config.OutlierDetectionConfig{Interval: time.Hour, EjectionDuration: ejectionDuration})
+	check := NewOutlierChecker("upstream2", detector)
+
+	recordRequests(detector, "upstream0", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream1", 0.0, 100*time.Millisecond)
+	recordRequests(detector, "upstream2", 1.0, 100*time.Millisecond)
+
+	// The interval has not passed yet.
+	check.RunCheck()
+	assert.True(t, check.IsPassing())
+
+	detector.lastEvaluatedAt = time.Now().Add(-time.Hour)
+	check.RunCheck()
+	assert.False(t, check.IsPassing())
+
+	time.Sleep(ejectionDuration)
+	assert.True(t, check.IsPassing())
+}
@@ -90,3 +90,27 @@ func TestPeerChecker_SkipPeerCountCheck(t *testing.T) {
 	checker.RunCheck()
 	ethClient.AssertNumberOfCalls(t, "PeerCount", 1)
 }
+
+func TestPeerChecker_MinPeerCount(t *testing.T) {
+	ethClient := mocks.NewEthClient(t)
+	ethClient.EXPECT().PeerCount(mock.Anything).Return(uint64(1), nil)
+
+	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
+		return ethClient, nil
+	}
+
+	minPeerCount := uint64(1)
+	upstreamConfig := &config.UpstreamConfig{
+		ID:      "eth_mainnet",
+		HTTPURL: "http://alchemy",
+		WSURL:   "wss://alchemy",
+		HealthCheckConfig: config.HealthCheckConfig{
+			MinPeerCount: &minPeerCount,
+		},
+	}
+
+	checker := NewPeerChecker(upstreamConfig, mockEthClientGetter, metrics.NewContainer(config.TestChainName), zap.L())
+
+	assert.True(t, checker.IsPassing())
+	ethClient.AssertNumberOfCalls(t, "PeerCount", 1)
+}
@@ -0,0 +1,141 @@
+package checks
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/failsafe-go/failsafe-go/circuitbreaker"
+	"github.com/satsuma-data/node-gateway/internal/config"
+)
+
+type recoveryPhase int
+
+const (
+	recoveryPhaseClosed recoveryPhase = iota
+	recoveryPhaseOpen
+	recoveryPhaseHalfOpen
+	recoveryPhaseSlowStart
+)
+
+// RecoveringCircuitBreaker wraps a circuit breaker so that, if a RecoveryConfig is set, an upstream whose ban expired
+// goes through a half-open and then a slow-start phase before getting its full share of traffic again.
+// This struct is thread-safe.
+type RecoveringCircuitBreaker struct {
+	slowStartedAt     time.Time
+	circuitBreaker    circuitbreaker.CircuitBreaker[any]
+	recoveryConfig    *config.RecoveryConfig
+	lock              sync.Mutex
+	phase             recoveryPhase
+	halfOpenSuccesses uint
+}
+
+func NewRecoveringCircuitBreaker(
+	circuitBreaker circuitbreaker.CircuitBreaker[any],
+	recoveryConfig *config.RecoveryConfig,
+) *RecoveringCircuitBreaker {
+	return &RecoveringCircuitBreaker{
+		circuitBreaker: circuitBreaker,
+		recoveryConfig: recoveryConfig,
+	}
+}
+
+func (b *RecoveringCircuitBreaker) RecordSuccess() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.phase == recoveryPhaseHalfOpen {
+		b.halfOpenSuccesses++
+
+		if b.halfOpenSuccesses >= b.recoveryConfig.HalfOpenSuccesses {
+			b.phase = recoveryPhaseSlowStart
+			b.slowStartedAt = time.Now()
+		}
+	}
+
+	b.circuitBreaker.RecordSuccess()
+}
+
+func (b *RecoveringCircuitBreaker) RecordFailure() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// The upstream has not proven itself yet, so a single failure bans it again.
+	if b.phase == recoveryPhaseHalfOpen {
+		b.phase = recoveryPhaseOpen
+		b.circuitBreaker.Open()
+
+		return
+	}
+
+	b.circuitBreaker.RecordFailure()
+}
+
+// Open opens the circuit breaker regardless of the recorded failure rate, e.g. because an aggregated latency is too
+// high.
+func (b *RecoveringCircuitBreaker) Open() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.phase = recoveryPhaseOpen
+	b.circuitBreaker.Open()
+}
+
+func (b *RecoveringCircuitBreaker) IsOpen() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// TODO(polsar): We should be able to check `b.circuitBreaker.IsOpen()`,
+	//  but it appears to remain open forever, regardless of the configured delay.
+	//  We also must reset the circuit breaker manually if it is not supposed to be open.
+	isOpen := b.circuitBreaker.RemainingDelay() > 0
+	if isOpen {
+		b.phase = recoveryPhaseOpen
+		return true
+	}
+
+	b.circuitBreaker.Close()
+
+	if b.phase == recoveryPhaseOpen {
+		if b.recoveryConfig == nil {
+			b.phase = recoveryPhaseClosed
+		} else {
+			b.phase = recoveryPhaseHalfOpen
+			b.halfOpenSuccesses = 0
+		}
+	}
+
+	return false
+}
+
+// GetTrafficShare returns the share of traffic in [0.0, 1.0] the upstream should currently get.
+func (b *RecoveringCircuitBreaker) GetTrafficShare() float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.phase {
+	case recoveryPhaseOpen:
+		return 0.0
+	case recoveryPhaseHalfOpen:
+		return b.recoveryConfig.HalfOpenTrafficShare
+	case recoveryPhaseSlowStart:
+		elapsed := time.Since(b.slowStartedAt)
+		if elapsed >= b.recoveryConfig.SlowStartWindow {
+			b.phase = recoveryPhaseClosed
+			return 1.0
+		}
+
+		minShare := b.recoveryConfig.HalfOpenTrafficShare
+
+		return minShare + (1.0-minShare)*float64(elapsed)/float64(b.recoveryConfig.SlowStartWindow)
+	default:
+		return 1.0
+	}
+}
+
+// IsAdmitted randomly admits requests in proportion to the current traffic share. It should be called after IsOpen.
+func (b *RecoveringCircuitBreaker) IsAdmitted() bool {
+	share := b.GetTrafficShare()
+
+	return share >= 1.0 || rand.Float64() < share
+}
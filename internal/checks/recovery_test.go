@@ -0,0 +1,81 @@
+package checks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const recoveryTestBanWindow = 50 * time.Millisecond
+
+func newTestRecoveringCircuitBreaker(recoveryConfig *config.RecoveryConfig) *RecoveringCircuitBreaker {
+	return NewRecoveringCircuitBreaker(NewCircuitBreaker(0.5, time.Minute, recoveryTestBanWindow), recoveryConfig)
+}
+
+func banCircuitBreaker(t *testing.T, breaker *RecoveringCircuitBreaker) {
+	t.Helper()
+
+	for i := 0; i < MinNumRequestsForRate; i++ {
+		breaker.RecordFailure()
+	}
+
+	assert.True(t, breaker.IsOpen())
+	assert.Equal(t, 0.0, breaker.GetTrafficShare())
+	assert.False(t, breaker.IsAdmitted())
+}
+
+func TestRecoveringCircuitBreaker_NoRecoveryConfig(t *testing.T) {
+	breaker := newTestRecoveringCircuitBreaker(nil)
+	assert.False(t, breaker.IsOpen())
+	assert.Equal(t, 1.0, breaker.GetTrafficShare())
+
+	banCircuitBreaker(t, breaker)
+
+	// The upstream gets its full share of traffic as soon as the ban expires.
+	time.Sleep(2 * recoveryTestBanWindow)
+	assert.False(t, breaker.IsOpen())
+	assert.Equal(t, 1.0, breaker.GetTrafficShare())
+	assert.True(t, breaker.IsAdmitted())
+}
+
+func TestRecoveringCircuitBreaker_HalfOpenAndSlowStart(t *testing.T) {
+	slowStartWindow := 200 * time.Millisecond
+	breaker := newTestRecoveringCircuitBreaker(&config.RecoveryConfig{
+		HalfOpenTrafficShare: 0.25,
+		HalfOpenSuccesses:    2,
+		SlowStartWindow:      slowStartWindow,
+	})
+
+	banCircuitBreaker(t, breaker)
+
+	time.Sleep(2 * recoveryTestBanWindow)
+	assert.False(t, breaker.IsOpen())
+	assert.Equal(t, 0.25, breaker.GetTrafficShare())
+
+	// A single failure in the half-open phase bans the upstream again.
+	breaker.RecordFailure()
+	assert.True(t, breaker.IsOpen())
+	assert.Equal(t, 0.0, breaker.GetTrafficShare())
+
+	time.Sleep(2 * recoveryTestBanWindow)
+	assert.False(t, breaker.IsOpen())
+	assert.Equal(t, 0.25, breaker.GetTrafficShare())
+
+	breaker.RecordSuccess()
+	assert.Equal(t, 0.25, breaker.GetTrafficShare())
+
+	// Enough successes end the half-open phase, after which the share ramps up.
+	breaker.RecordSuccess()
+	time.Sleep(slowStartWindow / 2)
+
+	share := breaker.GetTrafficShare()
+	assert.Greater(t, share, 0.25)
+	assert.Less(t, share, 1.0)
+
+	time.Sleep(slowStartWindow)
+	assert.False(t, breaker.IsOpen())
+	assert.Equal(t, 1.0, breaker.GetTrafficShare())
+	assert.True(t, breaker.IsAdmitted())
+}
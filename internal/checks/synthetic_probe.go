@@ -0,0 +1,208 @@
+package checks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/client"
+	conf "github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/types"
+	"go.uber.org/zap"
+)
+
+// SyntheticProbeCheck periodically calls a user-defined RPC method on an upstream and matches its result against the
+// expected one. This catches failures that only affect specific methods, e.g. a broken trace module, which is why a
+// failing probe only stops the upstream from being routed requests for the methods in the probe's scope.
+type SyntheticProbeCheck struct {
+	lastRunAt        time.Time
+	client           client.EthClient
+	Err              error
+	clientGetter     client.EthClientGetter
+	metricsContainer *metrics.Container
+	logger           *zap.Logger
+	upstreamConfig   *conf.UpstreamConfig
+	probe            *conf.SyntheticProbeConfig
+}
+
+func NewSyntheticProbeChecker(
+	upstreamConfig *conf.UpstreamConfig,
+	probe *conf.SyntheticProbeConfig,
+	clientGetter client.EthClientGetter,
+	metricsContainer *metrics.Container,
+	logger *zap.Logger,
+) types.SyntheticProbeChecker {
+	return &SyntheticProbeCheck{
+		upstreamConfig:   upstreamConfig,
+		probe:            probe,
+		clientGetter:     clientGetter,
+		metricsContainer: metricsContainer,
+		logger:           logger,
+	}
+}
+
+func (c *SyntheticProbeCheck) Initialize() error {
+	c.logger.Debug("Initializing SyntheticProbeCheck.", zap.Any("config", c.upstreamConfig), zap.String("method", c.probe.Method))
+
+	httpClient, err := c.clientGetter(c.upstreamConfig.HTTPURL, &c.upstreamConfig.BasicAuthConfig, &c.upstreamConfig.RequestHeadersConfig)
+	if err != nil {
+		c.Err = err
+		return c.Err
+	}
+
+	c.client = httpClient
+
+	return nil
+}
+
+func (c *SyntheticProbeCheck) RunCheck() {
+	// Probes only run when the health checks run, so intervals shorter than the health check interval are
+	// effectively rounded up to it.
+	if time.Since(c.lastRunAt) < c.probe.Interval {
+		return
+	}
+
+	c.lastRunAt = time.Now()
+
+	if c.client == nil {
+		if err := c.Initialize(); err != nil {
+			c.logger.Error("Error initializing SyntheticProbeCheck.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("method", c.probe.Method), zap.Error(err))
+			c.metricsContainer.SyntheticProbeErrors.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, metrics.HTTPInit, c.probe.Method).Inc()
+
+			return
+		}
+	}
+
+	runCheck := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.upstreamConfig.HealthCheckConfig.GetTimeout())
+		defer cancel()
+
+		result, err := c.client.CallRaw(ctx, c.probe.Method, c.probe.Params)
+		if c.Err = err; c.Err != nil {
+			c.logger.Debug("SyntheticProbeCheck request failed.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("method", c.probe.Method), zap.Error(c.Err))
+			c.metricsContainer.SyntheticProbeErrors.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, metrics.HTTPRequest, c.probe.Method).Inc()
+
+			return
+		}
+
+		if c.Err = matchSyntheticProbeResult(result, &c.probe.Expect); c.Err != nil {
+			c.logger.Debug("SyntheticProbeCheck returned an unexpected result.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("method", c.probe.Method), zap.Error(c.Err))
+			c.metricsContainer.SyntheticProbeErrors.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, metrics.UnexpectedResult, c.probe.Method).Inc()
+
+			return
+		}
+
+		c.logger.Debug("Ran SyntheticProbeCheck.", zap.Any("upstreamID", c.upstreamConfig.ID), zap.String("method", c.probe.Method))
+	}
+
+	runCheckWithMetrics(runCheck,
+		c.metricsContainer.SyntheticProbeRequests.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, c.probe.Method),
+		c.metricsContainer.SyntheticProbeDuration.WithLabelValues(c.upstreamConfig.ID, c.upstreamConfig.HTTPURL, c.probe.Method))
+}
+
+func (c *SyntheticProbeCheck) GetError() error {
+	return c.Err
+}
+
+func (c *SyntheticProbeCheck) IsPassing() bool {
+	return c.Err == nil
+}
+
+// IsInScope returns true iff any of the given methods is in the probe's method scope.
+func (c *SyntheticProbeCheck) IsInScope(methods []string) bool {
+	return c.probe.IsInScope(methods)
+}
+
+func matchSyntheticProbeResult(result json.RawMessage, expect *conf.SyntheticProbeExpectConfig) error {
+	var actual any
+	if err := json.Unmarshal(result, &actual); err != nil {
+		return fmt.Errorf("error decoding result: %w", err)
+	}
+
+	switch expect.Matcher {
+	case conf.NonNullMatcher:
+		if actual == nil {
+			return errors.New("result is null")
+		}
+
+		return nil
+	case conf.ExactMatcher:
+		return matchValue(actual, expect.Value)
+	case conf.JSONPathMatcher:
+		value, err := getJSONPathValue(actual, expect.Path)
+		if err != nil {
+			return err
+		}
+
+		// Without an expected value, the value at the path only needs to be non-null.
+		if expect.Value == nil {
+			if value == nil {
+				return fmt.Errorf("value at path %s is null", expect.Path)
+			}
+
+			return nil
+		}
+
+		return matchValue(value, expect.Value)
+	default:
+		panic("Unknown synthetic probe matcher " + expect.Matcher + "!")
+	}
+}
+
+// matchValue compares a decoded JSON value with an expected value from the config, which is first converted to the
+// types JSON decoding produces (e.g. float64 rather than int).
+func matchValue(actual, expected any) error {
+	expectedJSON, err := json.Marshal(expected)
+	if err != nil {
+		return fmt.Errorf("error encoding expected value: %w", err)
+	}
+
+	var normalizedExpected any
+	if err := json.Unmarshal(expectedJSON, &normalizedExpected); err != nil {
+		return fmt.Errorf("error decoding expected value: %w", err)
+	}
+
+	if !reflect.DeepEqual(actual, normalizedExpected) {
+		return fmt.Errorf("expected %s, got %v", expectedJSON, actual)
+	}
+
+	return nil
+}
+
+// getJSONPathValue returns the value at the given dot-separated path of object keys and array indices, e.g.
+// "0.action.callType". A leading "$" is ignored.
+func getJSONPathValue(value any, path string) (any, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return value, nil
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		switch typedValue := value.(type) {
+		case map[string]any:
+			nextValue, ok := typedValue[segment]
+			if !ok {
+				return nil, fmt.Errorf("key %s not found at path %s", segment, path)
+			}
+
+			value = nextValue
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(typedValue) {
+				return nil, fmt.Errorf("index %s out of range at path %s", segment, path)
+			}
+
+			value = typedValue[index]
+		default:
+			return nil, fmt.Errorf("cannot look up %s in a scalar at path %s", segment, path)
+		}
+	}
+
+	return value, nil
+}
@@ -0,0 +1,94 @@
+package checks
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/client"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func newTestSyntheticProbeChecker(t *testing.T, probe *config.SyntheticProbeConfig, result string, err error) (*SyntheticProbeCheck, *mocks.EthClient) {
+	t.Helper()
+
+	ethClient := mocks.NewEthClient(t)
+	ethClient.EXPECT().CallRaw(mock.Anything, probe.Method, probe.Params).Return(json.RawMessage(result), err).Maybe()
+
+	mockEthClientGetter := func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (client.EthClient, error) { //nolint:nolintlint,revive // Legacy
+		return ethClient, nil
+	}
+
+	checker := NewSyntheticProbeChecker(defaultUpstreamConfig, probe, mockEthClientGetter, metrics.NewContainer(config.TestChainName), zap.L())
+
+	return checker.(*SyntheticProbeCheck), ethClient //nolint:errcheck // ignore error
+}
+
+func TestSyntheticProbeChecker_Matchers(t *testing.T) {
+	traceResult := `[{"action":{"callType":"call","value":"0x0"},"result":null}]`
+
+	for _, testCase := range []struct {
+		name      string
+		result    string
+		expect    config.SyntheticProbeExpectConfig
+		isPassing bool
+	}{
+		{"Non-null", traceResult, config.SyntheticProbeExpectConfig{Matcher: config.NonNullMatcher}, true},
+		{"Null", "null", config.SyntheticProbeExpectConfig{Matcher: config.NonNullMatcher}, false},
+		{"Exact", `"0x1"`, config.SyntheticProbeExpectConfig{Matcher: config.ExactMatcher, Value: "0x1"}, true},
+		{"Exact number", `1`, config.SyntheticProbeExpectConfig{Matcher: config.ExactMatcher, Value: 1}, true},
+		{"Exact object", `{"a":[1,2]}`, config.SyntheticProbeExpectConfig{Matcher: config.ExactMatcher, Value: map[string]any{"a": []any{1, 2}}}, true},
+		{"Exact mismatch", `"0x2"`, config.SyntheticProbeExpectConfig{Matcher: config.ExactMatcher, Value: "0x1"}, false},
+		{"JSON path", traceResult, config.SyntheticProbeExpectConfig{Matcher: config.JSONPathMatcher, Path: "$.0.action.callType", Value: "call"}, true},
+		{"JSON path mismatch", traceResult, config.SyntheticProbeExpectConfig{Matcher: config.JSONPathMatcher, Path: "0.action.callType", Value: "create"}, false},
+		{"JSON path non-null", traceResult, config.SyntheticProbeExpectConfig{Matcher: config.JSONPathMatcher, Path: "0.action"}, true},
+		{"JSON path null", traceResult, config.SyntheticProbeExpectConfig{Matcher: config.JSONPathMatcher, Path: "0.result"}, false},
+		{"JSON path missing key", traceResult, config.SyntheticProbeExpectConfig{Matcher: config.JSONPathMatcher, Path: "0.subtraces"}, false},
+		{"JSON path index out of range", traceResult, config.SyntheticProbeExpectConfig{Matcher: config.JSONPathMatcher, Path: "1.action"}, false},
+		{"Invalid JSON", `{`, config.SyntheticProbeExpectConfig{Matcher: config.NonNullMatcher}, false},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			probe := &config.SyntheticProbeConfig{Method: "trace_block", Params: []any{"0x1"}, Expect: testCase.expect}
+			checker, _ := newTestSyntheticProbeChecker(t, probe, testCase.result, nil)
+
+			checker.RunCheck()
+			assert.Equal(t, testCase.isPassing, checker.IsPassing())
+		})
+	}
+}
+
+func TestSyntheticProbeChecker_RequestError(t *testing.T) {
+	probe := &config.SyntheticProbeConfig{Method: "trace_block", Expect: config.SyntheticProbeExpectConfig{Matcher: config.NonNullMatcher}}
+	checker, _ := newTestSyntheticProbeChecker(t, probe, "", errors.New("the method trace_block does not exist"))
+
+	checker.RunCheck()
+	assert.False(t, checker.IsPassing())
+}
+
+func TestSyntheticProbeChecker_Interval(t *testing.T) {
+	probe := &config.SyntheticProbeConfig{Method: "eth_chainId", Interval: time.Hour, Expect: config.SyntheticProbeExpectConfig{Matcher: config.NonNullMatcher}}
+	checker, ethClient := newTestSyntheticProbeChecker(t, probe, `"0x1"`, nil)
+
+	checker.RunCheck()
+	checker.RunCheck()
+
+	// The second run is within the interval, so the probe is not sent.
+	ethClient.AssertNumberOfCalls(t, "CallRaw", 1)
+	assert.True(t, checker.IsPassing())
+}
+
+func TestSyntheticProbeChecker_IsInScope(t *testing.T) {
+	probe := &config.SyntheticProbeConfig{Method: "trace_block", Methods: []string{"trace_block", "trace_transaction"}}
+	checker, _ := newTestSyntheticProbeChecker(t, probe, "null", nil)
+
+	assert.True(t, checker.IsInScope([]string{"eth_blockNumber", "trace_block"}))
+	assert.True(t, checker.IsInScope([]string{"trace_transaction"}))
+	assert.False(t, checker.IsInScope([]string{"eth_blockNumber"}))
+	assert.False(t, checker.IsInScope(nil))
+}
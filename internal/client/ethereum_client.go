@@ -3,6 +3,8 @@ package client
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	netUrl "net/url"
@@ -16,7 +18,8 @@ import (
 )
 
 const (
-	clientDialTimeout = 10 * time.Second
+	clientDialTimeout           = 10 * time.Second
+	jsonRPCErrCodeInvalidParams = -32602
 )
 
 type NewHeadHandler struct {
@@ -32,7 +35,9 @@ type EthClient interface {
 	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
 	PeerCount(ctx context.Context) (uint64, error)
 	SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error)
-	RecordLatency(ctx context.Context, method string) (time.Duration, error)
+	RecordLatency(ctx context.Context, method string, params []any) (time.Duration, error)
+	CallRaw(ctx context.Context, method string, params []any) (json.RawMessage, error)
+	Close()
 }
 
 func (c *Client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
@@ -43,6 +48,10 @@ func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.He
 	return (*ethclient.Client)(c).HeaderByNumber(ctx, number)
 }
 
+func (c *Client) Close() {
+	(*ethclient.Client)(c).Close()
+}
+
 func (c *Client) PeerCount(ctx context.Context) (uint64, error) {
 	return (*ethclient.Client)(c).PeerCount(ctx)
 }
@@ -51,18 +60,36 @@ func (c *Client) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, erro
 	return (*ethclient.Client)(c).SyncProgress(ctx)
 }
 
-// RecordLatency calls the specified RPC method using the given context and returns the duration of the call,
-// as well as the error if one occurred. No arguments are passed to the RPC method.
-//
-// TODO(polsar): If the given method expects one or more arguments, it will return an error that will be passed
+// RecordLatency calls the specified RPC method with the given params using the given context and returns the duration
+// of the call, as well as the error if one occurred.
 //
-//	to the caller. We should detect this type of error and not return it, since the call has otherwise succeeded,
-//	which is the only thing the caller cares about.
-func (c *Client) RecordLatency(ctx context.Context, method string) (time.Duration, error) {
+// If the upstream rejects the params, e.g. because none were passed to a method that expects some, no error is
+// returned, since the call has otherwise succeeded, which is the only thing the caller cares about.
+func (c *Client) RecordLatency(ctx context.Context, method string, params []any) (time.Duration, error) {
 	start := time.Now()
-	err := (*ethclient.Client)(c).Client().CallContext(ctx, nil, method)
+	err := (*ethclient.Client)(c).Client().CallContext(ctx, nil, method, params...)
+	latency := time.Since(start)
+
+	if isInvalidParamsErr(err) {
+		return latency, nil
+	}
+
+	return latency, err
+}
+
+// CallRaw calls the specified RPC method with the given params using the given context and returns the raw result.
+func (c *Client) CallRaw(ctx context.Context, method string, params []any) (json.RawMessage, error) {
+	var result json.RawMessage
+
+	err := (*ethclient.Client)(c).Client().CallContext(ctx, &result, method, params...)
+
+	return result, err
+}
+
+func isInvalidParamsErr(err error) bool {
+	var rpcErr rpc.Error
 
-	return time.Since(start), err
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == jsonRPCErrCodeInvalidParams
 }
 
 type EthClientGetter func(url string, credentials *config.BasicAuthConfig, additionalRequestHeaders *[]config.RequestHeaderConfig) (EthClient, error)
@@ -1,12 +1,18 @@
 package config //nolint:nolintlint,typecheck // Legacy
 
 import (
+	"cmp"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
 	"os"
+	"path"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/satsuma-data/node-gateway/internal/util"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 )
@@ -18,11 +24,59 @@ const (
 	DefaultDetectionWindow             = time.Minute
 	DefaultMaxLatency                  = 10 * time.Second // Default latency threshold
 	DefaultErrorRate                   = 0.25
-	DefaultLatencyTooHighRate          = 0.5 // TODO(polsar): Expose this parameter in the config.
+	DefaultLatencyTooHighRate          = 0.5
 	Archive                   NodeType = "archive"
 	Full                      NodeType = "full"
 )
 
+type LatencyAggregation string
+
+const (
+	RateAggregation LatencyAggregation = "rate" // The share of latencies at or above the threshold.
+	MeanAggregation LatencyAggregation = "mean"
+	P50Aggregation  LatencyAggregation = "p50"
+	P90Aggregation  LatencyAggregation = "p90"
+	P99Aggregation  LatencyAggregation = "p99"
+
+	DefaultLatencyMinSamples uint = 3
+)
+
+const (
+	DefaultAdaptiveLatencyMultiplier      = 3.0
+	DefaultAdaptiveLatencyWindow          = 10 * time.Minute
+	DefaultAdaptiveLatencyMinSamples uint = 100
+)
+
+const (
+	DefaultLatencyProbeInterval = 30 * time.Second
+	DefaultLatencyProbeMethod   = "eth_blockNumber"
+)
+
+const (
+	DefaultHalfOpenTrafficShare      = 0.1
+	DefaultHalfOpenSuccesses    uint = 3
+	DefaultSlowStartWindow           = time.Minute
+)
+
+const DefaultSyntheticProbeInterval = time.Minute
+
+const (
+	DefaultOutlierInterval                = 30 * time.Second
+	DefaultOutlierEjectionDuration        = time.Minute
+	DefaultOutlierMinRequests        uint = 20
+	DefaultOutlierErrorRateFactor         = 2.0
+	DefaultOutlierMinErrorRateDelta       = 0.1
+	DefaultOutlierLatencyFactor           = 2.0
+	DefaultOutlierLatencyPercentile       = 0.9
+	DefaultOutlierMaxEjectionPercent uint = 50
+)
+
+const (
+	DefaultHealthCheckInterval        = 5 * time.Second
+	DefaultHealthCheckTimeout         = 10 * time.Second
+	DefaultMinPeerCount        uint64 = 3
+)
+
 type UpstreamConfig struct {
 	Methods              MethodsConfig         `yaml:"methods"`
 	HealthCheckConfig    HealthCheckConfig     `yaml:"healthCheck"`
@@ -33,6 +87,10 @@ type UpstreamConfig struct {
 	GroupID              string                `yaml:"group"`
 	NodeType             NodeType              `yaml:"nodeType"`
 	RequestHeadersConfig []RequestHeaderConfig `yaml:"requestHeaders"`
+	// User-defined health checks, each of which only affects routing for the methods in its scope.
+	SyntheticProbes []SyntheticProbeConfig `yaml:"probes"`
+	// Reference-only upstreams are health checked and raise the chain's global max heights, but are never routed to.
+	ReferenceOnly bool `yaml:"referenceOnly"`
 }
 
 func (c *UpstreamConfig) isValid(groups []GroupConfig) bool {
@@ -55,7 +113,26 @@ func (c *UpstreamConfig) isValid(groups []GroupConfig) bool {
 		zap.L().Error("wsURL should be provided if useWsForBlockHeight=true.", zap.Any("config", c), zap.String("upstreamId", c.ID))
 	}
 
-	if len(groups) > 0 {
+	if !c.HealthCheckConfig.isValid() {
+		isValid = false
+	}
+
+	for i := range c.SyntheticProbes {
+		if !c.SyntheticProbes[i].isValid() {
+			isValid = false
+
+			zap.L().Error("Invalid synthetic probe on upstream.", zap.Any("probe", c.SyntheticProbes[i]), zap.String("upstreamId", c.ID))
+		}
+	}
+
+	switch {
+	case c.ReferenceOnly:
+		if c.GroupID != "" {
+			isValid = false
+
+			zap.L().Error("A Group cannot be specified on reference-only upstreams since they are never routed to.", zap.Any("config", c), zap.String("upstreamId", c.ID))
+		}
+	case len(groups) > 0:
 		if c.GroupID == "" {
 			isValid = false
 
@@ -91,13 +168,183 @@ func IsUpstreamsValid(upstreams []UpstreamConfig) bool {
 		uniqueIDs[upstreams[idx].ID] = true
 	}
 
+	isRoutable := func(upstream UpstreamConfig) bool { return !upstream.ReferenceOnly }
+	if len(upstreams) > 0 && !slices.ContainsFunc(upstreams, isRoutable) {
+		zap.L().Error("At least one upstream of a chain must not be reference-only.", zap.Any("upstreams", upstreams))
+
+		return false
+	}
+
 	return true
 }
 
+type SyntheticProbeMatcher string
+
+const (
+	ExactMatcher    SyntheticProbeMatcher = "exact"    // The result must equal the expected value.
+	JSONPathMatcher SyntheticProbeMatcher = "jsonPath" // The value at the path in the result must equal the expected value.
+	NonNullMatcher  SyntheticProbeMatcher = "nonNull"  // The result must not be null.
+)
+
+// SyntheticProbeConfig is a user-defined health check that calls an RPC method on the upstream and checks its result.
+// While the probe fails, the upstream is not routed requests for any of the methods in its scope.
+type SyntheticProbeConfig struct {
+	Expect   SyntheticProbeExpectConfig `yaml:"expect"`
+	Method   string                     `yaml:"method"`
+	Params   []any                      `yaml:"params"`
+	Methods  []string                   `yaml:"methods"` // The probe's method scope, e.g. "trace_*". Defaults to the probed method.
+	Interval time.Duration              `yaml:"interval"`
+}
+
+type SyntheticProbeExpectConfig struct {
+	Value   any                   `yaml:"value"`
+	Matcher SyntheticProbeMatcher `yaml:"matcher"`
+	Path    string                `yaml:"path"` // Dot-separated object keys and array indices, e.g. "0.action.callType".
+}
+
+func (c *SyntheticProbeConfig) initialize() {
+	if c.Interval <= time.Duration(0) {
+		c.Interval = DefaultSyntheticProbeInterval
+	}
+
+	if len(c.Methods) == 0 {
+		c.Methods = []string{c.Method}
+	}
+
+	if c.Expect.Matcher == "" {
+		c.Expect.Matcher = NonNullMatcher
+	}
+}
+
+// IsInScope returns true iff any of the given methods matches any of the probe's methods.
+func (c *SyntheticProbeConfig) IsInScope(methods []string) bool {
+	return matchesAnyMethod(c.Methods, methods)
+}
+
+func (c *SyntheticProbeConfig) isValid() bool {
+	if c.Method == "" {
+		zap.L().Error("method cannot be empty in synthetic probe configuration")
+		return false
+	}
+
+	for _, method := range c.Methods {
+		if _, err := path.Match(method, ""); method == "" || err != nil {
+			zap.L().Error("method scope is empty or an invalid pattern in synthetic probe configuration", zap.String("method", method), zap.Error(err))
+			return false
+		}
+	}
+
+	switch c.Expect.Matcher {
+	case ExactMatcher, NonNullMatcher:
+		return true
+	case JSONPathMatcher:
+		if c.Expect.Path == "" {
+			zap.L().Error("path cannot be empty for the jsonPath matcher in synthetic probe configuration")
+			return false
+		}
+
+		return true
+	default:
+		zap.L().Error("unknown matcher in synthetic probe configuration", zap.Any("matcher", c.Expect.Matcher))
+		return false
+	}
+}
+
 type HealthCheckConfig struct {
 	// If not set - method to identify block height is auto-detected. Use websockets is its URL is set, else fall back to use HTTP polling.
 	UseWSForBlockHeight *bool `yaml:"useWsForBlockHeight"`
 	SkipPeerCountCheck  *bool `yaml:"skipPeerCountCheck"`
+	// If not set - the safe and finalized heads are not polled.
+	CheckFinality *bool `yaml:"checkFinality"`
+
+	// The fields below form the health check policy. They can be set globally, per chain and per upstream,
+	// with the most specific level taking precedence.
+	Interval *time.Duration `yaml:"interval"`
+	Timeout  *time.Duration `yaml:"timeout"`
+	// Each run of the health checks is delayed by a random duration in [0, jitter) so upstreams aren't polled in lockstep.
+	Jitter       *time.Duration `yaml:"jitter"`
+	MinPeerCount *uint64        `yaml:"minPeerCount"`
+	// If not set - the chain's `routing.maxBlocksBehind` is used.
+	MaxBlocksBehind *uint64 `yaml:"maxBlocksBehind"`
+}
+
+// merge sets any health check policy values that are not specified to those of the parent (chain or global) config.
+func (c *HealthCheckConfig) merge(parentConfig *HealthCheckConfig) {
+	if c.Interval == nil {
+		c.Interval = parentConfig.Interval
+	}
+
+	if c.Timeout == nil {
+		c.Timeout = parentConfig.Timeout
+	}
+
+	if c.Jitter == nil {
+		c.Jitter = parentConfig.Jitter
+	}
+
+	if c.MinPeerCount == nil {
+		c.MinPeerCount = parentConfig.MinPeerCount
+	}
+
+	if c.MaxBlocksBehind == nil {
+		c.MaxBlocksBehind = parentConfig.MaxBlocksBehind
+	}
+}
+
+func (c *HealthCheckConfig) isValid() bool {
+	isValid := true
+
+	if c.Interval != nil && *c.Interval <= 0 {
+		isValid = false
+
+		zap.L().Error("healthCheck interval must be positive.", zap.Duration("interval", *c.Interval))
+	}
+
+	if c.Timeout != nil && *c.Timeout <= 0 {
+		isValid = false
+
+		zap.L().Error("healthCheck timeout must be positive.", zap.Duration("timeout", *c.Timeout))
+	}
+
+	if c.Jitter != nil && *c.Jitter < 0 {
+		isValid = false
+
+		zap.L().Error("healthCheck jitter cannot be negative.", zap.Duration("jitter", *c.Jitter))
+	}
+
+	return isValid
+}
+
+func (c *HealthCheckConfig) GetInterval() time.Duration {
+	if c.Interval == nil {
+		return DefaultHealthCheckInterval
+	}
+
+	return *c.Interval
+}
+
+func (c *HealthCheckConfig) GetTimeout() time.Duration {
+	if c.Timeout == nil {
+		return DefaultHealthCheckTimeout
+	}
+
+	return *c.Timeout
+}
+
+func (c *HealthCheckConfig) GetJitter() time.Duration {
+	if c.Jitter == nil {
+		return 0
+	}
+
+	return *c.Jitter
+}
+
+func (c *HealthCheckConfig) GetMinPeerCount() uint64 {
+	if c.MinPeerCount == nil {
+		return DefaultMinPeerCount
+	}
+
+	return *c.MinPeerCount
 }
 
 type BasicAuthConfig struct {
@@ -142,8 +389,42 @@ func (m *MethodsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 }
 
 type GroupConfig struct {
-	ID       string `yaml:"id"`
-	Priority int    `yaml:"priority"`
+	Failover *FailoverConfig `yaml:"failover"`
+	ID       string          `yaml:"id"`
+	Priority int             `yaml:"priority"`
+}
+
+// FailoverConfig adds hysteresis to switching traffic between priority groups. Each health check round is a round.
+type FailoverConfig struct {
+	// Minimum time the group stays active before traffic can fail back to a higher priority group, or fail over to a
+	// lower priority group while the group still has healthy upstreams.
+	MinDwellTime time.Duration `yaml:"minDwellTime"`
+	// Number of consecutive rounds the group must be healthy before traffic fails back to it.
+	FailbackHealthyRounds uint `yaml:"failbackHealthyRounds"`
+	// Minimum number of healthy upstreams for the group to be considered healthy. Defaults to 1.
+	MinHealthyUpstreams int `yaml:"minHealthyUpstreams"`
+}
+
+func (c *FailoverConfig) isValid() bool {
+	if c == nil {
+		return true
+	}
+
+	isValid := true
+
+	if c.MinDwellTime < 0 {
+		isValid = false
+
+		zap.L().Error("minDwellTime cannot be negative.", zap.Duration("minDwellTime", c.MinDwellTime))
+	}
+
+	if c.MinHealthyUpstreams < 0 {
+		isValid = false
+
+		zap.L().Error("minHealthyUpstreams cannot be negative.", zap.Int("minHealthyUpstreams", c.MinHealthyUpstreams))
+	}
+
+	return isValid
 }
 
 func IsGroupsValid(groups []GroupConfig) bool {
@@ -169,18 +450,30 @@ func IsGroupsValid(groups []GroupConfig) bool {
 		uniquePriorities[group.Priority] = true
 	}
 
+	for _, group := range groups {
+		if !group.Failover.isValid() {
+			return false
+		}
+	}
+
 	return true
 }
 
 type GlobalConfig struct {
-	Cache   CacheConfig   `yaml:"cache"`
-	Routing RoutingConfig `yaml:"routing"`
-	Port    int           `yaml:"port"`
+	Cache       CacheConfig       `yaml:"cache"`
+	HealthCheck HealthCheckConfig `yaml:"healthCheck"`
+	Routing     RoutingConfig     `yaml:"routing"`
+	Port        int               `yaml:"port"`
 }
 
 // setDefaults sets the default values for the global config if global enhanced routing is specified in the YAML,
-// and returns true. Otherwise, it does nothing and returns false.
+// and returns true. Otherwise, it does nothing and returns false. Either way, it sets the defaults of the memory cache
+// if it is enabled.
 func (c *GlobalConfig) setDefaults() bool {
+	if c.Cache.Memory != nil {
+		c.Cache.Memory.initialize()
+	}
+
 	return c.Routing.setDefaults(nil, false)
 }
 
@@ -188,6 +481,237 @@ type CacheConfig struct {
 	Redis       string `yaml:"redis"`       // Kept for backwards compatibility
 	RedisReader string `yaml:"redisReader"` // Endpoint for read operations
 	RedisWriter string `yaml:"redisWriter"` // Endpoint for write operations
+	// Caches in the gateway's memory instead of Redis. Cannot be used together with Redis.
+	Memory *MemoryCacheConfig `yaml:"memory"`
+	// Connection options of the Redis reader and writer, nil for plain connections to the endpoints above.
+	RedisOptions *RedisConfig `yaml:"redisOptions"`
+}
+
+// RedisConfig holds the options of the connections to Redis. By default, the reader and writer connect to their own
+// endpoint. With Sentinel, the writer connects to the master and the reader to a replica. With Cluster, the writer
+// connects to the masters and the reader to the closest node of each slot.
+type RedisConfig struct {
+	RedisCredentialsConfig `yaml:",inline"`
+	DB                     int                  `yaml:"db"`
+	TLS                    *RedisTLSConfig      `yaml:"tls"`
+	Sentinel               *RedisSentinelConfig `yaml:"sentinel"`
+	Cluster                *RedisClusterConfig  `yaml:"cluster"`
+}
+
+// RedisCredentialsConfig authenticates to Redis. The password can be set in the config, or read from an environment
+// variable or a file, e.g. a mounted secret.
+type RedisCredentialsConfig struct {
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	PasswordEnv  string `yaml:"passwordEnv"`
+	PasswordFile string `yaml:"passwordFile"`
+}
+
+// RedisTLSConfig enables TLS. The server's certificate is verified with the system's CAs unless a CA file is set.
+type RedisTLSConfig struct {
+	CAFile             string `yaml:"caFile"`
+	CertFile           string `yaml:"certFile"` // Client certificate, for mutual TLS.
+	KeyFile            string `yaml:"keyFile"`
+	ServerName         string `yaml:"serverName"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+}
+
+type RedisSentinelConfig struct {
+	MasterName string   `yaml:"masterName"`
+	Addresses  []string `yaml:"addresses"`
+	// Credentials of the Sentinel nodes, which may differ from those of the master and replicas.
+	Credentials RedisCredentialsConfig `yaml:"credentials"`
+}
+
+type RedisClusterConfig struct {
+	Addresses []string `yaml:"addresses"` // Seed nodes, the other nodes are discovered from them.
+}
+
+// GetPassword returns the password from the config, the environment variable or the file, whichever is set.
+func (c *RedisCredentialsConfig) GetPassword() (string, error) {
+	switch {
+	case c.PasswordEnv != "":
+		password, ok := os.LookupEnv(c.PasswordEnv)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", c.PasswordEnv)
+		}
+
+		return password, nil
+	case c.PasswordFile != "":
+		passwordBytes, err := os.ReadFile(c.PasswordFile)
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimSpace(string(passwordBytes)), nil
+	default:
+		return c.Password, nil
+	}
+}
+
+// GetTLSConfig returns the TLS config with the CA and client certificate loaded from their files, or nil if TLS is not
+// enabled.
+func (c *RedisTLSConfig) GetTLSConfig() (*tls.Config, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec // Opt-in, e.g. for self-signed certificates.
+	}
+
+	if c.CAFile != "" {
+		caBytes, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caBytes) {
+			return nil, errors.New("no certificates found in the redis CA file")
+		}
+	}
+
+	if c.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the redis client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+func (c *RedisCredentialsConfig) isValid() bool {
+	passwordSources := slices.DeleteFunc([]string{c.Password, c.PasswordEnv, c.PasswordFile}, func(source string) bool {
+		return source == ""
+	})
+	if len(passwordSources) > 1 {
+		zap.L().Error("Only one of password, passwordEnv and passwordFile can be set.")
+		return false
+	}
+
+	if _, err := c.GetPassword(); err != nil {
+		zap.L().Error("Failed to read the Redis password.", zap.Error(err))
+		return false
+	}
+
+	return true
+}
+
+func (c *RedisConfig) isValid() bool {
+	if !c.RedisCredentialsConfig.isValid() {
+		return false
+	}
+
+	if c.DB < 0 {
+		zap.L().Error("Redis db cannot be negative.", zap.Int("db", c.DB))
+		return false
+	}
+
+	if c.TLS != nil && (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		zap.L().Error("Redis TLS certFile and keyFile must be set together.")
+		return false
+	}
+
+	// Otherwise, the Redis clients would fail to be created and caching would be silently disabled.
+	if _, err := c.TLS.GetTLSConfig(); err != nil {
+		zap.L().Error("Failed to load the Redis TLS files.", zap.Error(err))
+		return false
+	}
+
+	if c.Sentinel != nil && c.Cluster != nil {
+		zap.L().Error("Redis Sentinel and Cluster cannot be used together.")
+		return false
+	}
+
+	if c.Sentinel != nil {
+		if c.Sentinel.MasterName == "" || len(c.Sentinel.Addresses) == 0 {
+			zap.L().Error("Redis Sentinel requires a masterName and addresses.")
+			return false
+		}
+
+		if !c.Sentinel.Credentials.isValid() {
+			return false
+		}
+	}
+
+	if c.Cluster != nil {
+		if len(c.Cluster.Addresses) == 0 {
+			zap.L().Error("Redis Cluster requires addresses.")
+			return false
+		}
+
+		if c.DB != 0 {
+			zap.L().Error("Redis Cluster only supports db 0.")
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasRedisTopology returns whether Redis Sentinel or Cluster is configured, in which case the Redis endpoints are
+// discovered instead of configured.
+func (cfg *CacheConfig) HasRedisTopology() bool {
+	return cfg.RedisOptions != nil && (cfg.RedisOptions.Sentinel != nil || cfg.RedisOptions.Cluster != nil)
+}
+
+const (
+	DefaultMemoryCacheMaxEntries  = 10000
+	DefaultMemoryCacheMaxMemoryMB = 64
+)
+
+// MemoryCacheConfig bounds the in-process cache, which is shared by all chains. The least recently used entries are
+// evicted once either bound is reached.
+type MemoryCacheConfig struct {
+	MaxEntries  int `yaml:"maxEntries"`
+	MaxMemoryMB int `yaml:"maxMemoryMB"` // Counts the size of the cached keys and results only.
+}
+
+func (c *MemoryCacheConfig) initialize() {
+	if c.MaxEntries == 0 {
+		c.MaxEntries = DefaultMemoryCacheMaxEntries
+	}
+
+	if c.MaxMemoryMB == 0 {
+		c.MaxMemoryMB = DefaultMemoryCacheMaxMemoryMB
+	}
+}
+
+func (cfg *CacheConfig) isValid() bool {
+	readerAddr, writerAddr := cfg.GetRedisAddresses()
+
+	if cfg.RedisOptions != nil {
+		if !cfg.RedisOptions.isValid() {
+			return false
+		}
+
+		if cfg.HasRedisTopology() && (readerAddr != "" || writerAddr != "") {
+			zap.L().Error("Redis endpoints cannot be set together with Redis Sentinel or Cluster.")
+			return false
+		}
+	}
+
+	if cfg.Memory == nil {
+		return true
+	}
+
+	if readerAddr != "" || writerAddr != "" || cfg.HasRedisTopology() {
+		zap.L().Error("The memory cache cannot be used together with Redis.")
+		return false
+	}
+
+	if cfg.Memory.MaxEntries < 0 || cfg.Memory.MaxMemoryMB < 0 {
+		zap.L().Error("Memory cache bounds cannot be negative.", zap.Any("memory", cfg.Memory))
+		return false
+	}
+
+	return true
 }
 
 func (cfg *CacheConfig) GetRedisAddresses() (readerAddr, writerAddr string) {
@@ -206,6 +730,50 @@ type ErrorsConfig struct {
 	JSONRPCCodes []string `yaml:"jsonRpcCodes"`
 	ErrorStrings []string `yaml:"errorStrings"`
 	Rate         float64  `yaml:"rate"`
+	// Errors of these methods are tracked by a separate circuit breaker per entry, which only bans the upstream for
+	// the entry's methods. Errors of all other methods ban the upstream for every method.
+	Methods []ErrorMethodConfig `yaml:"methods"`
+}
+
+type ErrorMethodConfig struct {
+	Name string  `yaml:"method"` // A method name, or a pattern such as "debug_*".
+	Rate float64 `yaml:"rate"`   // Defaults to the errors rate.
+}
+
+// GetMethodConfig returns the first per-method config whose name or pattern matches the method, or nil if there is
+// none.
+func (c *ErrorsConfig) GetMethodConfig(method string) *ErrorMethodConfig {
+	for i := range c.Methods {
+		if isMatch, _ := path.Match(c.Methods[i].Name, method); isMatch {
+			return &c.Methods[i]
+		}
+	}
+
+	return nil
+}
+
+func (c *ErrorsConfig) isValid() bool {
+	if c == nil {
+		return true
+	}
+
+	isValid := true
+
+	for _, method := range c.Methods {
+		if _, err := path.Match(method.Name, ""); method.Name == "" || err != nil {
+			isValid = false
+
+			zap.L().Error("Errors method is empty or an invalid pattern.", zap.String("method", method.Name), zap.Error(err))
+		}
+
+		if method.Rate < 0.0 || method.Rate > 1.0 {
+			isValid = false
+
+			zap.L().Error("Errors method rate is not in range [0.0, 1.0].", zap.String("method", method.Name), zap.Float64("rate", method.Rate))
+		}
+	}
+
+	return isValid
 }
 
 func (c *ErrorsConfig) merge(globalConfig *ErrorsConfig) {
@@ -213,6 +781,13 @@ func (c *ErrorsConfig) merge(globalConfig *ErrorsConfig) {
 		return
 	}
 
+	// The chain's per-method configs take precedence over the global ones for the same method.
+	for _, method := range globalConfig.Methods {
+		if !slices.ContainsFunc(c.Methods, func(m ErrorMethodConfig) bool { return m.Name == method.Name }) {
+			c.Methods = append(c.Methods, method)
+		}
+	}
+
 	// TODO(polsar): Can we somehow combine these three sections into one to avoid code duplication?
 	c.HTTPCodes = append(c.HTTPCodes, globalConfig.HTTPCodes...)
 	c.HTTPCodes = sortAndRemoveDuplicates(c.HTTPCodes)
@@ -237,6 +812,12 @@ func (c *ErrorsConfig) initialize(globalConfig *RoutingConfig) {
 			c.Rate = globalErrorsConfig.Rate
 		}
 	}
+
+	for i := range c.Methods {
+		if c.Methods[i].Rate == 0 {
+			c.Methods[i].Rate = c.Rate
+		}
+	}
 }
 
 // Sorts in-place and removes duplicates from the specified slice.
@@ -254,18 +835,115 @@ func (c *MethodConfig) isMethodConfigValid() bool {
 	return true
 }
 
-// LatencyConfig
-// TODO(polsar): Add the minimum number of latencies in the detection window required to apply the threshold.
-// TODO(polsar): Add other aggregation options. Currently, the average of latencies in the detection windows is used.
-type LatencyConfig struct {
-	// This field allows us to quickly look up the latency of a method, rather than doing so by traversing the Methods slice.
-	//
-	// TODO(polsar): Move this field to a new struct and embed this struct in it.
-	//  Asana task: https://app.asana.com/0/1207397277805097/1208232039997185/f
-	MethodLatencyThresholds map[string]time.Duration
+// LatencyConfig configures how the latencies of each method in the detection window are compared with its threshold.
+// By default, an upstream is banned for a method if at least TooHighRate of the method's latencies are at or above the
+// threshold. Other aggregations ban it if the aggregated latency, e.g. the p90, is at or above the threshold.
+type LatencyConfig struct {
+	// This field allows us to quickly look up the latency of a method, rather than doing so by traversing the Methods slice.
+	//
+	// TODO(polsar): Move this field to a new struct and embed this struct in it.
+	//  Asana task: https://app.asana.com/0/1207397277805097/1208232039997185/f
+	MethodLatencyThresholds map[string]time.Duration
+
+	Probes    *LatencyProbesConfig `yaml:"probes"`
+	Methods   []MethodConfig       `yaml:"methods"`
+	Threshold time.Duration        `yaml:"threshold"`
+
+	Aggregation LatencyAggregation `yaml:"aggregation"` // Defaults to rate.
+	TooHighRate float64            `yaml:"tooHighRate"` // Only used by the rate aggregation. Defaults to 0.5.
+	MinSamples  uint               `yaml:"minSamples"`  // Latencies needed in the detection window to ban the upstream. Defaults to 3.
+
+	// If set, the threshold of each method is learned from the latencies of all upstreams of the chain, and the
+	// configured thresholds are only used until there are enough latencies to learn from.
+	Adaptive *AdaptiveLatencyConfig `yaml:"adaptive"`
+}
+
+// AdaptiveLatencyConfig configures learning the latency threshold of each method as a multiple of the median latency
+// of the method across all upstreams of the chain.
+type AdaptiveLatencyConfig struct {
+	Multiplier float64       `yaml:"multiplier"` // The threshold as a multiple of the median latency. Defaults to 3.
+	Window     time.Duration `yaml:"window"`     // How far back latencies are learned from. Defaults to 10m.
+	MinSamples uint          `yaml:"minSamples"` // Latencies needed to learn a method's threshold. Defaults to 100.
+}
+
+func (c *AdaptiveLatencyConfig) initialize() {
+	if c.Multiplier == 0 {
+		c.Multiplier = DefaultAdaptiveLatencyMultiplier
+	}
+
+	if c.Window == 0 {
+		c.Window = DefaultAdaptiveLatencyWindow
+	}
+
+	if c.MinSamples == 0 {
+		c.MinSamples = DefaultAdaptiveLatencyMinSamples
+	}
+}
+
+func (c *AdaptiveLatencyConfig) isValid() bool {
+	if c == nil {
+		return true
+	}
+
+	isValid := true
+
+	if c.Multiplier < 1.0 {
+		isValid = false
+
+		zap.L().Error("Adaptive latency multiplier must be at least 1.0.", zap.Float64("multiplier", c.Multiplier))
+	}
+
+	if c.Window < 0 {
+		isValid = false
+
+		zap.L().Error("Adaptive latency window cannot be negative.", zap.Duration("window", c.Window))
+	}
+
+	return isValid
+}
+
+// LatencyProbesConfig configures active latency probing. Probes are sent to every upstream of the chain, including
+// idle and banned ones, and their latencies are recorded in the same per-method circuit breakers as live traffic.
+type LatencyProbesConfig struct {
+	Methods  []LatencyProbeMethodConfig `yaml:"methods"`
+	Interval time.Duration              `yaml:"interval"`
+}
+
+type LatencyProbeMethodConfig struct {
+	Name     string        `yaml:"method"`
+	Params   []any         `yaml:"params"`
+	Interval time.Duration `yaml:"interval"` // Overrides the probes' interval for this method.
+}
+
+func (c *LatencyProbesConfig) initialize() {
+	if c.Interval <= time.Duration(0) {
+		c.Interval = DefaultLatencyProbeInterval
+	}
+
+	if len(c.Methods) == 0 {
+		c.Methods = []LatencyProbeMethodConfig{{Name: DefaultLatencyProbeMethod}}
+	}
+
+	for i := range c.Methods {
+		if c.Methods[i].Interval <= time.Duration(0) {
+			c.Methods[i].Interval = c.Interval
+		}
+	}
+}
+
+func (c *LatencyProbesConfig) isValid() bool {
+	if c == nil {
+		return true
+	}
+
+	for _, method := range c.Methods {
+		if method.Name == "" {
+			zap.L().Error("method name cannot be empty in latency probe configuration")
+			return false
+		}
+	}
 
-	Methods   []MethodConfig `yaml:"methods"`
-	Threshold time.Duration  `yaml:"threshold"`
+	return true
 }
 
 func (c *LatencyConfig) merge(globalConfig *LatencyConfig) {
@@ -273,6 +951,14 @@ func (c *LatencyConfig) merge(globalConfig *LatencyConfig) {
 		return
 	}
 
+	if c.Probes == nil {
+		c.Probes = globalConfig.Probes
+	}
+
+	if c.Adaptive == nil {
+		c.Adaptive = globalConfig.Adaptive
+	}
+
 	for method, latencyThreshold := range globalConfig.MethodLatencyThresholds {
 		if _, exists := c.MethodLatencyThresholds[method]; !exists {
 			c.MethodLatencyThresholds[method] = latencyThreshold
@@ -312,6 +998,16 @@ func (c *LatencyConfig) getLatencyThresholdForMethod(method string) time.Duratio
 func (c *LatencyConfig) initialize(globalConfig *RoutingConfig) {
 	c.MethodLatencyThresholds = make(map[string]time.Duration)
 
+	c.initializeAggregation(globalConfig)
+
+	if c.Probes != nil {
+		c.Probes.initialize()
+	}
+
+	if c.Adaptive != nil {
+		c.Adaptive.initialize()
+	}
+
 	if c.Methods == nil {
 		return
 	}
@@ -341,29 +1037,323 @@ func (c *LatencyConfig) initialize(globalConfig *RoutingConfig) {
 	}
 }
 
+// initializeAggregation uses the global config's aggregation settings for those that are not set. Settings that are
+// set in neither config are left unset, and the latency check uses their defaults.
+func (c *LatencyConfig) initializeAggregation(globalConfig *RoutingConfig) {
+	if globalConfig == nil || globalConfig.Latency == nil {
+		return
+	}
+
+	c.Aggregation = cmp.Or(c.Aggregation, globalConfig.Latency.Aggregation)
+	c.TooHighRate = cmp.Or(c.TooHighRate, globalConfig.Latency.TooHighRate)
+	c.MinSamples = cmp.Or(c.MinSamples, globalConfig.Latency.MinSamples)
+}
+
 func (c *LatencyConfig) isLatencyConfigValid() bool {
 	if c == nil {
 		return true
 	}
 
+	switch c.Aggregation {
+	case "", RateAggregation, MeanAggregation, P50Aggregation, P90Aggregation, P99Aggregation:
+	default:
+		zap.L().Error("Latency aggregation is unknown.", zap.String("aggregation", string(c.Aggregation)))
+		return false
+	}
+
+	if c.TooHighRate < 0.0 || c.TooHighRate > 1.0 {
+		zap.L().Error("Latency tooHighRate is not in range [0.0, 1.0].", zap.Float64("tooHighRate", c.TooHighRate))
+		return false
+	}
+
 	for _, method := range c.Methods {
 		if !method.isMethodConfigValid() {
 			return false
 		}
 	}
 
-	return true
+	return c.Probes.isValid() && c.Adaptive.isValid()
+}
+
+// RecoveryConfig configures how an upstream is let back in once its error or latency ban expires. In the half-open
+// phase, the upstream only gets a share of the traffic it would otherwise get (plus any latency probes), and a single
+// failure bans it again. Once enough requests succeed, its share ramps up linearly over the slow-start window.
+type RecoveryConfig struct {
+	HalfOpenTrafficShare float64       `yaml:"halfOpenTrafficShare"`
+	HalfOpenSuccesses    uint          `yaml:"halfOpenSuccesses"`
+	SlowStartWindow      time.Duration `yaml:"slowStartWindow"`
+}
+
+func (c *RecoveryConfig) initialize() {
+	if c.HalfOpenTrafficShare == 0 {
+		c.HalfOpenTrafficShare = DefaultHalfOpenTrafficShare
+	}
+
+	if c.HalfOpenSuccesses == 0 {
+		c.HalfOpenSuccesses = DefaultHalfOpenSuccesses
+	}
+
+	if c.SlowStartWindow == 0 {
+		c.SlowStartWindow = DefaultSlowStartWindow
+	}
+}
+
+func (c *RecoveryConfig) isValid() bool {
+	if c == nil {
+		return true
+	}
+
+	isValid := true
+
+	if c.HalfOpenTrafficShare <= 0.0 || c.HalfOpenTrafficShare > 1.0 {
+		isValid = false
+
+		zap.L().Error("halfOpenTrafficShare is not in range (0.0, 1.0]", zap.Any("halfOpenTrafficShare", c.HalfOpenTrafficShare))
+	}
+
+	if c.SlowStartWindow < 0 {
+		isValid = false
+
+		zap.L().Error("slowStartWindow cannot be negative", zap.Duration("slowStartWindow", c.SlowStartWindow))
+	}
+
+	return isValid
+}
+
+// OutlierDetectionConfig configures ejecting upstreams whose error rate or latency is far worse than the median of
+// their group. Unlike the absolute error and latency thresholds, this does not ban every upstream during a network-wide
+// slowdown, and still catches a single bad upstream when all others are doing well.
+type OutlierDetectionConfig struct {
+	Interval           time.Duration `yaml:"interval"`           // How often upstreams are compared, over the requests since the last comparison.
+	EjectionDuration   time.Duration `yaml:"ejectionDuration"`   // How long an outlier is not routed to.
+	MinRequests        uint          `yaml:"minRequests"`        // Upstreams with fewer requests in the interval are not compared.
+	ErrorRateFactor    float64       `yaml:"errorRateFactor"`    // Eject if the error rate exceeds the group median by this factor...
+	MinErrorRateDelta  float64       `yaml:"minErrorRateDelta"`  // ...and by at least this much, so a median of zero does not eject on a single error.
+	LatencyFactor      float64       `yaml:"latencyFactor"`      // Eject if the latency percentile exceeds the group median by this factor.
+	LatencyPercentile  float64       `yaml:"latencyPercentile"`  // The latency percentile that is compared, in (0.0, 1.0].
+	MaxEjectionPercent uint          `yaml:"maxEjectionPercent"` // The most upstreams of a group that can be ejected at once, rounded down.
+}
+
+func (c *OutlierDetectionConfig) initialize() {
+	if c.Interval == 0 {
+		c.Interval = DefaultOutlierInterval
+	}
+
+	if c.EjectionDuration == 0 {
+		c.EjectionDuration = DefaultOutlierEjectionDuration
+	}
+
+	if c.MinRequests == 0 {
+		c.MinRequests = DefaultOutlierMinRequests
+	}
+
+	if c.ErrorRateFactor == 0 {
+		c.ErrorRateFactor = DefaultOutlierErrorRateFactor
+	}
+
+	if c.MinErrorRateDelta == 0 {
+		c.MinErrorRateDelta = DefaultOutlierMinErrorRateDelta
+	}
+
+	if c.LatencyFactor == 0 {
+		c.LatencyFactor = DefaultOutlierLatencyFactor
+	}
+
+	if c.LatencyPercentile == 0 {
+		c.LatencyPercentile = DefaultOutlierLatencyPercentile
+	}
+
+	if c.MaxEjectionPercent == 0 {
+		c.MaxEjectionPercent = DefaultOutlierMaxEjectionPercent
+	}
+}
+
+func (c *OutlierDetectionConfig) isValid() bool {
+	if c == nil {
+		return true
+	}
+
+	isValid := true
+
+	if c.Interval < 0 || c.EjectionDuration < 0 {
+		isValid = false
+
+		zap.L().Error("Outlier detection interval and ejectionDuration cannot be negative.", zap.Duration("interval", c.Interval), zap.Duration("ejectionDuration", c.EjectionDuration))
+	}
+
+	if c.ErrorRateFactor < 1.0 || c.LatencyFactor < 1.0 {
+		isValid = false
+
+		zap.L().Error("Outlier detection errorRateFactor and latencyFactor must be at least 1.0.", zap.Float64("errorRateFactor", c.ErrorRateFactor), zap.Float64("latencyFactor", c.LatencyFactor))
+	}
+
+	if c.MinErrorRateDelta < 0.0 || c.MinErrorRateDelta > 1.0 {
+		isValid = false
+
+		zap.L().Error("Outlier detection minErrorRateDelta is not in range [0.0, 1.0].", zap.Float64("minErrorRateDelta", c.MinErrorRateDelta))
+	}
+
+	if c.LatencyPercentile <= 0.0 || c.LatencyPercentile > 1.0 {
+		isValid = false
+
+		zap.L().Error("Outlier detection latencyPercentile is not in range (0.0, 1.0].", zap.Float64("latencyPercentile", c.LatencyPercentile))
+	}
+
+	if c.MaxEjectionPercent > 100 {
+		isValid = false
+
+		zap.L().Error("Outlier detection maxEjectionPercent cannot exceed 100.", zap.Uint("maxEjectionPercent", c.MaxEjectionPercent))
+	}
+
+	return isValid
+}
+
+// CoalescingConfig makes identical in-flight requests for the given methods share a single upstream request. Only
+// methods without side effects should be listed.
+type CoalescingConfig struct {
+	Methods []string `yaml:"methods"` // Method names or patterns such as "eth_get*".
+	// Bounds the shared request, which is not canceled when the clients waiting for it go away. Defaults to
+	// DefaultCoalescingTimeout.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+const DefaultCoalescingTimeout = 30 * time.Second
+
+func (c *CoalescingConfig) GetTimeout() time.Duration {
+	if c.Timeout == 0 {
+		return DefaultCoalescingTimeout
+	}
+
+	return c.Timeout
+}
+
+// IsEligible returns true iff the method matches any of the coalesced methods.
+func (c *CoalescingConfig) IsEligible(method string) bool {
+	for _, pattern := range c.Methods {
+		if isMatch, _ := path.Match(pattern, method); isMatch {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *CoalescingConfig) isValid() bool {
+	if c == nil {
+		return true
+	}
+
+	isValid := true
+
+	if len(c.Methods) == 0 {
+		isValid = false
+
+		zap.L().Error("Coalescing must have methods.")
+	}
+
+	for _, method := range c.Methods {
+		if _, err := path.Match(method, ""); method == "" || err != nil {
+			isValid = false
+
+			zap.L().Error("Coalescing method is empty or an invalid pattern.", zap.String("method", method), zap.Error(err))
+		}
+	}
+
+	if c.Timeout < 0 {
+		isValid = false
+
+		zap.L().Error("Coalescing timeout cannot be negative.", zap.Duration("timeout", c.Timeout))
+	}
+
+	return isValid
+}
+
+// ClientRequirementConfig restricts the requests for the given methods to upstreams running one of the given clients,
+// as reported by web3_clientVersion. Upstreams whose client is unknown do not meet any requirement.
+type ClientRequirementConfig struct {
+	Methods []string                 `yaml:"methods"` // Method names or patterns such as "trace_*".
+	Clients []ClientConstraintConfig `yaml:"clients"`
+}
+
+type ClientConstraintConfig struct {
+	Name       string `yaml:"name"`       // The client name, e.g. "erigon", as in the first part of web3_clientVersion.
+	MinVersion string `yaml:"minVersion"` // Optional, e.g. "2.59.0".
+}
+
+// IsInScope returns true iff any of the given methods matches any of the requirement's methods.
+func (c *ClientRequirementConfig) IsInScope(methods []string) bool {
+	return matchesAnyMethod(c.Methods, methods)
+}
+
+// matchesAnyMethod returns true iff any of the methods matches any of the path.Match patterns.
+func matchesAnyMethod(patterns, methods []string) bool {
+	for _, pattern := range patterns {
+		for _, method := range methods {
+			if isMatch, _ := path.Match(pattern, method); isMatch {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *ClientRequirementConfig) isValid() bool {
+	isValid := true
+
+	if len(c.Methods) == 0 || len(c.Clients) == 0 {
+		isValid = false
+
+		zap.L().Error("Client requirement must have methods and clients.", zap.Strings("methods", c.Methods))
+	}
+
+	for _, method := range c.Methods {
+		if _, err := path.Match(method, ""); method == "" || err != nil {
+			isValid = false
+
+			zap.L().Error("Client requirement method is empty or an invalid pattern.", zap.String("method", method), zap.Error(err))
+		}
+	}
+
+	for _, client := range c.Clients {
+		if client.Name == "" {
+			isValid = false
+
+			zap.L().Error("Client requirement client name cannot be empty.", zap.Strings("methods", c.Methods))
+		}
+
+		if _, err := util.ParseVersion(client.MinVersion); client.MinVersion != "" && err != nil {
+			isValid = false
+
+			zap.L().Error("Client requirement minVersion is invalid.", zap.String("client", client.Name), zap.Error(err))
+		}
+	}
+
+	return isValid
 }
 
 type RoutingConfig struct {
-	AlwaysRoute     *bool          `yaml:"alwaysRoute"`
-	Errors          *ErrorsConfig  `yaml:"errors"`
-	Latency         *LatencyConfig `yaml:"latency"`
-	DetectionWindow *time.Duration `yaml:"detectionWindow"`
-	BanWindow       *time.Duration `yaml:"banWindow"`
-	MaxBlocksBehind int            `yaml:"maxBlocksBehind"`
+	AlwaysRoute     *bool           `yaml:"alwaysRoute"`
+	Errors          *ErrorsConfig   `yaml:"errors"`
+	Latency         *LatencyConfig  `yaml:"latency"`
+	Recovery        *RecoveryConfig `yaml:"recovery"`
+	DetectionWindow *time.Duration  `yaml:"detectionWindow"`
+	BanWindow       *time.Duration  `yaml:"banWindow"`
+	MaxBlocksBehind int             `yaml:"maxBlocksBehind"`
+	MaxHeadAge      time.Duration   `yaml:"maxHeadAge"` // Zero disables head staleness detection.
 	IsInitialized   bool
 	IsEnabled       bool
+	// Upstreams that report new heads more than this later than the fastest upstream, on average, are not routed to.
+	// Zero disables the check.
+	MaxHeadArrivalDelay time.Duration `yaml:"maxHeadArrivalDelay"`
+	// How many blocks an upstream's safe and finalized heads can be behind the max. Defaults to 0.
+	MaxFinalityBlocksBehind uint64 `yaml:"maxFinalityBlocksBehind"`
+	// Disabled unless configured.
+	OutlierDetection *OutlierDetectionConfig `yaml:"outlierDetection"`
+	// Requirements on the client software of the upstreams that serve specific methods.
+	ClientRequirements []ClientRequirementConfig `yaml:"clientRequirements"`
+	// Nil unless identical in-flight requests are coalesced.
+	Coalescing *CoalescingConfig `yaml:"coalescing"`
 }
 
 // IsEnhancedRoutingControlDefined returns true iff any of the enhanced routing control fields are specified
@@ -373,7 +1363,8 @@ type RoutingConfig struct {
 func (r *RoutingConfig) IsEnhancedRoutingControlDefined() bool {
 	// TODO(polsar): This is temporary. Eventually, we want to have enhanced routing control enabled by default even if
 	// none of these fields are specified in the config YAML.
-	return r.Errors != nil || r.Latency != nil || r.DetectionWindow != nil || r.BanWindow != nil || r.AlwaysRoute != nil
+	return r.Errors != nil || r.Latency != nil || r.DetectionWindow != nil || r.BanWindow != nil || r.AlwaysRoute != nil ||
+		r.Recovery != nil || r.OutlierDetection != nil
 }
 
 // setDefaults sets the default values for and initializes the routing config, and returns true.
@@ -440,6 +1431,24 @@ func (r *RoutingConfig) setDefaults(globalConfig *RoutingConfig, force bool) boo
 
 	r.Errors.initialize(globalConfig)
 
+	// Unlike errors and latency, recovery phases are opt-in.
+	if r.Recovery == nil && globalConfig != nil {
+		r.Recovery = globalConfig.Recovery
+	}
+
+	if r.Recovery != nil {
+		r.Recovery.initialize()
+	}
+
+	// Like recovery phases, outlier detection is opt-in.
+	if r.OutlierDetection == nil && globalConfig != nil {
+		r.OutlierDetection = globalConfig.OutlierDetection
+	}
+
+	if r.OutlierDetection != nil {
+		r.OutlierDetection.initialize()
+	}
+
 	if globalConfig != nil {
 		r.Latency.merge(globalConfig.Latency)
 		r.Errors.merge(globalConfig.Errors)
@@ -453,13 +1462,34 @@ func (r *RoutingConfig) setDefaults(globalConfig *RoutingConfig, force bool) boo
 
 func (r *RoutingConfig) isRoutingConfigValid() bool {
 	// TODO(polsar): Validate the HTTP and JSON RPC codes.
-	isValid := r.isErrorRateValid()
+	isValid := r.isErrorRateValid() && r.Errors.isValid()
 	latency := r.Latency
 
 	if latency != nil {
 		isValid = isValid && latency.isLatencyConfigValid()
 	}
 
+	isValid = isValid && r.Recovery.isValid()
+	isValid = isValid && r.OutlierDetection.isValid()
+
+	if r.MaxHeadAge < 0 {
+		zap.L().Error("maxHeadAge cannot be negative.", zap.Duration("maxHeadAge", r.MaxHeadAge))
+
+		isValid = false
+	}
+
+	for i := range r.ClientRequirements {
+		isValid = r.ClientRequirements[i].isValid() && isValid
+	}
+
+	isValid = r.Coalescing.isValid() && isValid
+
+	if r.MaxHeadArrivalDelay < 0 {
+		zap.L().Error("maxHeadArrivalDelay cannot be negative.", zap.Duration("maxHeadArrivalDelay", r.MaxHeadArrivalDelay))
+
+		isValid = false
+	}
+
 	return isValid
 }
 
@@ -485,17 +1515,59 @@ func NewDuration(d time.Duration) *time.Duration {
 type MethodTTLConfig struct {
 	Method string        `yaml:"method"`
 	TTL    time.Duration `yaml:"ttl"`
+	// How long the method's entries are still served after their TTL while they are refreshed in the background.
+	StaleWhileRevalidate time.Duration `yaml:"staleWhileRevalidate"`
+	// How long the method's null results are cached, until a new block arrives. Zero disables it.
+	NegativeTTL time.Duration `yaml:"negativeTTL"`
 }
 
 type ChainCacheConfig struct {
 	MethodTTLs map[string]time.Duration
 	TTL        time.Duration `yaml:"ttl"`
+	// Nil unless the TTLs depend on the finality of the block each request references.
+	Finality *CacheFinalityConfig
+	// Whether "latest" block params are replaced by the current head before requests are cached and forwarded.
+	ResolveLatest bool
+	// How long expired entries are kept to be served when a request cannot be routed. Zero disables it.
+	StaleIfError time.Duration
+	// Nil unless a method has a stale-while-revalidate window.
+	MethodStaleWhileRevalidates map[string]time.Duration
+	// Nil unless a method's null results are cached.
+	MethodNegativeTTLs map[string]time.Duration
+}
+
+// CacheFinalityConfig overrides the TTL of cached methods based on the block their requests reference. Requests pinned
+// by block hash, or referencing a block at or below the finalized height or `Confirmations` blocks below the head, get
+// FinalizedTTL. Requests referencing a block near the head, or a block tag such as "latest", get HeadTTL, and are
+// invalidated when a reorg of their block is detected.
+type CacheFinalityConfig struct {
+	Confirmations uint64        `yaml:"confirmations"` // Zero means only the finalized height is used.
+	FinalizedTTL  time.Duration `yaml:"finalizedTTL"`  // Zero means the entries never expire.
+	HeadTTL       time.Duration `yaml:"headTTL"`       // Zero means the method's TTL.
+}
+
+func (c *CacheFinalityConfig) isValid() bool {
+	// The redis-cache library will default the TTL to 1 hour if 0 < ttl < 1 second.
+	if c.FinalizedTTL < 0 || (c.FinalizedTTL > 0 && c.FinalizedTTL < time.Second) {
+		zap.L().Error("finalizedTTL must be zero or greater or equal to 1s", zap.Duration("finalizedTTL", c.FinalizedTTL))
+		return false
+	}
+
+	if c.HeadTTL < 0 || (c.HeadTTL > 0 && c.HeadTTL < time.Second) {
+		zap.L().Error("headTTL must be zero or greater or equal to 1s", zap.Duration("headTTL", c.HeadTTL))
+		return false
+	}
+
+	return true
 }
 
 func (c *ChainCacheConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	type ChainCacheConfigAux struct {
-		Methods []MethodTTLConfig `yaml:"methods"`
-		TTL     time.Duration     `yaml:"ttl"`
+		Finality      *CacheFinalityConfig `yaml:"finality"`
+		Methods       []MethodTTLConfig    `yaml:"methods"`
+		TTL           time.Duration        `yaml:"ttl"`
+		ResolveLatest bool                 `yaml:"resolveLatest"`
+		StaleIfError  time.Duration        `yaml:"staleIfError"`
 	}
 
 	// Unmarshal into the auxiliary type
@@ -505,10 +1577,29 @@ func (c *ChainCacheConfig) UnmarshalYAML(unmarshal func(interface{}) error) erro
 	}
 
 	c.TTL = aux.TTL
+	c.Finality = aux.Finality
+	c.ResolveLatest = aux.ResolveLatest
+	c.StaleIfError = aux.StaleIfError
 
 	c.MethodTTLs = make(map[string]time.Duration)
 	for _, methodConfig := range aux.Methods {
 		c.MethodTTLs[methodConfig.Method] = methodConfig.TTL
+
+		if methodConfig.StaleWhileRevalidate != 0 {
+			if c.MethodStaleWhileRevalidates == nil {
+				c.MethodStaleWhileRevalidates = make(map[string]time.Duration)
+			}
+
+			c.MethodStaleWhileRevalidates[methodConfig.Method] = methodConfig.StaleWhileRevalidate
+		}
+
+		if methodConfig.NegativeTTL != 0 {
+			if c.MethodNegativeTTLs == nil {
+				c.MethodNegativeTTLs = make(map[string]time.Duration)
+			}
+
+			c.MethodNegativeTTLs[methodConfig.Method] = methodConfig.NegativeTTL
+		}
 	}
 
 	return nil
@@ -535,6 +1626,36 @@ func (c *ChainCacheConfig) isValid() bool {
 		}
 	}
 
+	if c.StaleIfError < 0 {
+		zap.L().Error("staleIfError cannot be negative", zap.Duration("staleIfError", c.StaleIfError))
+		return false
+	}
+
+	for method, staleWhileRevalidate := range c.MethodStaleWhileRevalidates {
+		if staleWhileRevalidate < 0 {
+			zap.L().Error("method staleWhileRevalidate cannot be negative", zap.String("method", method))
+			return false
+		}
+	}
+
+	// The redis-cache library will default the TTL to 1 hour if 0 < ttl < 1 second.
+	for method, negativeTTL := range c.MethodNegativeTTLs {
+		if negativeTTL < time.Second {
+			zap.L().Error("method negativeTTL must be greater or equal to 1s", zap.String("method", method))
+			return false
+		}
+
+		// Requests for methods that are not cached skip the cache entirely, null results included.
+		if c.GetTTLForMethod(method) <= 0 {
+			zap.L().Error("method negativeTTL requires a positive ttl for the method", zap.String("method", method))
+			return false
+		}
+	}
+
+	if c.Finality != nil {
+		return c.Finality.isValid()
+	}
+
 	return true
 }
 
@@ -546,7 +1667,19 @@ func (c *ChainCacheConfig) GetTTLForMethod(method string) time.Duration {
 	return c.TTL
 }
 
-// GetMinimumTTL returns the minimum TTL value across the default TTL and all method-specific TTLs.
+// GetStaleWhileRevalidateForMethod returns how long the method's entries are served after their TTL while they are
+// refreshed in the background, or 0 if they are not.
+func (c *ChainCacheConfig) GetStaleWhileRevalidateForMethod(method string) time.Duration {
+	return c.MethodStaleWhileRevalidates[method]
+}
+
+// GetNegativeTTLForMethod returns how long the method's null results are cached, or 0 if they are not.
+func (c *ChainCacheConfig) GetNegativeTTLForMethod(method string) time.Duration {
+	return c.MethodNegativeTTLs[method]
+}
+
+// GetMinimumTTL returns the minimum TTL value across the default TTL, all method-specific TTLs, the head TTL and the
+// negative TTLs.
 // If no TTL values are set (empty config or all zero values), it returns 0.
 func (c *ChainCacheConfig) GetMinimumTTL() time.Duration {
 	minTTL := c.TTL
@@ -569,15 +1702,28 @@ func (c *ChainCacheConfig) GetMinimumTTL() time.Duration {
 		}
 	}
 
+	// Requests near the head may be cached for less than their method's TTL.
+	if c.Finality != nil && c.Finality.HeadTTL > 0 && (minTTL == 0 || c.Finality.HeadTTL < minTTL) {
+		minTTL = c.Finality.HeadTTL
+	}
+
+	// Null results may be cached for less than their method's TTL.
+	for _, negativeTTL := range c.MethodNegativeTTLs {
+		if negativeTTL > 0 && (minTTL == 0 || negativeTTL < minTTL) {
+			minTTL = negativeTTL
+		}
+	}
+
 	return minTTL
 }
 
 type SingleChainConfig struct {
-	Cache     ChainCacheConfig
-	ChainName string `yaml:"chainName"`
-	Routing   RoutingConfig
-	Upstreams []UpstreamConfig
-	Groups    []GroupConfig
+	Cache       ChainCacheConfig
+	HealthCheck HealthCheckConfig `yaml:"healthCheck"`
+	ChainName   string            `yaml:"chainName"`
+	Routing     RoutingConfig
+	Upstreams   []UpstreamConfig
+	Groups      []GroupConfig
 }
 
 func (c *SingleChainConfig) isValid() bool {
@@ -600,6 +1746,26 @@ func (c *SingleChainConfig) isValid() bool {
 }
 
 func (c *SingleChainConfig) setDefaults(globalConfig *GlobalConfig, isGlobalRoutingConfigSpecified bool) {
+	c.HealthCheck.merge(&globalConfig.HealthCheck)
+
+	for idx := range c.Upstreams {
+		c.Upstreams[idx].HealthCheckConfig.merge(&c.HealthCheck)
+
+		for probeIdx := range c.Upstreams[idx].SyntheticProbes {
+			c.Upstreams[idx].SyntheticProbes[probeIdx].initialize()
+		}
+	}
+
+	// Unlike the rest of the routing config, client requirements and coalescing do not depend on enhanced routing
+	// control, so they are inherited from the global config either way.
+	if c.Routing.ClientRequirements == nil {
+		c.Routing.ClientRequirements = globalConfig.Routing.ClientRequirements
+	}
+
+	if c.Routing.Coalescing == nil {
+		c.Routing.Coalescing = globalConfig.Routing.Coalescing
+	}
+
 	if !isGlobalRoutingConfigSpecified && !c.Routing.IsEnhancedRoutingControlDefined() {
 		return
 	}
@@ -638,6 +1804,7 @@ func (config *Config) Validate() error {
 
 	// Validate global config.
 	isValid = isValid && config.Global.Routing.isRoutingConfigValid()
+	isValid = isValid && config.Global.Cache.isValid()
 
 	if !isValid {
 		return errors.New("invalid config found")
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -199,68 +201,1703 @@ func TestParseConfig_InvalidConfigs(t *testing.T) {
                     priority: 1
             `,
 		},
+		{
+			name: "Group failover config with negative dwell time.",
+			config: `
+            chains:
+              - chainName: ethereum
+                groups:
+                  - id: primary
+                    priority: 0
+                    failover:
+                      minDwellTime: -1m
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    group: primary
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Recovery config with traffic share above 1.",
+			config: `
+            global:
+              routing:
+                recovery:
+                  halfOpenTrafficShare: 1.5
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Latency config with an unknown aggregation.",
+			config: `
+            chains:
+              - chainName: ethereum
+                routing:
+                  latency:
+                    aggregation: p95
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Adaptive latency config with a multiplier below 1.",
+			config: `
+            global:
+              routing:
+                latency:
+                  adaptive:
+                    multiplier: 0.5
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Errors method with an invalid pattern.",
+			config: `
+            chains:
+              - chainName: ethereum
+                routing:
+                  errors:
+                    methods:
+                      - method: "debug_["
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Outlier detection config with latency percentile above 1.",
+			config: `
+            chains:
+              - chainName: ethereum
+                routing:
+                  outlierDetection:
+                    latencyPercentile: 99
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Outlier detection config with max ejection percent above 100.",
+			config: `
+            global:
+              routing:
+                outlierDetection:
+                  maxEjectionPercent: 150
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Client requirement without clients.",
+			config: `
+            chains:
+              - chainName: ethereum
+                routing:
+                  clientRequirements:
+                    - methods: ["trace_*"]
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Client requirement with an invalid min version.",
+			config: `
+            chains:
+              - chainName: ethereum
+                routing:
+                  clientRequirements:
+                    - methods: ["trace_*"]
+                      clients:
+                        - name: erigon
+                          minVersion: latest
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Memory cache together with Redis.",
+			config: `
+            global:
+              cache:
+                redis: localhost:6379
+                memory:
+                  maxEntries: 1000
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Cache finality config with a head TTL below 1s.",
+			config: `
+            chains:
+              - chainName: ethereum
+                cache:
+                  ttl: 6s
+                  finality:
+                    headTTL: 500ms
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Cache config with a negative staleIfError.",
+			config: `
+            chains:
+              - chainName: ethereum
+                cache:
+                  ttl: 6s
+                  staleIfError: -1m
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Cache method config with a negative staleWhileRevalidate.",
+			config: `
+            chains:
+              - chainName: ethereum
+                cache:
+                  ttl: 6s
+                  methods:
+                    - method: eth_gasPrice
+                      ttl: 2s
+                      staleWhileRevalidate: -1s
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Cache method config with a negativeTTL below 1s.",
+			config: `
+            chains:
+              - chainName: ethereum
+                cache:
+                  ttl: 6s
+                  methods:
+                    - method: eth_getTransactionReceipt
+                      ttl: 6s
+                      negativeTTL: 500ms
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Cache method config with a negativeTTL but no ttl.",
+			config: `
+            chains:
+              - chainName: ethereum
+                cache:
+                  methods:
+                    - method: eth_getTransactionReceipt
+                      negativeTTL: 2s
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Coalescing without methods.",
+			config: `
+            chains:
+              - chainName: ethereum
+                routing:
+                  coalescing:
+                    methods: []
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Coalescing with a negative timeout.",
+			config: `
+            chains:
+              - chainName: ethereum
+                routing:
+                  coalescing:
+                    methods: [eth_call]
+                    timeout: -1s
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Redis Sentinel together with Cluster.",
+			config: `
+            global:
+              cache:
+                redisOptions:
+                  sentinel:
+                    masterName: mymaster
+                    addresses: ["sentinel:26379"]
+                  cluster:
+                    addresses: ["node:6379"]
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Redis Sentinel without a master name.",
+			config: `
+            global:
+              cache:
+                redisOptions:
+                  sentinel:
+                    addresses: ["sentinel:26379"]
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Redis Cluster with a db.",
+			config: `
+            global:
+              cache:
+                redisOptions:
+                  db: 1
+                  cluster:
+                    addresses: ["node:6379"]
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Redis Cluster together with Redis endpoints.",
+			config: `
+            global:
+              cache:
+                redis: localhost:6379
+                redisOptions:
+                  cluster:
+                    addresses: ["node:6379"]
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Redis password set from several sources.",
+			config: `
+            global:
+              cache:
+                redis: localhost:6379
+                redisOptions:
+                  password: secret
+                  passwordFile: /run/secrets/redis
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Redis password file that does not exist.",
+			config: `
+            global:
+              cache:
+                redis: localhost:6379
+                redisOptions:
+                  passwordFile: /does/not/exist
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Redis TLS CA file that does not exist.",
+			config: `
+            global:
+              cache:
+                redis: localhost:6379
+                redisOptions:
+                  tls:
+                    caFile: /does/not/exist
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Redis TLS certFile without keyFile.",
+			config: `
+            global:
+              cache:
+                redis: localhost:6379
+                redisOptions:
+                  tls:
+                    certFile: client.crt
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Memory cache together with Redis Cluster.",
+			config: `
+            global:
+              cache:
+                memory:
+                  maxEntries: 1000
+                redisOptions:
+                  cluster:
+                    addresses: ["node:6379"]
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Health check policy with non-positive interval.",
+			config: `
+            global:
+              healthCheck:
+                interval: 0s
+
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+            `,
+		},
+		{
+			name: "Health check policy with negative jitter.",
+			config: `
+            chains:
+              - chainName: ethereum
+                upstreams:
+                  - id: alchemy-eth
+                    httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+                    nodeType: full
+                    healthCheck:
+                      jitter: -1s
+            `,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			configBytes := []byte(testCase.config)
+			_, err := parseConfig(configBytes)
+			assert.NotNil(t, err)
+
+			// To prevent catching formatting errors, that's not what we're checking for in this test.
+			if err != nil {
+				assert.NotContains(t, err.Error(), "found character that cannot start any token", testCase.config)
+				assert.NotContains(t, err.Error(), "found a tab character that violates indentation", testCase.config)
+			}
+		})
+	}
+}
+
+func newBool(b bool) *bool {
+	return &b
+}
+
+func TestParseConfig_ValidConfig(t *testing.T) {
+	config := `
+    global:
+      port: 8080
+      cache:
+        redis: localhost:6379
+
+    chains:
+      - chainName: ethereum
+
+        groups:
+          - id: primary
+            priority: 0
+          - id: fallback
+            priority: 1
+
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            wsURL: "wss://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            healthCheck:
+              useWsForBlockHeight: true
+            group: primary
+            nodeType: full
+            methods:
+              enabled: eth_getStorageAt
+              disabled: eth_getBalance,getLogs
+          - id: ankr-polygon
+            httpURL: "https://rpc.ankr.com/polygon"
+            wsURL: "wss://rpc.ankr.com/polygon/ws/${ANKR_API_KEY}"
+            group: fallback
+            nodeType: archive
+            requestHeaders:
+              - key: "x-api-key"
+                value: "xxxx"
+              - key: "client-id"
+                value: "my-client"
+
+      - chainName: polygon
+        upstreams:
+          - id: erigon-polygon-1
+            httpURL: "http://127.0.0.1:4040"
+            nodeType: archive
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
+
+	expectedConfig := Config{
+		Global: GlobalConfig{
+			Port: 8080,
+			Cache: CacheConfig{
+				Redis: "localhost:6379",
+			},
+		},
+		Chains: []SingleChainConfig{{
+			Upstreams: []UpstreamConfig{
+				{
+					ID:      "alchemy-eth",
+					HTTPURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}",
+					WSURL:   "wss://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}",
+					HealthCheckConfig: HealthCheckConfig{
+						UseWSForBlockHeight: newBool(true),
+					},
+					GroupID:  "primary",
+					NodeType: Full,
+					Methods: MethodsConfig{
+						Enabled:  map[string]bool{"eth_getStorageAt": true},
+						Disabled: map[string]bool{"eth_getBalance": true, "getLogs": true},
+					},
+				},
+				{
+					ID:      "ankr-polygon",
+					HTTPURL: "https://rpc.ankr.com/polygon",
+					WSURL:   "wss://rpc.ankr.com/polygon/ws/${ANKR_API_KEY}",
+					HealthCheckConfig: HealthCheckConfig{
+						UseWSForBlockHeight: nil,
+					},
+					GroupID:  "fallback",
+					NodeType: Archive,
+					RequestHeadersConfig: []RequestHeaderConfig{
+						{
+							Key:   "x-api-key",
+							Value: "xxxx",
+						},
+						{
+							Key:   "client-id",
+							Value: "my-client",
+						},
+					},
+				},
+			},
+			ChainName: "ethereum",
+			Groups: []GroupConfig{
+				{
+					ID:       "primary",
+					Priority: 0,
+				},
+				{
+					ID:       "fallback",
+					Priority: 1,
+				},
+			},
+		}, {
+			ChainName: "polygon",
+			Upstreams: []UpstreamConfig{{
+				ID:       "erigon-polygon-1",
+				HTTPURL:  "http://127.0.0.1:4040",
+				NodeType: Archive,
+			}},
+		}},
+	}
+
+	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
+		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	}
+}
+
+func getCommonChainsConfig(routingConfig *RoutingConfig) []SingleChainConfig {
+	c := RoutingConfig{}
+
+	if routingConfig != nil {
+		c = *routingConfig
+	}
+
+	return []SingleChainConfig{{
+		Routing: c,
+		Upstreams: []UpstreamConfig{
+			{
+				ID:       "alchemy-eth",
+				HTTPURL:  "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}",
+				GroupID:  "primary",
+				NodeType: Full,
+			},
+		},
+		ChainName: "ethereum",
+		Groups: []GroupConfig{
+			{
+				ID:       "primary",
+				Priority: 0,
+			},
+		},
+	}}
+}
+
+func TestParseConfig_ValidConfigLatencyRouting_AllFieldsSet(t *testing.T) {
+	config := `
+    global:
+      routing:
+        maxBlocksBehind: 33
+        detectionWindow: 10m
+        banWindow: 50m
+        errors:
+          rate: 0.25
+          httpCodes:
+            - 5xx
+            - 420
+          jsonRpcCodes:
+            - 32xxx
+          errorStrings:
+            - "internal server error"
+        latency:
+          threshold: 1000ms
+          methods:
+            - method: eth_getLogs
+              threshold: 2000ms
+            - method: eth_call
+              threshold: 10000ms
+        alwaysRoute: true
+
+    chains:
+      - chainName: ethereum
+
+        groups:
+          - id: primary
+            priority: 0
+
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
+
+	expectedLatencyConfig := LatencyConfig{
+		MethodLatencyThresholds: map[string]time.Duration{
+			"eth_call":    10000 * time.Millisecond,
+			"eth_getLogs": 2000 * time.Millisecond,
+		},
+		Threshold: 1000 * time.Millisecond,
+		Methods: []MethodConfig{
+			{
+				Name:      "eth_getLogs",
+				Threshold: 2000 * time.Millisecond,
+			},
+			{
+				Name:      "eth_call",
+				Threshold: 10000 * time.Millisecond,
+			},
+		},
+	}
+
+	expectedRoutingConfig := RoutingConfig{
+		MaxBlocksBehind: 33,
+		DetectionWindow: NewDuration(10 * time.Minute),
+		BanWindow:       NewDuration(50 * time.Minute),
+		Errors: &ErrorsConfig{
+			Rate: 0.25,
+			HTTPCodes: []string{
+				"420",
+				"5xx",
+			},
+			JSONRPCCodes: []string{
+				"32xxx",
+			},
+			ErrorStrings: []string{
+				"internal server error",
+			},
+		},
+		Latency:       &expectedLatencyConfig,
+		AlwaysRoute:   newBool(true),
+		IsInitialized: true,
+		IsEnabled:     true,
+	}
+
+	expectedRoutingChainConfig := expectedRoutingConfig
+	expectedRoutingChainConfig.MaxBlocksBehind = 0
+
+	expectedConfig := Config{
+		Global: GlobalConfig{
+			Routing: expectedRoutingConfig,
+		},
+		Chains: getCommonChainsConfig(&expectedRoutingChainConfig),
+	}
+
+	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
+		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	}
+}
+
+func TestParseConfig_ValidConfigLatencyRouting_ErrorsConfigOverridesAndMerges(t *testing.T) {
+	config := `
+    global:
+      routing:
+        errors:
+          rate: 0.28
+          httpCodes:
+            - 5xx
+            - 420
+          jsonRpcCodes:
+            - 32xxx
+            - 28282
+          errorStrings:
+            - "internal server error"
+            - "freaking out"
+
+    chains:
+      - chainName: ethereum
+
+        groups:
+          - id: primary
+            priority: 0
+
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+
+        routing:
+          errors:
+            rate: 0.82
+            httpCodes:
+              - 5xx
+              - 388
+              - 4X4
+            jsonRpcCodes:
+              - XXXX1
+              - 28282
+            errorStrings:
+              - "internal server error"
+              - "some weird error"
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
+
+	expectedRoutingConfig := RoutingConfig{
+		DetectionWindow: NewDuration(DefaultDetectionWindow),
+		BanWindow:       NewDuration(DefaultBanWindow),
+		Errors: &ErrorsConfig{
+			Rate: 0.28,
+			HTTPCodes: []string{
+				"5xx",
+				"420",
+			},
+			JSONRPCCodes: []string{
+				"32xxx",
+				"28282",
+			},
+			ErrorStrings: []string{
+				"internal server error",
+				"freaking out",
+			},
+		},
+		Latency:       &LatencyConfig{MethodLatencyThresholds: map[string]time.Duration{}},
+		AlwaysRoute:   newBool(false),
+		IsInitialized: true,
+		IsEnabled:     true,
+	}
+
+	expectedRoutingChainConfig := expectedRoutingConfig
+	expectedRoutingChainConfig.MaxBlocksBehind = 0
+	expectedRoutingChainConfig.Errors = &ErrorsConfig{
+		Rate: 0.82,
+		HTTPCodes: []string{
+			"388",
+			"420",
+			"4X4",
+			"5xx",
+		},
+		JSONRPCCodes: []string{
+			"28282",
+			"32xxx",
+			"XXXX1",
+		},
+		ErrorStrings: []string{
+			"freaking out",
+			"internal server error",
+			"some weird error",
+		},
+	}
+
+	expectedConfig := Config{
+		Global: GlobalConfig{
+			Routing: expectedRoutingConfig,
+		},
+		Chains: getCommonChainsConfig(&expectedRoutingChainConfig),
+	}
+
+	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
+		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	}
+}
+
+func TestParseConfig_ValidConfigLatencyRouting_DefaultsForDetectionAndBanWindows_Set(t *testing.T) {
+	config := `
+    global:
+      routing:
+        errors:
+          rate: 0.25
+        latency:
+          threshold: 1000ms
+
+    chains:
+      - chainName: ethereum
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
+
+	expectedLatencyConfig := LatencyConfig{
+		MethodLatencyThresholds: map[string]time.Duration{},
+		Threshold:               1000 * time.Millisecond,
+	}
+
+	expectedConfig := Config{
+		Global: GlobalConfig{
+			Routing: RoutingConfig{
+				DetectionWindow: NewDuration(DefaultDetectionWindow),
+				BanWindow:       NewDuration(DefaultBanWindow),
+				Errors: &ErrorsConfig{
+					Rate: 0.25,
+				},
+				Latency:       &expectedLatencyConfig,
+				AlwaysRoute:   newBool(false),
+				IsInitialized: true,
+				IsEnabled:     true,
+			},
+		},
+		Chains: getCommonChainsConfig(&RoutingConfig{
+			DetectionWindow: NewDuration(DefaultDetectionWindow),
+			BanWindow:       NewDuration(DefaultBanWindow),
+			Errors:          &ErrorsConfig{Rate: DefaultErrorRate},
+			Latency:         &expectedLatencyConfig,
+			AlwaysRoute:     newBool(false),
+			IsInitialized:   true,
+			IsEnabled:       true,
+		}),
+	}
+
+	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
+		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	}
+}
+
+func TestParseConfig_ValidConfigLatencyRouting_DefaultsForDetectionAndBanWindows_NotSet(t *testing.T) {
+	config := `
+    chains:
+      - chainName: ethereum
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
+
+	expectedConfig := Config{
+		Chains: getCommonChainsConfig(nil),
+	}
+
+	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
+		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	}
+}
+
+func TestParseConfig_InvalidConfigLatencyRouting_InvalidRateInChainConfig(t *testing.T) {
+	config := `
+    chains:
+      - chainName: ethereum
+        routing:
+          errors:
+            rate: 1.25
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	_, err := parseConfig(configBytes)
+
+	if err == nil {
+		t.Errorf("Expected error parsing invalid YAML.")
+	}
+}
+
+func TestParseConfig_InvalidConfigLatencyRouting_InvalidRateInGlobalConfig(t *testing.T) {
+	config := `
+    global:
+      routing:
+        errors:
+          rate: -0.25
+
+    chains:
+      - chainName: ethereum
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	_, err := parseConfig(configBytes)
+
+	if err == nil {
+		t.Errorf("Expected error parsing invalid YAML.")
+	}
+}
+
+func TestParseConfig_ValidConfigLatencyRouting_MethodLatencies_TopLevelLatencySpecifiedBothPerChainAndGlobal(t *testing.T) {
+	config := `
+    global:
+      routing:
+        latency:
+          threshold: 1000ms
+          methods:
+            - method: getLogs
+              threshold: 2000ms
+            - method: eth_getStorageAt
+
+    chains:
+      - chainName: ethereum
+        routing:
+          latency:
+            threshold: 4000ms
+            methods:
+              - method: getLogs
+              - method: eth_getStorageAt
+                threshold: 6000ms
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
+
+	expectedConfig := Config{
+		Global: GlobalConfig{
+			Routing: RoutingConfig{
+				DetectionWindow: NewDuration(DefaultDetectionWindow),
+				BanWindow:       NewDuration(DefaultBanWindow),
+				Latency: &LatencyConfig{
+					MethodLatencyThresholds: map[string]time.Duration{
+						"getLogs":          2000 * time.Millisecond,
+						"eth_getStorageAt": 1000 * time.Millisecond, // Top-level latency default
+					},
+					Threshold: 1000 * time.Millisecond,
+					Methods: []MethodConfig{
+						{
+							Name:      "getLogs",
+							Threshold: 2000 * time.Millisecond,
+						},
+						{
+							Name: "eth_getStorageAt",
+						},
+					},
+				},
+				Errors:        &ErrorsConfig{Rate: DefaultErrorRate},
+				AlwaysRoute:   newBool(false),
+				IsInitialized: true,
+				IsEnabled:     true,
+			},
+		},
+
+		Chains: getCommonChainsConfig(&RoutingConfig{
+			DetectionWindow: NewDuration(DefaultDetectionWindow),
+			BanWindow:       NewDuration(DefaultBanWindow),
+			Latency: &LatencyConfig{
+				MethodLatencyThresholds: map[string]time.Duration{
+					"getLogs":          4000 * time.Millisecond, // Top-level latency default
+					"eth_getStorageAt": 6000 * time.Millisecond,
+				},
+				Threshold: 4000 * time.Millisecond,
+				Methods: []MethodConfig{
+					{
+						Name: "getLogs",
+					},
+					{
+						Name:      "eth_getStorageAt",
+						Threshold: 6000 * time.Millisecond,
+					},
+				},
+			},
+			Errors:        &ErrorsConfig{Rate: DefaultErrorRate},
+			AlwaysRoute:   newBool(false),
+			IsInitialized: true,
+			IsEnabled:     true,
+		}),
+	}
+
+	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
+		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	}
+}
+
+func TestParseConfig_ValidConfigLatencyRouting_MethodLatencies_TopLevelLatencyNotSpecifiedGlobalConfig(t *testing.T) {
+	config := `
+    global:
+      routing:
+        latency:
+          methods:
+            - method: getLogs
+              threshold: 2000ms
+            - method: eth_getStorageAt
+
+    chains:
+      - chainName: ethereum
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
+
+	expectedLatencyConfig := LatencyConfig{
+		MethodLatencyThresholds: map[string]time.Duration{
+			"getLogs":          2000 * time.Millisecond,
+			"eth_getStorageAt": DefaultMaxLatency, // Global default
+		},
+		Methods: []MethodConfig{
+			{
+				Name:      "getLogs",
+				Threshold: 2000 * time.Millisecond,
+			},
+			{
+				Name: "eth_getStorageAt",
+			},
+		},
+		Threshold: DefaultMaxLatency,
+	}
+
+	expectedConfig := Config{
+		Global: GlobalConfig{
+			Routing: RoutingConfig{
+				DetectionWindow: NewDuration(DefaultDetectionWindow),
+				BanWindow:       NewDuration(DefaultBanWindow),
+				Latency:         &expectedLatencyConfig,
+				Errors:          &ErrorsConfig{Rate: DefaultErrorRate},
+				AlwaysRoute:     newBool(false),
+				IsInitialized:   true,
+				IsEnabled:       true,
+			},
+		},
+
+		Chains: getCommonChainsConfig(&RoutingConfig{
+			DetectionWindow: NewDuration(DefaultDetectionWindow),
+			BanWindow:       NewDuration(DefaultBanWindow),
+			Latency:         &expectedLatencyConfig,
+			Errors:          &ErrorsConfig{Rate: DefaultErrorRate},
+			AlwaysRoute:     newBool(false),
+			IsInitialized:   true,
+			IsEnabled:       true,
+		}),
+	}
+
+	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
+		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	}
+}
+
+func TestParseConfig_ValidConfigLatencyRouting_MethodLatencies_TopLevelLatencySpecifiedInGlobalConfigOnly(t *testing.T) {
+	config := `
+    global:
+      routing:
+        latency:
+          threshold: 1000ms
+          methods:
+            - method: getLogs
+              threshold: 2000ms
+            - method: eth_getStorageAt
+
+    chains:
+      - chainName: ethereum
+        routing:
+          latency:
+            methods:
+              - method: getLogs
+              - method: eth_getStorageAt
+                threshold: 6000ms
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
+
+	expectedConfig := Config{
+		Global: GlobalConfig{
+			Routing: RoutingConfig{
+				DetectionWindow: NewDuration(DefaultDetectionWindow),
+				BanWindow:       NewDuration(DefaultBanWindow),
+				Latency: &LatencyConfig{
+					MethodLatencyThresholds: map[string]time.Duration{
+						"getLogs":          2000 * time.Millisecond,
+						"eth_getStorageAt": 1000 * time.Millisecond, // Top-level latency default
+					},
+					Threshold: 1000 * time.Millisecond,
+					Methods: []MethodConfig{
+						{
+							Name:      "getLogs",
+							Threshold: 2000 * time.Millisecond,
+						},
+						{
+							Name: "eth_getStorageAt",
+						},
+					},
+				},
+				Errors:        &ErrorsConfig{Rate: DefaultErrorRate},
+				AlwaysRoute:   newBool(false),
+				IsInitialized: true,
+				IsEnabled:     true,
+			},
+		},
+
+		Chains: getCommonChainsConfig(&RoutingConfig{
+			DetectionWindow: NewDuration(DefaultDetectionWindow),
+			BanWindow:       NewDuration(DefaultBanWindow),
+			Latency: &LatencyConfig{
+				MethodLatencyThresholds: map[string]time.Duration{
+					"getLogs":          2000 * time.Millisecond, // Top-level latency for method
+					"eth_getStorageAt": 6000 * time.Millisecond,
+				},
+				Methods: []MethodConfig{
+					{
+						Name: "getLogs",
+					},
+					{
+						Name:      "eth_getStorageAt",
+						Threshold: 6000 * time.Millisecond,
+					},
+				},
+			},
+			Errors:        &ErrorsConfig{Rate: DefaultErrorRate},
+			AlwaysRoute:   newBool(false),
+			IsInitialized: true,
+			IsEnabled:     true,
+		}),
+	}
+
+	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
+		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	}
+}
+
+func TestParseConfig_ValidConfigLatencyRouting_MethodLatencies_TopLevelLatencyNotSpecifiedNeitherGlobalNotChain(t *testing.T) {
+	config := `
+    global:
+      routing:
+        latency:
+          methods:
+            - method: getLogs
+              threshold: 2000ms
+            - method: eth_getStorageAt
+            - method: eth_chainId
+              threshold: 20ms
+            - method: eth_yetAnotherMethod
+              threshold: 218ms
+        errors:
+          rate: 0.88
+          httpCodes:
+            - 5xx
+            - 420
+          jsonRpcCodes:
+            - 32xxx
+          errorStrings:
+            - "internal server error"
+
+    chains:
+      - chainName: ethereum
+        routing:
+          latency:
+            methods:
+              - method: getLogs
+              - method: eth_getStorageAt
+                threshold: 6000ms
+              - method: eth_doesSomethingImportant
+              - method: eth_yetAnotherMethod
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
+
+	expectedErrorsConfig := ErrorsConfig{
+		Rate: 0.88,
+		HTTPCodes: []string{
+			"420",
+			"5xx",
+		},
+		JSONRPCCodes: []string{
+			"32xxx",
+		},
+		ErrorStrings: []string{
+			"internal server error",
+		},
+	}
+
+	expectedConfig := Config{
+		Global: GlobalConfig{
+			Routing: RoutingConfig{
+				DetectionWindow: NewDuration(DefaultDetectionWindow),
+				BanWindow:       NewDuration(DefaultBanWindow),
+				Latency: &LatencyConfig{
+					MethodLatencyThresholds: map[string]time.Duration{
+						"getLogs":              2000 * time.Millisecond,
+						"eth_getStorageAt":     DefaultMaxLatency, // Top-level latency default
+						"eth_chainId":          20 * time.Millisecond,
+						"eth_yetAnotherMethod": 218 * time.Millisecond,
+					},
+					Methods: []MethodConfig{
+						{
+							Name:      "getLogs",
+							Threshold: 2000 * time.Millisecond,
+						},
+						{
+							Name: "eth_getStorageAt",
+						},
+						{
+							Name:      "eth_chainId",
+							Threshold: 20 * time.Millisecond,
+						},
+						{
+							Name:      "eth_yetAnotherMethod",
+							Threshold: 218 * time.Millisecond,
+						},
+					},
+					Threshold: DefaultMaxLatency,
+				},
+				Errors:        &expectedErrorsConfig,
+				AlwaysRoute:   newBool(false),
+				IsInitialized: true,
+				IsEnabled:     true,
+			},
+		},
+
+		Chains: getCommonChainsConfig(&RoutingConfig{
+			DetectionWindow: NewDuration(DefaultDetectionWindow),
+			BanWindow:       NewDuration(DefaultBanWindow),
+			Latency: &LatencyConfig{
+				MethodLatencyThresholds: map[string]time.Duration{
+					"getLogs":                    2000 * time.Millisecond, // Top-level latency for method
+					"eth_getStorageAt":           6000 * time.Millisecond,
+					"eth_doesSomethingImportant": DefaultMaxLatency,
+					"eth_chainId":                20 * time.Millisecond, // Inherited from global latency config
+					"eth_yetAnotherMethod":       218 * time.Millisecond,
+				},
+				Methods: []MethodConfig{
+					{
+						Name: "getLogs",
+					},
+					{
+						Name:      "eth_getStorageAt",
+						Threshold: 6000 * time.Millisecond,
+					},
+					{
+						Name: "eth_doesSomethingImportant",
+					},
+					{
+						Name: "eth_yetAnotherMethod",
+					},
+				},
+			},
+			Errors:        &expectedErrorsConfig,
+			AlwaysRoute:   newBool(false),
+			IsInitialized: true,
+			IsEnabled:     true,
+		}),
+	}
+
+	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
+		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	}
+}
+
+func TestParseConfig_ValidConfigLatencyRouting_NoGlobalRoutingConfig_TwoChains_OnlyOneHasRoutingConfig(t *testing.T) {
+	Assert := assert.New(t)
+
+	config := `
+    chains:
+      - chainName: ethereum
+        routing:
+          latency:
+            methods:
+              - method: getLogs
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+
+      - chainName: ethereum
+        routing:
+          latency:
+            methods:
+              - method: getLogs
+                threshold: 210ms
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+
+      - chainName: ethereum
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
+
+	expectedConfig := Config{
+		Global: GlobalConfig{
+			Routing: RoutingConfig{
+				DetectionWindow: NewDuration(DefaultDetectionWindow),
+				BanWindow:       NewDuration(DefaultBanWindow),
+				Latency: &LatencyConfig{
+					MethodLatencyThresholds: map[string]time.Duration{},
+				},
+				Errors:        &ErrorsConfig{Rate: 0.25},
+				AlwaysRoute:   newBool(false),
+				IsInitialized: true,
+				IsEnabled:     true,
+			},
+		},
+		Chains: append(getCommonChainsConfig(&RoutingConfig{
+			DetectionWindow: NewDuration(DefaultDetectionWindow),
+			BanWindow:       NewDuration(DefaultBanWindow),
+			Latency: &LatencyConfig{
+				MethodLatencyThresholds: map[string]time.Duration{
+					"getLogs": 10 * time.Second,
+				},
+				Methods: []MethodConfig{
+					{
+						Name: "getLogs",
+					},
+				},
+			},
+			Errors:        &ErrorsConfig{Rate: 0.25},
+			AlwaysRoute:   newBool(false),
+			IsInitialized: true,
+			IsEnabled:     true,
+		}), append(getCommonChainsConfig(&RoutingConfig{
+			DetectionWindow: NewDuration(DefaultDetectionWindow),
+			BanWindow:       NewDuration(DefaultBanWindow),
+			Latency: &LatencyConfig{
+				MethodLatencyThresholds: map[string]time.Duration{
+					"getLogs": 210 * time.Millisecond,
+				},
+				Methods: []MethodConfig{
+					{
+						Name:      "getLogs",
+						Threshold: 210 * time.Millisecond,
+					},
+				},
+			},
+			Errors:        &ErrorsConfig{Rate: 0.25},
+			AlwaysRoute:   newBool(false),
+			IsInitialized: true,
+			IsEnabled:     true,
+		}), getCommonChainsConfig(&RoutingConfig{})...)...),
+	}
+
+	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
+		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	}
+
+	Assert.True(parsedConfig.Chains[0].Routing.IsEnhancedRoutingControlDefined())
+	Assert.True(parsedConfig.Chains[1].Routing.IsEnhancedRoutingControlDefined())
+	Assert.False(parsedConfig.Chains[2].Routing.IsEnhancedRoutingControlDefined())
+}
+
+func TestParseConfig_InvalidYaml(t *testing.T) {
+	config := `
+    global:
+      port: 8080
+
+		invalid yaml
+
+    upstreams:
+      - id: alchemy-eth
+        httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+        wsURL: "wss://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+      - id: ankr-polygon
+        httpURL: "https://rpc.ankr.com/polygon"
+        wsURL: "wss://rpc.ankr.com/polygon/ws/${ANKR_API_KEY}"
+  `
+	configBytes := []byte(config)
+
+	_, err := parseConfig(configBytes)
+
+	if err == nil {
+		t.Errorf("Expected error parsing invalid YAML.")
+	}
+}
+
+func TestParseConfig_RedisOptions(t *testing.T) {
+	t.Setenv("REDIS_PASSWORD", "secret")
+
+	config := `
+    global:
+      cache:
+        redisOptions:
+          username: gateway
+          passwordEnv: REDIS_PASSWORD
+          tls:
+            serverName: redis.internal
+          sentinel:
+            masterName: mymaster
+            addresses: ["sentinel-1:26379", "sentinel-2:26379"]
+            credentials:
+              password: sentinel-secret
+
+    chains:
+      - chainName: ethereum
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
+
+	cacheConfig := parsedConfig.Global.Cache
+	expectedRedisOptions := &RedisConfig{
+		RedisCredentialsConfig: RedisCredentialsConfig{Username: "gateway", PasswordEnv: "REDIS_PASSWORD"},
+		TLS:                    &RedisTLSConfig{ServerName: "redis.internal"},
+		Sentinel: &RedisSentinelConfig{
+			MasterName:  "mymaster",
+			Addresses:   []string{"sentinel-1:26379", "sentinel-2:26379"},
+			Credentials: RedisCredentialsConfig{Password: "sentinel-secret"},
+		},
+	}
+
+	assert.Equal(t, expectedRedisOptions, cacheConfig.RedisOptions)
+	assert.True(t, cacheConfig.HasRedisTopology())
+
+	password, err := cacheConfig.RedisOptions.GetPassword()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", password)
+}
+
+func TestRedisCredentialsConfig_GetPassword(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	assert.NoError(t, os.WriteFile(passwordFile, []byte("file-secret\n"), 0o600))
+
+	t.Setenv("REDIS_PASSWORD", "env-secret")
+
+	for _, testCase := range []struct {
+		name        string
+		credentials RedisCredentialsConfig
+		expected    string
+		expectErr   bool
+	}{
+		{"Password.", RedisCredentialsConfig{Password: "secret"}, "secret", false},
+		{"Password from environment variable.", RedisCredentialsConfig{PasswordEnv: "REDIS_PASSWORD"}, "env-secret", false},
+		{"Password from file.", RedisCredentialsConfig{PasswordFile: passwordFile}, "file-secret", false},
+		{"Unset environment variable.", RedisCredentialsConfig{PasswordEnv: "REDIS_PASSWORD_UNSET"}, "", true},
+		{"No password.", RedisCredentialsConfig{}, "", false},
 	} {
 		t.Run(testCase.name, func(t *testing.T) {
-			configBytes := []byte(testCase.config)
-			_, err := parseConfig(configBytes)
-			assert.NotNil(t, err)
+			password, err := testCase.credentials.GetPassword()
+
+			assert.Equal(t, testCase.expectErr, err != nil)
+			assert.Equal(t, testCase.expected, password)
+		})
+	}
+}
+
+func TestRedisTLSConfig_GetTLSConfig(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+
+	tlsConfig, err := (*RedisTLSConfig)(nil).GetTLSConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+
+	tlsConfig, err = (&RedisTLSConfig{ServerName: "redis.internal"}).GetTLSConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "redis.internal", tlsConfig.ServerName)
+
+	_, err = (&RedisTLSConfig{CAFile: caFile}).GetTLSConfig()
+	assert.Error(t, err)
+
+	_, err = (&RedisTLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}).GetTLSConfig()
+	assert.Error(t, err)
+
+	_, err = (&RedisTLSConfig{CertFile: filepath.Join(t.TempDir(), "client.crt"), KeyFile: filepath.Join(t.TempDir(), "client.key")}).GetTLSConfig()
+	assert.Error(t, err)
+}
+
+func TestCacheConfig_GetRedisAddresses(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      CacheConfig
+		wantReader  string
+		wantWriter  string
+		description string
+	}{
+		{
+			name: "legacy_config",
+			config: CacheConfig{
+				Redis: "localhost:6379",
+			},
+			wantReader:  "localhost:6379",
+			wantWriter:  "localhost:6379",
+			description: "Should use Redis field for both when only legacy config provided",
+		},
+		{
+			name: "split_config",
+			config: CacheConfig{
+				RedisReader: "reader:6379",
+				RedisWriter: "writer:6379",
+			},
+			wantReader:  "reader:6379",
+			wantWriter:  "writer:6379",
+			description: "Should use separate addresses when both reader and writer specified",
+		},
+		{
+			name: "mixed_config",
+			config: CacheConfig{
+				Redis:       "legacy:6379",
+				RedisReader: "reader:6379",
+				RedisWriter: "writer:6379",
+			},
+			wantReader:  "reader:6379",
+			wantWriter:  "writer:6379",
+			description: "Should prefer new config over legacy when both present",
+		},
+		{
+			name: "partial_config",
+			config: CacheConfig{
+				Redis:       "legacy:6379",
+				RedisReader: "reader:6379",
+			},
+			wantReader:  "legacy:6379",
+			wantWriter:  "legacy:6379",
+			description: "Should fall back to legacy when new config is incomplete",
+		},
+	}
 
-			// To prevent catching formatting errors, that's not what we're checking for in this test.
-			if err != nil {
-				assert.NotContains(t, err.Error(), "found character that cannot start any token", testCase.config)
-				assert.NotContains(t, err.Error(), "found a tab character that violates indentation", testCase.config)
-			}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, writer := tt.config.GetRedisAddresses()
+			assert.Equal(t, tt.wantReader, reader, tt.description)
+			assert.Equal(t, tt.wantWriter, writer, tt.description)
 		})
 	}
 }
 
-func newBool(b bool) *bool {
-	return &b
-}
-
-func TestParseConfig_ValidConfig(t *testing.T) {
+func TestParseConfig_MemoryCache(t *testing.T) {
 	config := `
     global:
-      port: 8080
       cache:
-        redis: localhost:6379
+        memory:
+          maxMemoryMB: 16
 
     chains:
       - chainName: ethereum
-
-        groups:
-          - id: primary
-            priority: 0
-          - id: fallback
-            priority: 1
-
+        cache:
+          ttl: 6s
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            wsURL: "wss://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            healthCheck:
-              useWsForBlockHeight: true
-            group: primary
             nodeType: full
-            methods:
-              enabled: eth_getStorageAt
-              disabled: eth_getBalance,getLogs
-          - id: ankr-polygon
-            httpURL: "https://rpc.ankr.com/polygon"
-            wsURL: "wss://rpc.ankr.com/polygon/ws/${ANKR_API_KEY}"
-            group: fallback
-            nodeType: archive
-            requestHeaders:
-              - key: "x-api-key"
-                value: "xxxx"
-              - key: "client-id"
-                value: "my-client"
-
-      - chainName: polygon
-        upstreams:
-          - id: erigon-polygon-1
-            httpURL: "http://127.0.0.1:4040"
-            nodeType: archive
   `
 	configBytes := []byte(config)
 
@@ -270,139 +1907,50 @@ func TestParseConfig_ValidConfig(t *testing.T) {
 		t.Errorf("ParseConfig returned error: %v.", err)
 	}
 
-	expectedConfig := Config{
-		Global: GlobalConfig{
-			Port: 8080,
-			Cache: CacheConfig{
-				Redis: "localhost:6379",
-			},
-		},
-		Chains: []SingleChainConfig{{
-			Upstreams: []UpstreamConfig{
-				{
-					ID:      "alchemy-eth",
-					HTTPURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}",
-					WSURL:   "wss://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}",
-					HealthCheckConfig: HealthCheckConfig{
-						UseWSForBlockHeight: newBool(true),
-					},
-					GroupID:  "primary",
-					NodeType: Full,
-					Methods: MethodsConfig{
-						Enabled:  map[string]bool{"eth_getStorageAt": true},
-						Disabled: map[string]bool{"eth_getBalance": true, "getLogs": true},
-					},
-				},
-				{
-					ID:      "ankr-polygon",
-					HTTPURL: "https://rpc.ankr.com/polygon",
-					WSURL:   "wss://rpc.ankr.com/polygon/ws/${ANKR_API_KEY}",
-					HealthCheckConfig: HealthCheckConfig{
-						UseWSForBlockHeight: nil,
-					},
-					GroupID:  "fallback",
-					NodeType: Archive,
-					RequestHeadersConfig: []RequestHeaderConfig{
-						{
-							Key:   "x-api-key",
-							Value: "xxxx",
-						},
-						{
-							Key:   "client-id",
-							Value: "my-client",
-						},
-					},
-				},
-			},
-			ChainName: "ethereum",
-			Groups: []GroupConfig{
-				{
-					ID:       "primary",
-					Priority: 0,
-				},
-				{
-					ID:       "fallback",
-					Priority: 1,
-				},
-			},
-		}, {
-			ChainName: "polygon",
-			Upstreams: []UpstreamConfig{{
-				ID:       "erigon-polygon-1",
-				HTTPURL:  "http://127.0.0.1:4040",
-				NodeType: Archive,
-			}},
-		}},
-	}
-
-	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
-		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
-	}
+	assert.Equal(t, &MemoryCacheConfig{MaxEntries: DefaultMemoryCacheMaxEntries, MaxMemoryMB: 16}, parsedConfig.Global.Cache.Memory)
 }
 
-func getCommonChainsConfig(routingConfig *RoutingConfig) []SingleChainConfig {
-	c := RoutingConfig{}
+func TestParseConfig_CacheFinality(t *testing.T) {
+	config := `
+    chains:
+      - chainName: ethereum
+        cache:
+          ttl: 6s
+          finality:
+            confirmations: 64
+            headTTL: 2s
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            nodeType: full
+  `
+	configBytes := []byte(config)
 
-	if routingConfig != nil {
-		c = *routingConfig
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
 	}
 
-	return []SingleChainConfig{{
-		Routing: c,
-		Upstreams: []UpstreamConfig{
-			{
-				ID:       "alchemy-eth",
-				HTTPURL:  "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}",
-				GroupID:  "primary",
-				NodeType: Full,
-			},
-		},
-		ChainName: "ethereum",
-		Groups: []GroupConfig{
-			{
-				ID:       "primary",
-				Priority: 0,
-			},
-		},
-	}}
+	cacheConfig := parsedConfig.Chains[0].Cache
+	assert.Equal(t, &CacheFinalityConfig{Confirmations: 64, HeadTTL: 2 * time.Second}, cacheConfig.Finality)
+	assert.Equal(t, 6*time.Second, cacheConfig.TTL)
+
+	// The local cache must not keep results near the head for longer than the head TTL.
+	assert.Equal(t, 2*time.Second, cacheConfig.GetMinimumTTL())
 }
 
-func TestParseConfig_ValidConfigLatencyRouting_AllFieldsSet(t *testing.T) {
+func TestParseConfig_CacheResolveLatestAndStaleIfError(t *testing.T) {
 	config := `
-    global:
-      routing:
-        maxBlocksBehind: 33
-        detectionWindow: 10m
-        banWindow: 50m
-        errors:
-          rate: 0.25
-          httpCodes:
-            - 5xx
-            - 420
-          jsonRpcCodes:
-            - 32xxx
-          errorStrings:
-            - "internal server error"
-        latency:
-          threshold: 1000ms
-          methods:
-            - method: eth_getLogs
-              threshold: 2000ms
-            - method: eth_call
-              threshold: 10000ms
-        alwaysRoute: true
-
     chains:
       - chainName: ethereum
-
-        groups:
-          - id: primary
-            priority: 0
-
+        cache:
+          ttl: 6s
+          resolveLatest: true
+          staleIfError: 10m
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            group: primary
             nodeType: full
   `
 	configBytes := []byte(config)
@@ -413,104 +1961,57 @@ func TestParseConfig_ValidConfigLatencyRouting_AllFieldsSet(t *testing.T) {
 		t.Errorf("ParseConfig returned error: %v.", err)
 	}
 
-	expectedLatencyConfig := LatencyConfig{
-		MethodLatencyThresholds: map[string]time.Duration{
-			"eth_call":    10000 * time.Millisecond,
-			"eth_getLogs": 2000 * time.Millisecond,
-		},
-		Threshold: 1000 * time.Millisecond,
-		Methods: []MethodConfig{
-			{
-				Name:      "eth_getLogs",
-				Threshold: 2000 * time.Millisecond,
-			},
-			{
-				Name:      "eth_call",
-				Threshold: 10000 * time.Millisecond,
-			},
-		},
-	}
-
-	expectedRoutingConfig := RoutingConfig{
-		MaxBlocksBehind: 33,
-		DetectionWindow: NewDuration(10 * time.Minute),
-		BanWindow:       NewDuration(50 * time.Minute),
-		Errors: &ErrorsConfig{
-			Rate: 0.25,
-			HTTPCodes: []string{
-				"420",
-				"5xx",
-			},
-			JSONRPCCodes: []string{
-				"32xxx",
-			},
-			ErrorStrings: []string{
-				"internal server error",
-			},
-		},
-		Latency:       &expectedLatencyConfig,
-		AlwaysRoute:   newBool(true),
-		IsInitialized: true,
-		IsEnabled:     true,
-	}
+	assert.True(t, parsedConfig.Chains[0].Cache.ResolveLatest)
+	assert.Equal(t, 10*time.Minute, parsedConfig.Chains[0].Cache.StaleIfError)
+}
 
-	expectedRoutingChainConfig := expectedRoutingConfig
-	expectedRoutingChainConfig.MaxBlocksBehind = 0
+func TestParseConfig_CacheStaleWhileRevalidate(t *testing.T) {
+	config := `
+    chains:
+      - chainName: ethereum
+        cache:
+          ttl: 6s
+          methods:
+            - method: eth_gasPrice
+              ttl: 2s
+              staleWhileRevalidate: 10s
+            - method: eth_getBalance
+              ttl: 12s
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            nodeType: full
+  `
+	configBytes := []byte(config)
 
-	expectedConfig := Config{
-		Global: GlobalConfig{
-			Routing: expectedRoutingConfig,
-		},
-		Chains: getCommonChainsConfig(&expectedRoutingChainConfig),
-	}
+	parsedConfig, err := parseConfig(configBytes)
 
-	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
-		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
 	}
+
+	cacheConfig := parsedConfig.Chains[0].Cache
+	assert.Equal(t, map[string]time.Duration{"eth_gasPrice": 10 * time.Second}, cacheConfig.MethodStaleWhileRevalidates)
+	assert.Equal(t, 10*time.Second, cacheConfig.GetStaleWhileRevalidateForMethod("eth_gasPrice"))
+	assert.Equal(t, time.Duration(0), cacheConfig.GetStaleWhileRevalidateForMethod("eth_getBalance"))
 }
 
-func TestParseConfig_ValidConfigLatencyRouting_ErrorsConfigOverridesAndMerges(t *testing.T) {
+func TestParseConfig_CacheNegativeTTL(t *testing.T) {
 	config := `
-    global:
-      routing:
-        errors:
-          rate: 0.28
-          httpCodes:
-            - 5xx
-            - 420
-          jsonRpcCodes:
-            - 32xxx
-            - 28282
-          errorStrings:
-            - "internal server error"
-            - "freaking out"
-
     chains:
       - chainName: ethereum
-
-        groups:
-          - id: primary
-            priority: 0
-
+        cache:
+          ttl: 6s
+          methods:
+            - method: eth_getTransactionReceipt
+              ttl: 6s
+              negativeTTL: 2s
+            - method: eth_getBalance
+              ttl: 12s
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            group: primary
             nodeType: full
-
-        routing:
-          errors:
-            rate: 0.82
-            httpCodes:
-              - 5xx
-              - 388
-              - 4X4
-            jsonRpcCodes:
-              - XXXX1
-              - 28282
-            errorStrings:
-              - "internal server error"
-              - "some weird error"
   `
 	configBytes := []byte(config)
 
@@ -520,72 +2021,161 @@ func TestParseConfig_ValidConfigLatencyRouting_ErrorsConfigOverridesAndMerges(t
 		t.Errorf("ParseConfig returned error: %v.", err)
 	}
 
-	expectedRoutingConfig := RoutingConfig{
-		DetectionWindow: NewDuration(DefaultDetectionWindow),
-		BanWindow:       NewDuration(DefaultBanWindow),
-		Errors: &ErrorsConfig{
-			Rate: 0.28,
-			HTTPCodes: []string{
-				"5xx",
-				"420",
+	cacheConfig := parsedConfig.Chains[0].Cache
+	assert.Equal(t, map[string]time.Duration{"eth_getTransactionReceipt": 2 * time.Second}, cacheConfig.MethodNegativeTTLs)
+	assert.Equal(t, 2*time.Second, cacheConfig.GetNegativeTTLForMethod("eth_getTransactionReceipt"))
+	assert.Equal(t, time.Duration(0), cacheConfig.GetNegativeTTLForMethod("eth_getBalance"))
+	assert.Equal(t, 2*time.Second, cacheConfig.GetMinimumTTL())
+}
+
+func TestChainCacheConfig_GetTTLForMethod(t *testing.T) {
+	tests := []struct {
+		config         ChainCacheConfig
+		name           string
+		method         string
+		description    string
+		expectedTTL    time.Duration
+		expectedMinTTL time.Duration
+	}{
+		{
+			name: "default_ttl_only",
+			config: ChainCacheConfig{
+				TTL:        5 * time.Minute,
+				MethodTTLs: map[string]time.Duration{},
 			},
-			JSONRPCCodes: []string{
-				"32xxx",
-				"28282",
+			method:         "eth_getBalance",
+			expectedTTL:    5 * time.Minute,
+			expectedMinTTL: 5 * time.Minute,
+			description:    "Should return default TTL when no method-specific TTL exists",
+		},
+		{
+			name: "method_ttl_exists",
+			config: ChainCacheConfig{
+				TTL: 5 * time.Minute,
+				MethodTTLs: map[string]time.Duration{
+					"eth_getBalance": 10 * time.Minute,
+				},
 			},
-			ErrorStrings: []string{
-				"internal server error",
-				"freaking out",
+			method:         "eth_getBalance",
+			expectedTTL:    10 * time.Minute,
+			expectedMinTTL: 5 * time.Minute,
+			description:    "Should return method-specific TTL when it exists",
+		},
+		{
+			name: "method_ttl_exists_query_non_existent_method",
+			config: ChainCacheConfig{
+				TTL: 5 * time.Minute,
+				MethodTTLs: map[string]time.Duration{
+					"eth_getBalance": 10 * time.Minute,
+				},
 			},
+			method:         "eth_getLogs",
+			expectedTTL:    5 * time.Minute,
+			expectedMinTTL: 5 * time.Minute,
+			description:    "Should return default TTL when method-specific TTL doesn't exists",
 		},
-		Latency:       &LatencyConfig{MethodLatencyThresholds: map[string]time.Duration{}},
-		AlwaysRoute:   newBool(false),
-		IsInitialized: true,
-		IsEnabled:     true,
-	}
-
-	expectedRoutingChainConfig := expectedRoutingConfig
-	expectedRoutingChainConfig.MaxBlocksBehind = 0
-	expectedRoutingChainConfig.Errors = &ErrorsConfig{
-		Rate: 0.82,
-		HTTPCodes: []string{
-			"388",
-			"420",
-			"4X4",
-			"5xx",
+		{
+			name: "method_ttl_exists_but_not_default_query_non_existent_method",
+			config: ChainCacheConfig{
+				MethodTTLs: map[string]time.Duration{
+					"eth_getBalance": 10 * time.Minute,
+				},
+			},
+			method:         "eth_getLogs",
+			expectedTTL:    0,
+			expectedMinTTL: 10 * time.Minute,
+			description:    "Should return default TTL (which is 0) when method-specific AND default TTL doesn't exist",
 		},
-		JSONRPCCodes: []string{
-			"28282",
-			"32xxx",
-			"XXXX1",
+		{
+			name: "multiple_method_ttls_with_smaller_value",
+			config: ChainCacheConfig{
+				TTL: 5 * time.Minute,
+				MethodTTLs: map[string]time.Duration{
+					"eth_getBalance": 10 * time.Minute,
+					"eth_getLogs":    3 * time.Minute,
+				},
+			},
+			method:         "eth_getBalance",
+			expectedTTL:    10 * time.Minute,
+			expectedMinTTL: 3 * time.Minute,
+			description:    "Should return method-specific TTL and minimum TTL should be the smallest value",
 		},
-		ErrorStrings: []string{
-			"freaking out",
-			"internal server error",
-			"some weird error",
+		{
+			name: "all_zero_values",
+			config: ChainCacheConfig{
+				TTL:        0,
+				MethodTTLs: map[string]time.Duration{},
+			},
+			method:         "eth_getBalance",
+			expectedTTL:    0,
+			expectedMinTTL: 0,
+			description:    "Should return 0 for both TTL and minimum TTL when all values are zero",
 		},
 	}
 
-	expectedConfig := Config{
-		Global: GlobalConfig{
-			Routing: expectedRoutingConfig,
-		},
-		Chains: getCommonChainsConfig(&expectedRoutingChainConfig),
-	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl := tt.config.GetTTLForMethod(tt.method)
+			assert.Equal(t, tt.expectedTTL, ttl, tt.description)
 
-	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
-		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+			minTTL := tt.config.GetMinimumTTL()
+			assert.Equal(t, tt.expectedMinTTL, minTTL, "Minimum TTL should match expected value")
+		})
 	}
 }
 
-func TestParseConfig_ValidConfigLatencyRouting_DefaultsForDetectionAndBanWindows_Set(t *testing.T) {
+func TestParseConfig_ChainCacheConfig(t *testing.T) {
+	config := `
+    global:
+      port: 8080
+
+    chains:
+      - chainName: ethereum
+        cache:
+          ttl: 5m
+          methods:
+            - method: eth_getBalance
+              ttl: 10m
+            - method: eth_getBlockByNumber
+              ttl: 30s
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+	assert.NoError(t, err)
+
+	chainConfig := parsedConfig.Chains[0]
+
+	// Verify default TTL
+	assert.Equal(t, 5*time.Minute, chainConfig.Cache.TTL)
+
+	// Verify method-specific TTLs
+	assert.Equal(t, 10*time.Minute, chainConfig.Cache.MethodTTLs["eth_getBalance"])
+	assert.Equal(t, 30*time.Second, chainConfig.Cache.MethodTTLs["eth_getBlockByNumber"])
+
+	// Test GetTTLForMethod
+	assert.Equal(t, 10*time.Minute, chainConfig.Cache.GetTTLForMethod("eth_getBalance"))
+	assert.Equal(t, 30*time.Second, chainConfig.Cache.GetTTLForMethod("eth_getBlockByNumber"))
+	assert.Equal(t, 5*time.Minute, chainConfig.Cache.GetTTLForMethod("eth_call")) // Not specified, should return default
+}
+
+func TestParseConfig_ValidConfigLatencyRouting_Probes(t *testing.T) {
 	config := `
     global:
       routing:
-        errors:
-          rate: 0.25
         latency:
           threshold: 1000ms
+          probes:
+            interval: 1m
+            methods:
+              - method: eth_getBalance
+                params: ["0x0000000000000000000000000000000000000000", "latest"]
+              - method: eth_chainId
+                interval: 5m
 
     chains:
       - chainName: ethereum
@@ -606,45 +2196,34 @@ func TestParseConfig_ValidConfigLatencyRouting_DefaultsForDetectionAndBanWindows
 		t.Errorf("ParseConfig returned error: %v.", err)
 	}
 
-	expectedLatencyConfig := LatencyConfig{
-		MethodLatencyThresholds: map[string]time.Duration{},
-		Threshold:               1000 * time.Millisecond,
-	}
-
-	expectedConfig := Config{
-		Global: GlobalConfig{
-			Routing: RoutingConfig{
-				DetectionWindow: NewDuration(DefaultDetectionWindow),
-				BanWindow:       NewDuration(DefaultBanWindow),
-				Errors: &ErrorsConfig{
-					Rate: 0.25,
-				},
-				Latency:       &expectedLatencyConfig,
-				AlwaysRoute:   newBool(false),
-				IsInitialized: true,
-				IsEnabled:     true,
+	expectedProbesConfig := &LatencyProbesConfig{
+		Interval: time.Minute,
+		Methods: []LatencyProbeMethodConfig{
+			{
+				Name:     "eth_getBalance",
+				Params:   []any{"0x0000000000000000000000000000000000000000", "latest"},
+				Interval: time.Minute,
+			},
+			{
+				Name:     "eth_chainId",
+				Interval: 5 * time.Minute,
 			},
 		},
-		Chains: getCommonChainsConfig(&RoutingConfig{
-			DetectionWindow: NewDuration(DefaultDetectionWindow),
-			BanWindow:       NewDuration(DefaultBanWindow),
-			Errors:          &ErrorsConfig{Rate: DefaultErrorRate},
-			Latency:         &expectedLatencyConfig,
-			AlwaysRoute:     newBool(false),
-			IsInitialized:   true,
-			IsEnabled:       true,
-		}),
 	}
 
-	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
-		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
-	}
+	// The chain inherits the global probes.
+	assert.Equal(t, expectedProbesConfig, parsedConfig.Global.Routing.Latency.Probes)
+	assert.Equal(t, expectedProbesConfig, parsedConfig.Chains[0].Routing.Latency.Probes)
 }
 
-func TestParseConfig_ValidConfigLatencyRouting_DefaultsForDetectionAndBanWindows_NotSet(t *testing.T) {
+func TestParseConfig_ValidConfigLatencyRouting_ProbesDefaults(t *testing.T) {
 	config := `
     chains:
       - chainName: ethereum
+        routing:
+          latency:
+            threshold: 1000ms
+            probes: {}
         groups:
           - id: primary
             priority: 0
@@ -662,94 +2241,135 @@ func TestParseConfig_ValidConfigLatencyRouting_DefaultsForDetectionAndBanWindows
 		t.Errorf("ParseConfig returned error: %v.", err)
 	}
 
-	expectedConfig := Config{
-		Chains: getCommonChainsConfig(nil),
+	expectedProbesConfig := &LatencyProbesConfig{
+		Interval: DefaultLatencyProbeInterval,
+		Methods: []LatencyProbeMethodConfig{
+			{Name: DefaultLatencyProbeMethod, Interval: DefaultLatencyProbeInterval},
+		},
 	}
 
-	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
-		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
-	}
+	assert.Equal(t, expectedProbesConfig, parsedConfig.Chains[0].Routing.Latency.Probes)
 }
 
-func TestParseConfig_InvalidConfigLatencyRouting_InvalidRateInChainConfig(t *testing.T) {
+func TestParseConfig_HealthCheckPolicy(t *testing.T) {
 	config := `
+    global:
+      healthCheck:
+        interval: 10s
+        timeout: 20s
+        minPeerCount: 5
+
     chains:
       - chainName: ethereum
-        routing:
-          errors:
-            rate: 1.25
-        groups:
-          - id: primary
-            priority: 0
+        healthCheck:
+          interval: 1s
+          jitter: 200ms
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            group: primary
+            nodeType: archive
+            healthCheck:
+              timeout: 30s
+              maxBlocksBehind: 2
+          - id: infura-eth
+            httpURL: "https://mainnet.infura.io/v3/${INFURA_API_KEY}"
             nodeType: full
   `
 	configBytes := []byte(config)
 
-	_, err := parseConfig(configBytes)
+	parsedConfig, err := parseConfig(configBytes)
 
-	if err == nil {
-		t.Errorf("Expected error parsing invalid YAML.")
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
 	}
+
+	// Values set on the upstream take precedence over the chain, which takes precedence over global.
+	archiveHealthCheckConfig := parsedConfig.Chains[0].Upstreams[0].HealthCheckConfig
+	assert.Equal(t, time.Second, archiveHealthCheckConfig.GetInterval())
+	assert.Equal(t, 30*time.Second, archiveHealthCheckConfig.GetTimeout())
+	assert.Equal(t, 200*time.Millisecond, archiveHealthCheckConfig.GetJitter())
+	assert.Equal(t, uint64(5), archiveHealthCheckConfig.GetMinPeerCount())
+	assert.Equal(t, uint64(2), *archiveHealthCheckConfig.MaxBlocksBehind)
+
+	fullHealthCheckConfig := parsedConfig.Chains[0].Upstreams[1].HealthCheckConfig
+	assert.Equal(t, time.Second, fullHealthCheckConfig.GetInterval())
+	assert.Equal(t, 20*time.Second, fullHealthCheckConfig.GetTimeout())
+	assert.Equal(t, 200*time.Millisecond, fullHealthCheckConfig.GetJitter())
+	assert.Equal(t, uint64(5), fullHealthCheckConfig.GetMinPeerCount())
+	assert.Nil(t, fullHealthCheckConfig.MaxBlocksBehind)
 }
 
-func TestParseConfig_InvalidConfigLatencyRouting_InvalidRateInGlobalConfig(t *testing.T) {
+func TestHealthCheckConfig_Defaults(t *testing.T) {
+	healthCheckConfig := HealthCheckConfig{}
+
+	assert.Equal(t, DefaultHealthCheckInterval, healthCheckConfig.GetInterval())
+	assert.Equal(t, DefaultHealthCheckTimeout, healthCheckConfig.GetTimeout())
+	assert.Equal(t, time.Duration(0), healthCheckConfig.GetJitter())
+	assert.Equal(t, DefaultMinPeerCount, healthCheckConfig.GetMinPeerCount())
+}
+
+func TestParseConfig_ValidConfigLatencyRouting_Recovery(t *testing.T) {
 	config := `
     global:
       routing:
-        errors:
-          rate: -0.25
+        recovery:
+          halfOpenTrafficShare: 0.2
+          slowStartWindow: 2m
 
     chains:
       - chainName: ethereum
-        groups:
-          - id: primary
-            priority: 0
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            group: primary
+            nodeType: full
+      - chainName: optimism
+        routing:
+          recovery:
+            halfOpenSuccesses: 10
+        upstreams:
+          - id: alchemy-optimism
+            httpURL: "https://opt-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
             nodeType: full
   `
 	configBytes := []byte(config)
 
-	_, err := parseConfig(configBytes)
+	parsedConfig, err := parseConfig(configBytes)
 
-	if err == nil {
-		t.Errorf("Expected error parsing invalid YAML.")
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
 	}
+
+	assert.Equal(t, &RecoveryConfig{
+		HalfOpenTrafficShare: 0.2,
+		HalfOpenSuccesses:    DefaultHalfOpenSuccesses,
+		SlowStartWindow:      2 * time.Minute,
+	}, parsedConfig.Chains[0].Routing.Recovery)
+
+	// A chain's recovery config replaces the global one rather than being merged with it.
+	assert.Equal(t, &RecoveryConfig{
+		HalfOpenTrafficShare: DefaultHalfOpenTrafficShare,
+		HalfOpenSuccesses:    10,
+		SlowStartWindow:      DefaultSlowStartWindow,
+	}, parsedConfig.Chains[1].Routing.Recovery)
 }
 
-func TestParseConfig_ValidConfigLatencyRouting_MethodLatencies_TopLevelLatencySpecifiedBothPerChainAndGlobal(t *testing.T) {
+func TestParseConfig_ValidConfigLatencyRouting_Aggregation(t *testing.T) {
 	config := `
     global:
       routing:
         latency:
-          threshold: 1000ms
-          methods:
-            - method: getLogs
-              threshold: 2000ms
-            - method: eth_getStorageAt
+          aggregation: p90
+          minSamples: 20
 
     chains:
       - chainName: ethereum
         routing:
           latency:
-            threshold: 4000ms
-            methods:
-              - method: getLogs
-              - method: eth_getStorageAt
-                threshold: 6000ms
-        groups:
-          - id: primary
-            priority: 0
+            threshold: 2s
+            tooHighRate: 0.8
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            group: primary
             nodeType: full
   `
 	configBytes := []byte(config)
@@ -760,84 +2380,29 @@ func TestParseConfig_ValidConfigLatencyRouting_MethodLatencies_TopLevelLatencySp
 		t.Errorf("ParseConfig returned error: %v.", err)
 	}
 
-	expectedConfig := Config{
-		Global: GlobalConfig{
-			Routing: RoutingConfig{
-				DetectionWindow: NewDuration(DefaultDetectionWindow),
-				BanWindow:       NewDuration(DefaultBanWindow),
-				Latency: &LatencyConfig{
-					MethodLatencyThresholds: map[string]time.Duration{
-						"getLogs":          2000 * time.Millisecond,
-						"eth_getStorageAt": 1000 * time.Millisecond, // Top-level latency default
-					},
-					Threshold: 1000 * time.Millisecond,
-					Methods: []MethodConfig{
-						{
-							Name:      "getLogs",
-							Threshold: 2000 * time.Millisecond,
-						},
-						{
-							Name: "eth_getStorageAt",
-						},
-					},
-				},
-				Errors:        &ErrorsConfig{Rate: DefaultErrorRate},
-				AlwaysRoute:   newBool(false),
-				IsInitialized: true,
-				IsEnabled:     true,
-			},
-		},
-
-		Chains: getCommonChainsConfig(&RoutingConfig{
-			DetectionWindow: NewDuration(DefaultDetectionWindow),
-			BanWindow:       NewDuration(DefaultBanWindow),
-			Latency: &LatencyConfig{
-				MethodLatencyThresholds: map[string]time.Duration{
-					"getLogs":          4000 * time.Millisecond, // Top-level latency default
-					"eth_getStorageAt": 6000 * time.Millisecond,
-				},
-				Threshold: 4000 * time.Millisecond,
-				Methods: []MethodConfig{
-					{
-						Name: "getLogs",
-					},
-					{
-						Name:      "eth_getStorageAt",
-						Threshold: 6000 * time.Millisecond,
-					},
-				},
-			},
-			Errors:        &ErrorsConfig{Rate: DefaultErrorRate},
-			AlwaysRoute:   newBool(false),
-			IsInitialized: true,
-			IsEnabled:     true,
-		}),
-	}
-
-	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
-		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
-	}
+	// Aggregation settings the chain does not set are inherited from the global config.
+	latencyConfig := parsedConfig.Chains[0].Routing.Latency
+	assert.Equal(t, P90Aggregation, latencyConfig.Aggregation)
+	assert.Equal(t, 0.8, latencyConfig.TooHighRate)
+	assert.Equal(t, uint(20), latencyConfig.MinSamples)
 }
 
-func TestParseConfig_ValidConfigLatencyRouting_MethodLatencies_TopLevelLatencyNotSpecifiedGlobalConfig(t *testing.T) {
+func TestParseConfig_ValidConfigLatencyRouting_Adaptive(t *testing.T) {
 	config := `
     global:
       routing:
         latency:
-          methods:
-            - method: getLogs
-              threshold: 2000ms
-            - method: eth_getStorageAt
+          adaptive:
+            multiplier: 4
 
     chains:
       - chainName: ethereum
-        groups:
-          - id: primary
-            priority: 0
+        routing:
+          latency:
+            threshold: 2s
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            group: primary
             nodeType: full
   `
 	configBytes := []byte(config)
@@ -848,78 +2413,34 @@ func TestParseConfig_ValidConfigLatencyRouting_MethodLatencies_TopLevelLatencyNo
 		t.Errorf("ParseConfig returned error: %v.", err)
 	}
 
-	expectedLatencyConfig := LatencyConfig{
-		MethodLatencyThresholds: map[string]time.Duration{
-			"getLogs":          2000 * time.Millisecond,
-			"eth_getStorageAt": DefaultMaxLatency, // Global default
-		},
-		Methods: []MethodConfig{
-			{
-				Name:      "getLogs",
-				Threshold: 2000 * time.Millisecond,
-			},
-			{
-				Name: "eth_getStorageAt",
-			},
-		},
-		Threshold: DefaultMaxLatency,
-	}
-
-	expectedConfig := Config{
-		Global: GlobalConfig{
-			Routing: RoutingConfig{
-				DetectionWindow: NewDuration(DefaultDetectionWindow),
-				BanWindow:       NewDuration(DefaultBanWindow),
-				Latency:         &expectedLatencyConfig,
-				Errors:          &ErrorsConfig{Rate: DefaultErrorRate},
-				AlwaysRoute:     newBool(false),
-				IsInitialized:   true,
-				IsEnabled:       true,
-			},
-		},
-
-		Chains: getCommonChainsConfig(&RoutingConfig{
-			DetectionWindow: NewDuration(DefaultDetectionWindow),
-			BanWindow:       NewDuration(DefaultBanWindow),
-			Latency:         &expectedLatencyConfig,
-			Errors:          &ErrorsConfig{Rate: DefaultErrorRate},
-			AlwaysRoute:     newBool(false),
-			IsInitialized:   true,
-			IsEnabled:       true,
-		}),
-	}
-
-	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
-		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
-	}
+	assert.Equal(t, &AdaptiveLatencyConfig{
+		Multiplier: 4,
+		Window:     DefaultAdaptiveLatencyWindow,
+		MinSamples: DefaultAdaptiveLatencyMinSamples,
+	}, parsedConfig.Chains[0].Routing.Latency.Adaptive)
 }
 
-func TestParseConfig_ValidConfigLatencyRouting_MethodLatencies_TopLevelLatencySpecifiedInGlobalConfigOnly(t *testing.T) {
+func TestParseConfig_ValidConfigLatencyRouting_ErrorMethods(t *testing.T) {
 	config := `
     global:
       routing:
-        latency:
-          threshold: 1000ms
+        errors:
+          rate: 0.4
           methods:
-            - method: getLogs
-              threshold: 2000ms
-            - method: eth_getStorageAt
+            - method: "debug_*"
+            - method: eth_getLogs
+              rate: 0.8
 
     chains:
       - chainName: ethereum
         routing:
-          latency:
+          errors:
             methods:
-              - method: getLogs
-              - method: eth_getStorageAt
-                threshold: 6000ms
-        groups:
-          - id: primary
-            priority: 0
+              - method: eth_getLogs
+                rate: 0.6
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            group: primary
             nodeType: full
   `
 	configBytes := []byte(config)
@@ -930,104 +2451,62 @@ func TestParseConfig_ValidConfigLatencyRouting_MethodLatencies_TopLevelLatencySp
 		t.Errorf("ParseConfig returned error: %v.", err)
 	}
 
-	expectedConfig := Config{
-		Global: GlobalConfig{
-			Routing: RoutingConfig{
-				DetectionWindow: NewDuration(DefaultDetectionWindow),
-				BanWindow:       NewDuration(DefaultBanWindow),
-				Latency: &LatencyConfig{
-					MethodLatencyThresholds: map[string]time.Duration{
-						"getLogs":          2000 * time.Millisecond,
-						"eth_getStorageAt": 1000 * time.Millisecond, // Top-level latency default
-					},
-					Threshold: 1000 * time.Millisecond,
-					Methods: []MethodConfig{
-						{
-							Name:      "getLogs",
-							Threshold: 2000 * time.Millisecond,
-						},
-						{
-							Name: "eth_getStorageAt",
-						},
-					},
-				},
-				Errors:        &ErrorsConfig{Rate: DefaultErrorRate},
-				AlwaysRoute:   newBool(false),
-				IsInitialized: true,
-				IsEnabled:     true,
-			},
-		},
+	// The chain's config for a method takes precedence over the global one, and rates default to the errors rate.
+	errorsConfig := parsedConfig.Chains[0].Routing.Errors
+	assert.Equal(t, []ErrorMethodConfig{
+		{Name: "eth_getLogs", Rate: 0.6},
+		{Name: "debug_*", Rate: 0.4},
+	}, errorsConfig.Methods)
 
-		Chains: getCommonChainsConfig(&RoutingConfig{
-			DetectionWindow: NewDuration(DefaultDetectionWindow),
-			BanWindow:       NewDuration(DefaultBanWindow),
-			Latency: &LatencyConfig{
-				MethodLatencyThresholds: map[string]time.Duration{
-					"getLogs":          2000 * time.Millisecond, // Top-level latency for method
-					"eth_getStorageAt": 6000 * time.Millisecond,
-				},
-				Methods: []MethodConfig{
-					{
-						Name: "getLogs",
-					},
-					{
-						Name:      "eth_getStorageAt",
-						Threshold: 6000 * time.Millisecond,
-					},
-				},
-			},
-			Errors:        &ErrorsConfig{Rate: DefaultErrorRate},
-			AlwaysRoute:   newBool(false),
-			IsInitialized: true,
-			IsEnabled:     true,
-		}),
-	}
+	assert.Equal(t, "debug_*", errorsConfig.GetMethodConfig("debug_traceTransaction").Name)
+	assert.Equal(t, 0.6, errorsConfig.GetMethodConfig("eth_getLogs").Rate)
+	assert.Nil(t, errorsConfig.GetMethodConfig("eth_call"))
+}
+
+func TestParseConfig_ValidConfig_ClientRequirements(t *testing.T) {
+	config := `
+    chains:
+      - chainName: ethereum
+        routing:
+          clientRequirements:
+            - methods: ["trace_*"]
+              clients:
+                - name: erigon
+                  minVersion: 2.59.0
+                - name: reth
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
 
-	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
-		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
 	}
+
+	clientRequirements := parsedConfig.Chains[0].Routing.ClientRequirements
+	assert.Equal(t, []ClientRequirementConfig{{
+		Methods: []string{"trace_*"},
+		Clients: []ClientConstraintConfig{{Name: "erigon", MinVersion: "2.59.0"}, {Name: "reth"}},
+	}}, clientRequirements)
+
+	assert.True(t, clientRequirements[0].IsInScope([]string{"eth_blockNumber", "trace_block"}))
+	assert.False(t, clientRequirements[0].IsInScope([]string{"eth_getLogs"}))
 }
 
-func TestParseConfig_ValidConfigLatencyRouting_MethodLatencies_TopLevelLatencyNotSpecifiedNeitherGlobalNotChain(t *testing.T) {
+func TestParseConfig_ValidConfig_Coalescing(t *testing.T) {
 	config := `
-    global:
-      routing:
-        latency:
-          methods:
-            - method: getLogs
-              threshold: 2000ms
-            - method: eth_getStorageAt
-            - method: eth_chainId
-              threshold: 20ms
-            - method: eth_yetAnotherMethod
-              threshold: 218ms
-        errors:
-          rate: 0.88
-          httpCodes:
-            - 5xx
-            - 420
-          jsonRpcCodes:
-            - 32xxx
-          errorStrings:
-            - "internal server error"
-
     chains:
       - chainName: ethereum
         routing:
-          latency:
-            methods:
-              - method: getLogs
-              - method: eth_getStorageAt
-                threshold: 6000ms
-              - method: eth_doesSomethingImportant
-              - method: eth_yetAnotherMethod
-        groups:
-          - id: primary
-            priority: 0
+          coalescing:
+            methods: [eth_call, "eth_get*"]
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            group: primary
             nodeType: full
   `
 	configBytes := []byte(config)
@@ -1038,139 +2517,106 @@ func TestParseConfig_ValidConfigLatencyRouting_MethodLatencies_TopLevelLatencyNo
 		t.Errorf("ParseConfig returned error: %v.", err)
 	}
 
-	expectedErrorsConfig := ErrorsConfig{
-		Rate: 0.88,
-		HTTPCodes: []string{
-			"420",
-			"5xx",
-		},
-		JSONRPCCodes: []string{
-			"32xxx",
-		},
-		ErrorStrings: []string{
-			"internal server error",
-		},
-	}
-
-	expectedConfig := Config{
-		Global: GlobalConfig{
-			Routing: RoutingConfig{
-				DetectionWindow: NewDuration(DefaultDetectionWindow),
-				BanWindow:       NewDuration(DefaultBanWindow),
-				Latency: &LatencyConfig{
-					MethodLatencyThresholds: map[string]time.Duration{
-						"getLogs":              2000 * time.Millisecond,
-						"eth_getStorageAt":     DefaultMaxLatency, // Top-level latency default
-						"eth_chainId":          20 * time.Millisecond,
-						"eth_yetAnotherMethod": 218 * time.Millisecond,
-					},
-					Methods: []MethodConfig{
-						{
-							Name:      "getLogs",
-							Threshold: 2000 * time.Millisecond,
-						},
-						{
-							Name: "eth_getStorageAt",
-						},
-						{
-							Name:      "eth_chainId",
-							Threshold: 20 * time.Millisecond,
-						},
-						{
-							Name:      "eth_yetAnotherMethod",
-							Threshold: 218 * time.Millisecond,
-						},
-					},
-					Threshold: DefaultMaxLatency,
-				},
-				Errors:        &expectedErrorsConfig,
-				AlwaysRoute:   newBool(false),
-				IsInitialized: true,
-				IsEnabled:     true,
-			},
-		},
-
-		Chains: getCommonChainsConfig(&RoutingConfig{
-			DetectionWindow: NewDuration(DefaultDetectionWindow),
-			BanWindow:       NewDuration(DefaultBanWindow),
-			Latency: &LatencyConfig{
-				MethodLatencyThresholds: map[string]time.Duration{
-					"getLogs":                    2000 * time.Millisecond, // Top-level latency for method
-					"eth_getStorageAt":           6000 * time.Millisecond,
-					"eth_doesSomethingImportant": DefaultMaxLatency,
-					"eth_chainId":                20 * time.Millisecond, // Inherited from global latency config
-					"eth_yetAnotherMethod":       218 * time.Millisecond,
-				},
-				Methods: []MethodConfig{
-					{
-						Name: "getLogs",
-					},
-					{
-						Name:      "eth_getStorageAt",
-						Threshold: 6000 * time.Millisecond,
-					},
-					{
-						Name: "eth_doesSomethingImportant",
-					},
-					{
-						Name: "eth_yetAnotherMethod",
-					},
-				},
-			},
-			Errors:        &expectedErrorsConfig,
-			AlwaysRoute:   newBool(false),
-			IsInitialized: true,
-			IsEnabled:     true,
-		}),
-	}
+	coalescing := parsedConfig.Chains[0].Routing.Coalescing
+	assert.Equal(t, &CoalescingConfig{Methods: []string{"eth_call", "eth_get*"}}, coalescing)
+	assert.Equal(t, DefaultCoalescingTimeout, coalescing.GetTimeout())
 
-	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
-		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
-	}
+	assert.True(t, coalescing.IsEligible("eth_getBalance"))
+	assert.True(t, coalescing.IsEligible("eth_call"))
+	assert.False(t, coalescing.IsEligible("eth_sendRawTransaction"))
 }
 
-func TestParseConfig_ValidConfigLatencyRouting_NoGlobalRoutingConfig_TwoChains_OnlyOneHasRoutingConfig(t *testing.T) {
-	Assert := assert.New(t)
-
+func TestParseConfig_ValidConfig_GlobalCoalescingAndClientRequirements(t *testing.T) {
 	config := `
+    global:
+      routing:
+        coalescing:
+          methods: [eth_call]
+        clientRequirements:
+          - methods: ["trace_*"]
+            clients:
+              - name: erigon
     chains:
       - chainName: ethereum
-        routing:
-          latency:
-            methods:
-              - method: getLogs
-        groups:
-          - id: primary
-            priority: 0
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            group: primary
             nodeType: full
+      - chainName: polygon
+        routing:
+          coalescing:
+            methods: ["eth_get*"]
+          clientRequirements: []
+        upstreams:
+          - id: alchemy-polygon
+            httpURL: "https://polygon-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
 
+	// Chains without their own config inherit the global one.
+	ethereumRouting := parsedConfig.Chains[0].Routing
+	assert.Equal(t, &CoalescingConfig{Methods: []string{"eth_call"}}, ethereumRouting.Coalescing)
+	assert.Equal(t, []ClientRequirementConfig{{Methods: []string{"trace_*"}, Clients: []ClientConstraintConfig{{Name: "erigon"}}}}, ethereumRouting.ClientRequirements)
+
+	// Chains with their own config, even an empty one, override it.
+	polygonRouting := parsedConfig.Chains[1].Routing
+	assert.Equal(t, &CoalescingConfig{Methods: []string{"eth_get*"}}, polygonRouting.Coalescing)
+	assert.Empty(t, polygonRouting.ClientRequirements)
+}
+
+func TestParseConfig_ValidConfigLatencyRouting_OutlierDetection(t *testing.T) {
+	config := `
+    global:
+      routing:
+        outlierDetection:
+          latencyFactor: 3
+          maxEjectionPercent: 20
+
+    chains:
       - chainName: ethereum
-        routing:
-          latency:
-            methods:
-              - method: getLogs
-                threshold: 210ms
-        groups:
-          - id: primary
-            priority: 0
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            group: primary
             nodeType: full
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
+
+	assert.Equal(t, &OutlierDetectionConfig{
+		Interval:           DefaultOutlierInterval,
+		EjectionDuration:   DefaultOutlierEjectionDuration,
+		MinRequests:        DefaultOutlierMinRequests,
+		ErrorRateFactor:    DefaultOutlierErrorRateFactor,
+		MinErrorRateDelta:  DefaultOutlierMinErrorRateDelta,
+		LatencyFactor:      3,
+		LatencyPercentile:  DefaultOutlierLatencyPercentile,
+		MaxEjectionPercent: 20,
+	}, parsedConfig.Chains[0].Routing.OutlierDetection)
+	assert.True(t, parsedConfig.Chains[0].Routing.IsEnabled)
+}
 
+func TestParseConfig_ValidConfig_HeadFreshness(t *testing.T) {
+	config := `
+    chains:
       - chainName: ethereum
-        groups:
-          - id: primary
-            priority: 0
+        routing:
+          maxHeadAge: 1m
+          maxHeadArrivalDelay: 200ms
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            group: primary
             nodeType: full
   `
 	configBytes := []byte(config)
@@ -1181,81 +2627,48 @@ func TestParseConfig_ValidConfigLatencyRouting_NoGlobalRoutingConfig_TwoChains_O
 		t.Errorf("ParseConfig returned error: %v.", err)
 	}
 
-	expectedConfig := Config{
-		Global: GlobalConfig{
-			Routing: RoutingConfig{
-				DetectionWindow: NewDuration(DefaultDetectionWindow),
-				BanWindow:       NewDuration(DefaultBanWindow),
-				Latency: &LatencyConfig{
-					MethodLatencyThresholds: map[string]time.Duration{},
-				},
-				Errors:        &ErrorsConfig{Rate: 0.25},
-				AlwaysRoute:   newBool(false),
-				IsInitialized: true,
-				IsEnabled:     true,
-			},
-		},
-		Chains: append(getCommonChainsConfig(&RoutingConfig{
-			DetectionWindow: NewDuration(DefaultDetectionWindow),
-			BanWindow:       NewDuration(DefaultBanWindow),
-			Latency: &LatencyConfig{
-				MethodLatencyThresholds: map[string]time.Duration{
-					"getLogs": 10 * time.Second,
-				},
-				Methods: []MethodConfig{
-					{
-						Name: "getLogs",
-					},
-				},
-			},
-			Errors:        &ErrorsConfig{Rate: 0.25},
-			AlwaysRoute:   newBool(false),
-			IsInitialized: true,
-			IsEnabled:     true,
-		}), append(getCommonChainsConfig(&RoutingConfig{
-			DetectionWindow: NewDuration(DefaultDetectionWindow),
-			BanWindow:       NewDuration(DefaultBanWindow),
-			Latency: &LatencyConfig{
-				MethodLatencyThresholds: map[string]time.Duration{
-					"getLogs": 210 * time.Millisecond,
-				},
-				Methods: []MethodConfig{
-					{
-						Name:      "getLogs",
-						Threshold: 210 * time.Millisecond,
-					},
-				},
-			},
-			Errors:        &ErrorsConfig{Rate: 0.25},
-			AlwaysRoute:   newBool(false),
-			IsInitialized: true,
-			IsEnabled:     true,
-		}), getCommonChainsConfig(&RoutingConfig{})...)...),
-	}
+	assert.Equal(t, time.Minute, parsedConfig.Chains[0].Routing.MaxHeadAge)
+	assert.Equal(t, 200*time.Millisecond, parsedConfig.Chains[0].Routing.MaxHeadArrivalDelay)
 
-	if diff := cmp.Diff(expectedConfig, parsedConfig); diff != "" {
-		t.Errorf("ParseConfig returned unexpected config - diff:\n%s", diff)
+	// maxHeadAge does not turn on enhanced routing control.
+	assert.False(t, parsedConfig.Chains[0].Routing.IsEnabled)
+}
+
+func TestParseConfig_ValidConfig_Finality(t *testing.T) {
+	config := `
+    chains:
+      - chainName: ethereum
+        routing:
+          maxFinalityBlocksBehind: 2
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            nodeType: full
+            healthCheck:
+              checkFinality: true
+  `
+	configBytes := []byte(config)
+
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
 	}
 
-	Assert.True(parsedConfig.Chains[0].Routing.IsEnhancedRoutingControlDefined())
-	Assert.True(parsedConfig.Chains[1].Routing.IsEnhancedRoutingControlDefined())
-	Assert.False(parsedConfig.Chains[2].Routing.IsEnhancedRoutingControlDefined())
+	assert.Equal(t, uint64(2), parsedConfig.Chains[0].Routing.MaxFinalityBlocksBehind)
+	assert.True(t, *parsedConfig.Chains[0].Upstreams[0].HealthCheckConfig.CheckFinality)
 }
 
-func TestParseConfig_InvalidYaml(t *testing.T) {
+func TestParseConfig_InvalidConfig_NegativeMaxHeadAge(t *testing.T) {
 	config := `
-    global:
-      port: 8080
-
-		invalid yaml
-
-    upstreams:
-      - id: alchemy-eth
-        httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-        wsURL: "wss://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-      - id: ankr-polygon
-        httpURL: "https://rpc.ankr.com/polygon"
-        wsURL: "wss://rpc.ankr.com/polygon/ws/${ANKR_API_KEY}"
+    chains:
+      - chainName: ethereum
+        routing:
+          maxHeadAge: -1m
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            nodeType: full
   `
 	configBytes := []byte(config)
 
@@ -1266,196 +2679,174 @@ func TestParseConfig_InvalidYaml(t *testing.T) {
 	}
 }
 
-func TestCacheConfig_GetRedisAddresses(t *testing.T) {
-	tests := []struct {
-		name        string
-		config      CacheConfig
-		wantReader  string
-		wantWriter  string
-		description string
-	}{
-		{
-			name: "legacy_config",
-			config: CacheConfig{
-				Redis: "localhost:6379",
-			},
-			wantReader:  "localhost:6379",
-			wantWriter:  "localhost:6379",
-			description: "Should use Redis field for both when only legacy config provided",
-		},
-		{
-			name: "split_config",
-			config: CacheConfig{
-				RedisReader: "reader:6379",
-				RedisWriter: "writer:6379",
-			},
-			wantReader:  "reader:6379",
-			wantWriter:  "writer:6379",
-			description: "Should use separate addresses when both reader and writer specified",
-		},
-		{
-			name: "mixed_config",
-			config: CacheConfig{
-				Redis:       "legacy:6379",
-				RedisReader: "reader:6379",
-				RedisWriter: "writer:6379",
-			},
-			wantReader:  "reader:6379",
-			wantWriter:  "writer:6379",
-			description: "Should prefer new config over legacy when both present",
-		},
-		{
-			name: "partial_config",
-			config: CacheConfig{
-				Redis:       "legacy:6379",
-				RedisReader: "reader:6379",
-			},
-			wantReader:  "legacy:6379",
-			wantWriter:  "legacy:6379",
-			description: "Should fall back to legacy when new config is incomplete",
-		},
-	}
+func TestParseConfig_ValidConfig_ReferenceOnly(t *testing.T) {
+	config := `
+    chains:
+      - chainName: ethereum
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+          - id: public-eth
+            httpURL: "https://ethereum-rpc.publicnode.com"
+            nodeType: full
+            referenceOnly: true
+  `
+	configBytes := []byte(config)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			reader, writer := tt.config.GetRedisAddresses()
-			assert.Equal(t, tt.wantReader, reader, tt.description)
-			assert.Equal(t, tt.wantWriter, writer, tt.description)
-		})
+	parsedConfig, err := parseConfig(configBytes)
+
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
 	}
+
+	assert.False(t, parsedConfig.Chains[0].Upstreams[0].ReferenceOnly)
+	assert.True(t, parsedConfig.Chains[0].Upstreams[1].ReferenceOnly)
 }
 
-func TestChainCacheConfig_GetTTLForMethod(t *testing.T) {
-	tests := []struct {
-		config         ChainCacheConfig
-		name           string
-		method         string
-		description    string
-		expectedTTL    time.Duration
-		expectedMinTTL time.Duration
+func TestParseConfig_InvalidConfig_ReferenceOnly(t *testing.T) {
+	for _, testCase := range []struct {
+		name   string
+		config string
 	}{
 		{
-			name: "default_ttl_only",
-			config: ChainCacheConfig{
-				TTL:        5 * time.Minute,
-				MethodTTLs: map[string]time.Duration{},
-			},
-			method:         "eth_getBalance",
-			expectedTTL:    5 * time.Minute,
-			expectedMinTTL: 5 * time.Minute,
-			description:    "Should return default TTL when no method-specific TTL exists",
-		},
-		{
-			name: "method_ttl_exists",
-			config: ChainCacheConfig{
-				TTL: 5 * time.Minute,
-				MethodTTLs: map[string]time.Duration{
-					"eth_getBalance": 10 * time.Minute,
-				},
-			},
-			method:         "eth_getBalance",
-			expectedTTL:    10 * time.Minute,
-			expectedMinTTL: 5 * time.Minute,
-			description:    "Should return method-specific TTL when it exists",
-		},
-		{
-			name: "method_ttl_exists_query_non_existent_method",
-			config: ChainCacheConfig{
-				TTL: 5 * time.Minute,
-				MethodTTLs: map[string]time.Duration{
-					"eth_getBalance": 10 * time.Minute,
-				},
-			},
-			method:         "eth_getLogs",
-			expectedTTL:    5 * time.Minute,
-			expectedMinTTL: 5 * time.Minute,
-			description:    "Should return default TTL when method-specific TTL doesn't exists",
-		},
-		{
-			name: "method_ttl_exists_but_not_default_query_non_existent_method",
-			config: ChainCacheConfig{
-				MethodTTLs: map[string]time.Duration{
-					"eth_getBalance": 10 * time.Minute,
-				},
-			},
-			method:         "eth_getLogs",
-			expectedTTL:    0,
-			expectedMinTTL: 10 * time.Minute,
-			description:    "Should return default TTL (which is 0) when method-specific AND default TTL doesn't exist",
-		},
-		{
-			name: "multiple_method_ttls_with_smaller_value",
-			config: ChainCacheConfig{
-				TTL: 5 * time.Minute,
-				MethodTTLs: map[string]time.Duration{
-					"eth_getBalance": 10 * time.Minute,
-					"eth_getLogs":    3 * time.Minute,
-				},
-			},
-			method:         "eth_getBalance",
-			expectedTTL:    10 * time.Minute,
-			expectedMinTTL: 3 * time.Minute,
-			description:    "Should return method-specific TTL and minimum TTL should be the smallest value",
+			name: "Reference-only upstream in a group",
+			config: `
+    chains:
+      - chainName: ethereum
+        groups:
+          - id: primary
+            priority: 0
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            group: primary
+            nodeType: full
+          - id: public-eth
+            httpURL: "https://ethereum-rpc.publicnode.com"
+            group: primary
+            nodeType: full
+            referenceOnly: true
+  `,
 		},
 		{
-			name: "all_zero_values",
-			config: ChainCacheConfig{
-				TTL:        0,
-				MethodTTLs: map[string]time.Duration{},
-			},
-			method:         "eth_getBalance",
-			expectedTTL:    0,
-			expectedMinTTL: 0,
-			description:    "Should return 0 for both TTL and minimum TTL when all values are zero",
+			name: "Only reference-only upstreams",
+			config: `
+    chains:
+      - chainName: ethereum
+        upstreams:
+          - id: public-eth
+            httpURL: "https://ethereum-rpc.publicnode.com"
+            nodeType: full
+            referenceOnly: true
+  `,
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			ttl := tt.config.GetTTLForMethod(tt.method)
-			assert.Equal(t, tt.expectedTTL, ttl, tt.description)
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := parseConfig([]byte(testCase.config))
 
-			minTTL := tt.config.GetMinimumTTL()
-			assert.Equal(t, tt.expectedMinTTL, minTTL, "Minimum TTL should match expected value")
+			if err == nil {
+				t.Errorf("Expected error parsing invalid YAML.")
+			}
 		})
 	}
 }
 
-func TestParseConfig_ChainCacheConfig(t *testing.T) {
+func TestParseConfig_ValidConfig_SyntheticProbes(t *testing.T) {
 	config := `
-    global:
-      port: 8080
-
     chains:
       - chainName: ethereum
-        cache:
-          ttl: 5m
-          methods:
-            - method: eth_getBalance
-              ttl: 10m
-            - method: eth_getBlockByNumber
-              ttl: 30s
         upstreams:
           - id: alchemy-eth
             httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
-            nodeType: full
+            nodeType: archive
+            probes:
+              - method: trace_block
+                params: ["0x1"]
+                interval: 5m
+                methods: [trace_block, trace_transaction]
+                expect:
+                  matcher: jsonPath
+                  path: 0.action.callType
+                  value: call
+              - method: eth_chainId
+                expect:
+                  matcher: exact
+                  value: "0x1"
+              - method: eth_getBlockByNumber
+                params: ["0x0", false]
   `
 	configBytes := []byte(config)
 
 	parsedConfig, err := parseConfig(configBytes)
-	assert.NoError(t, err)
 
-	chainConfig := parsedConfig.Chains[0]
+	if err != nil {
+		t.Errorf("ParseConfig returned error: %v.", err)
+	}
 
-	// Verify default TTL
-	assert.Equal(t, 5*time.Minute, chainConfig.Cache.TTL)
+	assert.Equal(t, []SyntheticProbeConfig{
+		{
+			Method:   "trace_block",
+			Params:   []any{"0x1"},
+			Interval: 5 * time.Minute,
+			Methods:  []string{"trace_block", "trace_transaction"},
+			Expect:   SyntheticProbeExpectConfig{Matcher: JSONPathMatcher, Path: "0.action.callType", Value: "call"},
+		},
+		{
+			Method:   "eth_chainId",
+			Interval: DefaultSyntheticProbeInterval,
+			Methods:  []string{"eth_chainId"},
+			Expect:   SyntheticProbeExpectConfig{Matcher: ExactMatcher, Value: "0x1"},
+		},
+		{
+			Method:   "eth_getBlockByNumber",
+			Params:   []any{"0x0", false},
+			Interval: DefaultSyntheticProbeInterval,
+			Methods:  []string{"eth_getBlockByNumber"},
+			Expect:   SyntheticProbeExpectConfig{Matcher: NonNullMatcher},
+		},
+	}, parsedConfig.Chains[0].Upstreams[0].SyntheticProbes)
+}
 
-	// Verify method-specific TTLs
-	assert.Equal(t, 10*time.Minute, chainConfig.Cache.MethodTTLs["eth_getBalance"])
-	assert.Equal(t, 30*time.Second, chainConfig.Cache.MethodTTLs["eth_getBlockByNumber"])
+func TestParseConfig_InvalidConfig_SyntheticProbes(t *testing.T) {
+	for _, testCase := range []struct {
+		name  string
+		probe string
+	}{
+		{"Missing method", `
+              - expect:
+                  matcher: nonNull`},
+		{"Unknown matcher", `
+              - method: eth_chainId
+                expect:
+                  matcher: regex`},
+		{"JSON path matcher without path", `
+              - method: eth_chainId
+                expect:
+                  matcher: jsonPath`},
+		{"Invalid method scope pattern", `
+              - method: trace_block
+                methods: ["trace_["]`},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			config := `
+    chains:
+      - chainName: ethereum
+        upstreams:
+          - id: alchemy-eth
+            httpURL: "https://eth-mainnet.g.alchemy.com/v2/${ALCHEMY_API_KEY}"
+            nodeType: full
+            probes:` + testCase.probe
 
-	// Test GetTTLForMethod
-	assert.Equal(t, 10*time.Minute, chainConfig.Cache.GetTTLForMethod("eth_getBalance"))
-	assert.Equal(t, 30*time.Second, chainConfig.Cache.GetTTLForMethod("eth_getBlockByNumber"))
-	assert.Equal(t, 5*time.Minute, chainConfig.Cache.GetTTLForMethod("eth_call")) // Not specified, should return default
+			_, err := parseConfig([]byte(config))
+
+			if err == nil {
+				t.Errorf("Expected error parsing invalid YAML.")
+			}
+		})
+	}
 }
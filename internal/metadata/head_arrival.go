@@ -0,0 +1,89 @@
+package metadata
+
+import (
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+)
+
+const (
+	// HeadArrivalWindowSize is the number of an upstream's latest heads that its head arrival delay is averaged over.
+	HeadArrivalWindowSize = 20
+	// Arrival times are forgotten for heads this many blocks behind the latest head.
+	headArrivalMaxTrackedBlocks = 128
+)
+
+// headArrivals tracks how long after the first upstream each upstream reports each new head, averaged over the
+// upstream's latest HeadArrivalWindowSize heads.
+type headArrivals struct {
+	metricsContainer       *metrics.Container
+	firstSeenByBlockHeight map[uint64]time.Time
+	delaysByUpstreamID     map[string][]time.Duration
+	delayByUpstreamID      map[string]time.Duration
+	maxBlockHeight         uint64
+}
+
+func newHeadArrivals(metricsContainer *metrics.Container) *headArrivals {
+	return &headArrivals{
+		metricsContainer:       metricsContainer,
+		firstSeenByBlockHeight: make(map[uint64]time.Time),
+		delaysByUpstreamID:     make(map[string][]time.Duration),
+		delayByUpstreamID:      make(map[string]time.Duration),
+	}
+}
+
+// update records that the upstream reported the given head, which is newer than its previous head, at the given time.
+func (h *headArrivals) update(upstreamID string, blockHeight uint64, arrivedAt time.Time) {
+	if blockHeight+headArrivalMaxTrackedBlocks < h.maxBlockHeight {
+		// The upstream is too far behind for its delay to be known, and will not be routed to anyway.
+		return
+	}
+
+	firstSeenAt, ok := h.firstSeenByBlockHeight[blockHeight]
+	if !ok {
+		firstSeenAt = arrivedAt
+		h.firstSeenByBlockHeight[blockHeight] = arrivedAt
+	}
+
+	if blockHeight > h.maxBlockHeight {
+		h.maxBlockHeight = blockHeight
+
+		for height := range h.firstSeenByBlockHeight {
+			if height+headArrivalMaxTrackedBlocks < h.maxBlockHeight {
+				delete(h.firstSeenByBlockHeight, height)
+			}
+		}
+	}
+
+	// Updates are processed in the order they are sent to the store, so the delay can only be negative by a hair.
+	delays := append(h.delaysByUpstreamID[upstreamID], max(arrivedAt.Sub(firstSeenAt), 0))
+	if len(delays) > HeadArrivalWindowSize {
+		delays = delays[len(delays)-HeadArrivalWindowSize:]
+	}
+
+	h.delaysByUpstreamID[upstreamID] = delays
+
+	var totalDelay time.Duration
+	for _, delay := range delays {
+		totalDelay += delay
+	}
+
+	h.delayByUpstreamID[upstreamID] = totalDelay / time.Duration(len(delays))
+	h.metricsContainer.HeadArrivalDelay.WithLabelValues(upstreamID).Set(h.delayByUpstreamID[upstreamID].Seconds())
+}
+
+// getMinDelay returns the lowest head arrival delay across all upstreams, or 0 if no head has been seen yet.
+func (h *headArrivals) getMinDelay() time.Duration {
+	var minDelay time.Duration
+
+	isFirst := true
+
+	for _, delay := range h.delayByUpstreamID {
+		if isFirst || delay < minDelay {
+			minDelay = delay
+			isFirst = false
+		}
+	}
+
+	return minDelay
+}
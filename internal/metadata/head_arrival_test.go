@@ -0,0 +1,68 @@
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeadArrivals_Update(t *testing.T) {
+	arrivals := newHeadArrivals(metrics.NewContainer(config.TestChainName))
+	start := time.Now()
+
+	// Upstream 1 sees every head first, upstream 2 sees them 100ms later.
+	for blockHeight := uint64(1); blockHeight <= 3; blockHeight++ {
+		arrivedAt := start.Add(time.Duration(blockHeight) * time.Second)
+		arrivals.update("upstream1", blockHeight, arrivedAt)
+		arrivals.update("upstream2", blockHeight, arrivedAt.Add(100*time.Millisecond))
+	}
+
+	assert.Equal(t, time.Duration(0), arrivals.delayByUpstreamID["upstream1"])
+	assert.Equal(t, 100*time.Millisecond, arrivals.delayByUpstreamID["upstream2"])
+	assert.Equal(t, time.Duration(0), arrivals.getMinDelay())
+	assert.Equal(t, 0.1, testutil.ToFloat64(arrivals.metricsContainer.HeadArrivalDelay.WithLabelValues("upstream2")))
+
+	// Upstream 2 sees the next head first and upstream 1 sees it 400ms later.
+	arrivals.update("upstream2", 4, start.Add(4*time.Second))
+	arrivals.update("upstream1", 4, start.Add(4*time.Second+400*time.Millisecond))
+
+	assert.Equal(t, 100*time.Millisecond, arrivals.delayByUpstreamID["upstream1"])
+	assert.Equal(t, 75*time.Millisecond, arrivals.delayByUpstreamID["upstream2"])
+	assert.Equal(t, 75*time.Millisecond, arrivals.getMinDelay())
+}
+
+func TestHeadArrivals_Window(t *testing.T) {
+	arrivals := newHeadArrivals(metrics.NewContainer(config.TestChainName))
+	start := time.Now()
+
+	arrivals.update("upstream1", 1, start)
+	arrivals.update("upstream2", 1, start.Add(time.Second))
+
+	// Once upstream 2 has caught up for a full window, its early delay is forgotten.
+	for blockHeight := uint64(2); blockHeight <= HeadArrivalWindowSize+1; blockHeight++ {
+		arrivedAt := start.Add(time.Duration(blockHeight) * time.Second)
+		arrivals.update("upstream1", blockHeight, arrivedAt)
+		arrivals.update("upstream2", blockHeight, arrivedAt)
+	}
+
+	assert.Equal(t, time.Duration(0), arrivals.delayByUpstreamID["upstream2"])
+	assert.Len(t, arrivals.delaysByUpstreamID["upstream2"], HeadArrivalWindowSize)
+}
+
+func TestHeadArrivals_ForgetsOldHeads(t *testing.T) {
+	arrivals := newHeadArrivals(metrics.NewContainer(config.TestChainName))
+	start := time.Now()
+
+	arrivals.update("upstream1", 1, start)
+	arrivals.update("upstream1", 1+headArrivalMaxTrackedBlocks+1, start.Add(time.Second))
+
+	assert.NotContains(t, arrivals.firstSeenByBlockHeight, uint64(1))
+
+	// An upstream reporting a forgotten head is not assigned a delay.
+	arrivals.update("upstream2", 1, start.Add(time.Minute))
+	assert.NotContains(t, arrivals.delayByUpstreamID, "upstream2")
+}
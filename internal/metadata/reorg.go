@@ -0,0 +1,90 @@
+package metadata
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// Reorgs deeper than this many blocks below the latest head are not detected, since older blocks are forgotten.
+const reorgMaxTrackedBlocks = 128
+
+// blockIdentity tells apart competing blocks at the same height. Header.Hash is not used because go-ethereum only
+// computes it correctly for the header formats it knows, which excludes those of newer forks and of some chains.
+type blockIdentity struct {
+	parentHash  common.Hash
+	root        common.Hash
+	txHash      common.Hash
+	receiptHash common.Hash
+	time        uint64
+}
+
+// recentBlocks tracks the recent heads reported by all upstreams, and detects a reorg when an upstream reports a head
+// that conflicts with them. An upstream that stays on a different fork than the others keeps causing reorgs to be
+// detected, which only makes the dependent caches more conservative.
+type recentBlocks struct {
+	identityByBlockHeight map[uint64]blockIdentity
+	// The hash of each block, as reported by the parent hash of the next one.
+	hashByBlockHeight map[uint64]common.Hash
+	maxBlockHeight    uint64
+}
+
+func newRecentBlocks() *recentBlocks {
+	return &recentBlocks{
+		identityByBlockHeight: make(map[uint64]blockIdentity),
+		hashByBlockHeight:     make(map[uint64]common.Hash),
+	}
+}
+
+// update records the given head, and returns the lowest block height it reorged, if any. Only the heads observed are
+// compared, without fetching their ancestors, so the returned height is the lowest conflict observed: if the fork
+// point is below the blocks the upstreams reported, e.g. because polling skipped some heads, it is not detected, and
+// the reorged blocks below the returned height are not reported.
+func (r *recentBlocks) update(header *ethTypes.Header) (reorgedBlockHeight uint64, isReorg bool) {
+	blockHeight := header.Number.Uint64()
+	if blockHeight == 0 || blockHeight+reorgMaxTrackedBlocks < r.maxBlockHeight {
+		return 0, false
+	}
+
+	identity := blockIdentity{
+		parentHash:  header.ParentHash,
+		root:        header.Root,
+		txHash:      header.TxHash,
+		receiptHash: header.ReceiptHash,
+		time:        header.Time,
+	}
+
+	if knownHash, ok := r.hashByBlockHeight[blockHeight-1]; ok && knownHash != header.ParentHash {
+		reorgedBlockHeight, isReorg = blockHeight-1, true
+	} else if knownIdentity, ok := r.identityByBlockHeight[blockHeight]; ok && knownIdentity != identity {
+		reorgedBlockHeight, isReorg = blockHeight, true
+	}
+
+	if isReorg {
+		// The blocks from the reorged one up are no longer known to be canonical.
+		r.forget(func(height uint64) bool { return height >= reorgedBlockHeight })
+	}
+
+	r.identityByBlockHeight[blockHeight] = identity
+	r.hashByBlockHeight[blockHeight-1] = header.ParentHash
+
+	if blockHeight > r.maxBlockHeight {
+		r.maxBlockHeight = blockHeight
+		r.forget(func(height uint64) bool { return height+reorgMaxTrackedBlocks < r.maxBlockHeight })
+	}
+
+	return reorgedBlockHeight, isReorg
+}
+
+func (r *recentBlocks) forget(shouldForget func(blockHeight uint64) bool) {
+	for height := range r.identityByBlockHeight {
+		if shouldForget(height) {
+			delete(r.identityByBlockHeight, height)
+		}
+	}
+
+	for height := range r.hashByBlockHeight {
+		if shouldForget(height) {
+			delete(r.hashByBlockHeight, height)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package metadata
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestHeader returns a header whose fork tells apart competing blocks at the same height.
+func newTestHeader(blockHeight uint64, parentHash common.Hash, fork byte) *ethTypes.Header {
+	return &ethTypes.Header{
+		Number:     new(big.Int).SetUint64(blockHeight),
+		ParentHash: parentHash,
+		Root:       common.Hash{fork, byte(blockHeight)},
+	}
+}
+
+func TestRecentBlocks_SameHeightReorg(t *testing.T) {
+	blocks := newRecentBlocks()
+
+	_, isReorg := blocks.update(newTestHeader(100, common.Hash{0xa}, 0))
+	assert.False(t, isReorg)
+
+	// Reporting the same head again, e.g. from another upstream, is not a reorg.
+	_, isReorg = blocks.update(newTestHeader(100, common.Hash{0xa}, 0))
+	assert.False(t, isReorg)
+
+	reorgedBlockHeight, isReorg := blocks.update(newTestHeader(100, common.Hash{0xa}, 1))
+	assert.True(t, isReorg)
+	assert.Equal(t, uint64(100), reorgedBlockHeight)
+}
+
+func TestRecentBlocks_ParentHashReorg(t *testing.T) {
+	blocks := newRecentBlocks()
+
+	blocks.update(newTestHeader(101, common.Hash{0xa}, 0))
+	blocks.update(newTestHeader(102, common.Hash{0xb}, 0))
+
+	// The new head's parent is not the block 101 that the previous head was built on.
+	reorgedBlockHeight, isReorg := blocks.update(newTestHeader(102, common.Hash{0xc}, 1))
+	assert.True(t, isReorg)
+	assert.Equal(t, uint64(101), reorgedBlockHeight)
+
+	// The new fork is now canonical.
+	_, isReorg = blocks.update(newTestHeader(102, common.Hash{0xc}, 1))
+	assert.False(t, isReorg)
+}
+
+func TestRecentBlocks_ForgetsOldBlocks(t *testing.T) {
+	blocks := newRecentBlocks()
+
+	blocks.update(newTestHeader(100, common.Hash{0xa}, 0))
+	blocks.update(newTestHeader(100+reorgMaxTrackedBlocks+1, common.Hash{0xb}, 0))
+
+	_, isReorg := blocks.update(newTestHeader(100, common.Hash{0xa}, 1))
+	assert.False(t, isReorg)
+	assert.Len(t, blocks.identityByBlockHeight, 1)
+}
+
+func TestChainMetadataStore_OnReorg(t *testing.T) {
+	store := NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+
+	reorgedBlockHeights := make(chan uint64, 1)
+	store.OnReorg(func(reorgedBlockHeight uint64) {
+		reorgedBlockHeights <- reorgedBlockHeight
+	})
+	store.Start()
+
+	store.ProcessBlockHeaderUpdate("group1", "upstream1", newTestHeader(100, common.Hash{0xa}, 0))
+	store.ProcessBlockHeaderUpdate("group1", "upstream2", newTestHeader(100, common.Hash{0xa}, 1))
+
+	select {
+	case reorgedBlockHeight := <-reorgedBlockHeights:
+		assert.Equal(t, uint64(100), reorgedBlockHeight)
+	case <-time.After(time.Second):
+		assert.Fail(t, "The reorg listener was not called.")
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(store.metricsContainer.Reorgs.WithLabelValues("upstream2")))
+}
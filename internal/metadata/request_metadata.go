@@ -1,5 +1,37 @@
 package metadata
 
+import "slices"
+
+type BlockTag string
+
+// Named block tags that may be passed to JSON-RPC methods instead of a block number.
+// See: https://ethereum.org/en/developers/docs/apis/json-rpc/#default-block
+const (
+	EarliestBlockTag  BlockTag = "earliest"
+	LatestBlockTag    BlockTag = "latest"
+	PendingBlockTag   BlockTag = "pending"
+	SafeBlockTag      BlockTag = "safe"
+	FinalizedBlockTag BlockTag = "finalized"
+)
+
+// FinalityBlockTags are the block tags whose heads are tracked separately from the latest head.
+var FinalityBlockTags = []BlockTag{SafeBlockTag, FinalizedBlockTag}
+
+func isBlockTag(value string) bool {
+	switch BlockTag(value) {
+	case EarliestBlockTag, LatestBlockTag, PendingBlockTag, SafeBlockTag, FinalizedBlockTag:
+		return true
+	default:
+		return false
+	}
+}
+
 type RequestMetadata struct {
 	Methods []string
+	// Named block tags referenced anywhere in the request params. Nil if the request references none.
+	BlockTags []BlockTag
+}
+
+func (m *RequestMetadata) HasBlockTag(tag BlockTag) bool {
+	return slices.Contains(m.BlockTags, tag)
 }
@@ -1,7 +1,11 @@
 package metadata
 
 import (
+	"slices"
+	"sort"
+
 	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"golang.org/x/exp/maps"
 )
 
 type RequestMetadataParser struct{}
@@ -10,7 +14,8 @@ func (p *RequestMetadataParser) Parse(requestBody jsonrpc.RequestBody) RequestMe
 	switch requestBody.(type) {
 	case *jsonrpc.SingleRequestBody:
 		return RequestMetadata{
-			Methods: []string{requestBody.GetMethod()},
+			Methods:   []string{requestBody.GetMethod()},
+			BlockTags: collectBlockTags(nil, requestBody.GetSubRequests()[0].Params),
 		}
 	case *jsonrpc.BatchRequestBody:
 		result := RequestMetadata{
@@ -19,6 +24,7 @@ func (p *RequestMetadataParser) Parse(requestBody jsonrpc.RequestBody) RequestMe
 
 		for _, requestBody := range requestBody.GetSubRequests() {
 			result.Methods = append(result.Methods, requestBody.Method)
+			result.BlockTags = collectBlockTags(result.BlockTags, requestBody.Params)
 		}
 
 		return result
@@ -26,3 +32,28 @@ func (p *RequestMetadataParser) Parse(requestBody jsonrpc.RequestBody) RequestMe
 		panic("Invalid request body type   ")
 	}
 }
+
+// collectBlockTags appends the distinct block tags found in the given decoded JSON params to tags.
+// Block tags can appear as top-level params (e.g. `eth_getBalance`) or nested in objects (e.g. `eth_getLogs` filters).
+func collectBlockTags(tags []BlockTag, params any) []BlockTag {
+	switch p := params.(type) {
+	case string:
+		if isBlockTag(p) && !slices.Contains(tags, BlockTag(p)) {
+			tags = append(tags, BlockTag(p))
+		}
+	case []any:
+		for _, param := range p {
+			tags = collectBlockTags(tags, param)
+		}
+	case map[string]any:
+		// Sort the keys so that the order of the collected tags is deterministic.
+		keys := maps.Keys(p)
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			tags = collectBlockTags(tags, p[key])
+		}
+	}
+
+	return tags
+}
@@ -59,6 +59,43 @@ func TestRequestMetadataParser_Parse(t *testing.T) {
 			[]string{"eth_call", "eth_getTransactionReceipt", "trace_filter", "eth_getLogs"}),
 	}
 
+	tests = append(tests,
+		testArgs{
+			args{
+				requestBody: &jsonrpc.SingleRequestBody{
+					Method: "eth_getBalance",
+					Params: []any{"0x407d73d8a49eeb85d32cf465507dd71d507100c1", "finalized"},
+				},
+			},
+			"eth_getBalance with finalized block tag",
+			RequestMetadata{
+				Methods:   []string{"eth_getBalance"},
+				BlockTags: []BlockTag{FinalizedBlockTag},
+			},
+		},
+		testArgs{
+			args{
+				requestBody: &jsonrpc.BatchRequestBody{
+					Requests: []jsonrpc.SingleRequestBody{
+						{
+							Method: "eth_getLogs",
+							Params: []any{map[string]any{"fromBlock": "safe", "toBlock": "latest"}},
+						},
+						{
+							Method: "eth_getBlockByNumber",
+							Params: []any{"safe", false},
+						},
+					},
+				},
+			},
+			"batch with nested and repeated block tags",
+			RequestMetadata{
+				Methods:   []string{"eth_getLogs", "eth_getBlockByNumber"},
+				BlockTags: []BlockTag{SafeBlockTag, LatestBlockTag}, // "fromBlock" sorts before "toBlock".
+			},
+		},
+	)
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := &RequestMetadataParser{}
@@ -1,33 +1,116 @@
 package metadata
 
-import "github.com/samber/lo"
+import (
+	"sync/atomic"
+	"time"
+
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/samber/lo"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+)
+
+// ReferenceGroupID is the group that the heads of reference-only upstreams are tracked under. Reference-only upstreams
+// cannot be in a configured group, so they only contribute to the global max heights.
+const ReferenceGroupID = "(reference)"
 
 type BlockHeightStatus struct {
-	Error                error
-	GroupID              string
-	UpstreamID           string
-	BlockHeight          uint64
-	GroupMaxBlockHeight  uint64
-	GlobalMaxBlockHeight uint64
+	Error                         error
+	GroupID                       string
+	UpstreamID                    string
+	BlockHeight                   uint64
+	GroupMaxBlockHeight           uint64
+	GlobalMaxBlockHeight          uint64
+	SafeBlockHeight               uint64
+	GroupMaxSafeBlockHeight       uint64
+	GlobalMaxSafeBlockHeight      uint64
+	FinalizedBlockHeight          uint64
+	GroupMaxFinalizedBlockHeight  uint64
+	GlobalMaxFinalizedBlockHeight uint64
+	// Timestamps of the latest blocks. They are zero until the first block is seen.
+	BlockTimestamp          time.Time
+	GlobalMaxBlockTimestamp time.Time
+	// Average delay between the first upstream and this upstream reporting each of its latest heads, along with
+	// the lowest such delay across all upstreams.
+	HeadArrivalDelay    time.Duration
+	MinHeadArrivalDelay time.Duration
 }
 
-type ChainMetadataStore struct {
-	opChannel          chan func()
+// GetFinalityHeights returns the upstream's head for the given finality tag, along with the max head for that tag
+// in the upstream's group and across all upstreams.
+func (s *BlockHeightStatus) GetFinalityHeights(tag BlockTag) (blockHeight, groupMaxBlockHeight, globalMaxBlockHeight uint64) {
+	switch tag {
+	case SafeBlockTag:
+		return s.SafeBlockHeight, s.GroupMaxSafeBlockHeight, s.GlobalMaxSafeBlockHeight
+	case FinalizedBlockTag:
+		return s.FinalizedBlockHeight, s.GroupMaxFinalizedBlockHeight, s.GlobalMaxFinalizedBlockHeight
+	default:
+		panic("Unsupported finality block tag " + tag + "!")
+	}
+}
+
+// finalityHeights tracks the heads of a single finality tag (e.g. "safe" or "finalized").
+type finalityHeights struct {
 	maxHeightByGroupID map[string]uint64
 	heightByUpstreamID map[string]uint64
-	errorByUpstreamID  map[string]error
-	globalMaxHeight    uint64
+	// Only written by the op goroutine, but read directly by GetGlobalMaxBlockHeights.
+	globalMaxHeight atomic.Uint64
 }
 
-func NewChainMetadataStore() *ChainMetadataStore {
-	return &ChainMetadataStore{
+func newFinalityHeights() *finalityHeights {
+	return &finalityHeights{
 		maxHeightByGroupID: make(map[string]uint64),
 		heightByUpstreamID: make(map[string]uint64),
-		errorByUpstreamID:  make(map[string]error),
-		opChannel:          make(chan func()),
 	}
 }
 
+func (f *finalityHeights) update(groupID, upstreamID string, blockHeight uint64) {
+	if blockHeight > f.globalMaxHeight.Load() {
+		f.globalMaxHeight.Store(blockHeight)
+	}
+
+	f.maxHeightByGroupID[groupID] = lo.Max([]uint64{f.maxHeightByGroupID[groupID], blockHeight})
+	f.heightByUpstreamID[upstreamID] = blockHeight
+}
+
+type ChainMetadataStore struct {
+	globalMaxTimestamp    time.Time
+	opChannel             chan func()
+	maxHeightByGroupID    map[string]uint64
+	heightByUpstreamID    map[string]uint64
+	errorByUpstreamID     map[string]error
+	timestampByUpstreamID map[string]time.Time
+	safeHeights           *finalityHeights
+	finalizedHeights      *finalityHeights
+	headArrivals          *headArrivals
+	// Only written by the op goroutine, but read directly by GetGlobalMaxBlockHeights.
+	globalMaxHeight atomic.Uint64
+	// Only used to detect reorgs if there are reorg listeners.
+	recentBlocks     *recentBlocks
+	reorgListeners   []func(reorgedBlockHeight uint64)
+	metricsContainer *metrics.Container
+}
+
+func NewChainMetadataStore(metricsContainer *metrics.Container) *ChainMetadataStore {
+	return &ChainMetadataStore{
+		maxHeightByGroupID:    make(map[string]uint64),
+		heightByUpstreamID:    make(map[string]uint64),
+		errorByUpstreamID:     make(map[string]error),
+		timestampByUpstreamID: make(map[string]time.Time),
+		safeHeights:           newFinalityHeights(),
+		finalizedHeights:      newFinalityHeights(),
+		headArrivals:          newHeadArrivals(metricsContainer),
+		opChannel:             make(chan func()),
+		recentBlocks:          newRecentBlocks(),
+		metricsContainer:      metricsContainer,
+	}
+}
+
+// OnReorg registers a listener that is called with the lowest reorged block height whenever a reorg is detected. The
+// listener is called in a new goroutine. Must be called before Start.
+func (c *ChainMetadataStore) OnReorg(listener func(reorgedBlockHeight uint64)) {
+	c.reorgListeners = append(c.reorgListeners, listener)
+}
+
 func (c *ChainMetadataStore) Start() {
 	go func() {
 		for op := range c.opChannel {
@@ -52,12 +135,22 @@ func (c *ChainMetadataStore) GetBlockHeightStatus(groupID, upstreamID string) Bl
 	returnChannel := make(chan BlockHeightStatus)
 	c.opChannel <- func() {
 		blockHeightStatus := BlockHeightStatus{
-			Error:                c.errorByUpstreamID[upstreamID],
-			GroupID:              groupID,
-			UpstreamID:           upstreamID,
-			BlockHeight:          c.heightByUpstreamID[upstreamID],
-			GroupMaxBlockHeight:  c.maxHeightByGroupID[groupID],
-			GlobalMaxBlockHeight: c.globalMaxHeight,
+			Error:                         c.errorByUpstreamID[upstreamID],
+			GroupID:                       groupID,
+			UpstreamID:                    upstreamID,
+			BlockHeight:                   c.heightByUpstreamID[upstreamID],
+			GroupMaxBlockHeight:           c.maxHeightByGroupID[groupID],
+			GlobalMaxBlockHeight:          c.globalMaxHeight.Load(),
+			SafeBlockHeight:               c.safeHeights.heightByUpstreamID[upstreamID],
+			GroupMaxSafeBlockHeight:       c.safeHeights.maxHeightByGroupID[groupID],
+			GlobalMaxSafeBlockHeight:      c.safeHeights.globalMaxHeight.Load(),
+			FinalizedBlockHeight:          c.finalizedHeights.heightByUpstreamID[upstreamID],
+			GroupMaxFinalizedBlockHeight:  c.finalizedHeights.maxHeightByGroupID[groupID],
+			GlobalMaxFinalizedBlockHeight: c.finalizedHeights.globalMaxHeight.Load(),
+			BlockTimestamp:                c.timestampByUpstreamID[upstreamID],
+			GlobalMaxBlockTimestamp:       c.globalMaxTimestamp,
+			HeadArrivalDelay:              c.headArrivals.delayByUpstreamID[upstreamID],
+			MinHeadArrivalDelay:           c.headArrivals.getMinDelay(),
 		}
 		returnChannel <- blockHeightStatus
 		close(returnChannel)
@@ -67,14 +160,84 @@ func (c *ChainMetadataStore) GetBlockHeightStatus(groupID, upstreamID string) Bl
 }
 
 func (c *ChainMetadataStore) ProcessBlockHeightUpdate(groupID, upstreamID string, blockHeight uint64) {
+	arrivedAt := time.Now()
+
 	c.opChannel <- func() {
-		c.globalMaxHeight = lo.Max([]uint64{c.globalMaxHeight, blockHeight})
+		if blockHeight > c.heightByUpstreamID[upstreamID] {
+			c.headArrivals.update(upstreamID, blockHeight, arrivedAt)
+		}
+
+		if blockHeight > c.globalMaxHeight.Load() {
+			c.globalMaxHeight.Store(blockHeight)
+		}
+
 		c.updateHeightForGroup(groupID, blockHeight)
 		c.updateHeightForUpstream(upstreamID, blockHeight)
 		c.updateErrorForUpstream(upstreamID, nil)
 	}
 }
 
+func (c *ChainMetadataStore) ProcessSafeBlockHeightUpdate(groupID, upstreamID string, blockHeight uint64) {
+	c.opChannel <- func() {
+		c.safeHeights.update(groupID, upstreamID, blockHeight)
+	}
+}
+
+func (c *ChainMetadataStore) ProcessFinalizedBlockHeightUpdate(groupID, upstreamID string, blockHeight uint64) {
+	c.opChannel <- func() {
+		c.finalizedHeights.update(groupID, upstreamID, blockHeight)
+	}
+}
+
+func (c *ChainMetadataStore) ProcessBlockTimestampUpdate(_, upstreamID string, blockTimestamp time.Time) {
+	c.opChannel <- func() {
+		c.timestampByUpstreamID[upstreamID] = blockTimestamp
+
+		if blockTimestamp.After(c.globalMaxTimestamp) {
+			c.globalMaxTimestamp = blockTimestamp
+		}
+	}
+}
+
+// ProcessBlockHeaderUpdate records the header of the upstream's latest block, which is how reorgs are detected.
+func (c *ChainMetadataStore) ProcessBlockHeaderUpdate(_, upstreamID string, header *ethTypes.Header) {
+	c.opChannel <- func() {
+		if len(c.reorgListeners) == 0 {
+			return
+		}
+
+		reorgedBlockHeight, isReorg := c.recentBlocks.update(header)
+		if !isReorg {
+			return
+		}
+
+		c.metricsContainer.Reorgs.WithLabelValues(upstreamID).Inc()
+
+		for _, listener := range c.reorgListeners {
+			go listener(reorgedBlockHeight)
+		}
+	}
+}
+
+// GetGlobalMaxBlockHeights returns the latest and finalized heads across all upstreams, which are zero until the
+// first one is seen. It is called for every cacheable request, so the heights are read without going through the op
+// goroutine, and reflect the updates processed so far.
+func (c *ChainMetadataStore) GetGlobalMaxBlockHeights() (blockHeight, finalizedBlockHeight uint64) {
+	return c.globalMaxHeight.Load(), c.finalizedHeights.globalMaxHeight.Load()
+}
+
+// GetGlobalMaxBlockTimestamp returns the timestamp of the latest block seen across all upstreams, or the zero time if
+// no block has been seen yet.
+func (c *ChainMetadataStore) GetGlobalMaxBlockTimestamp() time.Time {
+	returnChannel := make(chan time.Time)
+	c.opChannel <- func() {
+		returnChannel <- c.globalMaxTimestamp
+		close(returnChannel)
+	}
+
+	return <-returnChannel
+}
+
 func (c *ChainMetadataStore) ProcessErrorUpdate(_, upstreamID string, err error) {
 	c.opChannel <- func() {
 		c.updateErrorForUpstream(upstreamID, err)
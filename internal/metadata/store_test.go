@@ -2,12 +2,15 @@ package metadata
 
 import (
 	"testing"
+	"time"
 
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestChainMetadataStore_GetBlockHeightStatus(t *testing.T) {
-	store := NewChainMetadataStore()
+	store := NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
 
 	store.Start()
 
@@ -44,6 +47,77 @@ func TestChainMetadataStore_GetBlockHeightStatus(t *testing.T) {
 	assert.Nil(t, status.Error)
 }
 
+func TestChainMetadataStore_GetBlockHeightStatus_Finality(t *testing.T) {
+	store := NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+
+	store.Start()
+
+	store.ProcessSafeBlockHeightUpdate("group1", "upstream1", 90)
+	store.ProcessFinalizedBlockHeightUpdate("group1", "upstream1", 60)
+	store.ProcessSafeBlockHeightUpdate("group1", "upstream2", 95)
+	store.ProcessFinalizedBlockHeightUpdate("group2", "upstream3", 70)
+
+	status := store.GetBlockHeightStatus("group1", "upstream1")
+	assert.Equal(t, uint64(90), status.SafeBlockHeight)
+	assert.Equal(t, uint64(95), status.GroupMaxSafeBlockHeight)
+	assert.Equal(t, uint64(95), status.GlobalMaxSafeBlockHeight)
+	assert.Equal(t, uint64(60), status.FinalizedBlockHeight)
+	assert.Equal(t, uint64(60), status.GroupMaxFinalizedBlockHeight)
+	assert.Equal(t, uint64(70), status.GlobalMaxFinalizedBlockHeight)
+
+	blockHeight, groupMaxBlockHeight, globalMaxBlockHeight := status.GetFinalityHeights(FinalizedBlockTag)
+	assert.Equal(t, uint64(60), blockHeight)
+	assert.Equal(t, uint64(60), groupMaxBlockHeight)
+	assert.Equal(t, uint64(70), globalMaxBlockHeight)
+
+	// Finality heads are tracked independently of the latest head.
+	assert.Equal(t, uint64(0), status.BlockHeight)
+}
+
+func TestChainMetadataStore_GetBlockHeightStatus_BlockTimestamp(t *testing.T) {
+	store := NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+
+	store.Start()
+
+	assert.True(t, store.GetGlobalMaxBlockTimestamp().IsZero())
+
+	now := time.Now().Truncate(time.Second)
+	store.ProcessBlockTimestampUpdate("group1", "upstream1", now.Add(-time.Minute))
+	store.ProcessBlockTimestampUpdate("group2", "upstream2", now)
+
+	status := store.GetBlockHeightStatus("group1", "upstream1")
+	assert.Equal(t, now.Add(-time.Minute), status.BlockTimestamp)
+	assert.Equal(t, now, status.GlobalMaxBlockTimestamp)
+	assert.Equal(t, now, store.GetGlobalMaxBlockTimestamp())
+
+	// An older block (e.g. after a reorg) does not move the global max timestamp back.
+	store.ProcessBlockTimestampUpdate("group2", "upstream2", now.Add(-time.Hour))
+	status = store.GetBlockHeightStatus("group2", "upstream2")
+	assert.Equal(t, now.Add(-time.Hour), status.BlockTimestamp)
+	assert.Equal(t, now, status.GlobalMaxBlockTimestamp)
+}
+
+func TestChainMetadataStore_GetBlockHeightStatus_HeadArrivalDelay(t *testing.T) {
+	store := NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+
+	store.Start()
+
+	emitBlockHeight(store, "group1", "upstream1", 100)
+	time.Sleep(10 * time.Millisecond)
+	emitBlockHeight(store, "group1", "upstream2", 100)
+
+	// Reporting the same head again does not count as another arrival.
+	time.Sleep(10 * time.Millisecond)
+	emitBlockHeight(store, "group1", "upstream1", 100)
+
+	status := store.GetBlockHeightStatus("group1", "upstream2")
+	assert.GreaterOrEqual(t, status.HeadArrivalDelay, 10*time.Millisecond)
+	assert.Equal(t, time.Duration(0), status.MinHeadArrivalDelay)
+
+	status = store.GetBlockHeightStatus("group1", "upstream1")
+	assert.Equal(t, time.Duration(0), status.HeadArrivalDelay)
+}
+
 func emitBlockHeight(store *ChainMetadataStore, groupID, upstreamID string, blockHeight uint64) {
 	store.ProcessBlockHeightUpdate(groupID, upstreamID, blockHeight)
 }
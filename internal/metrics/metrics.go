@@ -28,6 +28,19 @@ const (
 	// WSSubscribe BlockHeightCheck-specific errors
 	WSSubscribe = "wsSubscribe"
 	WSError     = "wsError"
+
+	// HTTPFinalityRequest BlockHeightCheck-specific error when retrieving the safe or finalized head
+	HTTPFinalityRequest = "httpFinalityReq"
+
+	// UnexpectedResult SyntheticProbeCheck-specific error when the result does not match the expected one
+	UnexpectedResult = "unexpectedResult"
+
+	// OutlierErrorRate Reasons for ejecting an upstream as an outlier
+	OutlierErrorRate = "errorRate"
+	OutlierLatency   = "latency"
+
+	// UnparsableClientVersion ClientVersionCheck-specific error when web3_clientVersion cannot be parsed
+	UnparsableClientVersion = "unparsableClientVersion"
 )
 
 var (
@@ -78,6 +91,16 @@ var (
 		[]string{"chain_name", "client", "upstream_id", "url", "jsonrpc_method", "cached"},
 	)
 
+	coalescedRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "router",
+			Name:      "coalesced_requests",
+			Help:      "Count of total RPC requests that shared the upstream request of an identical in-flight request.",
+		},
+		[]string{"chain_name", "client", "jsonrpc_method"},
+	)
+
 	upstreamRPCRequestErrorsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: MetricsNamespace,
@@ -113,6 +136,16 @@ var (
 		[]string{"chain_name", "client", "upstream_id", "url", "jsonrpc_method", "response_code"},
 	)
 
+	activeGroup = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "router",
+			Name:      "active_group",
+			Help:      "Whether the upstream group currently receives traffic.",
+		},
+		[]string{"chain_name", "group_id", "priority"},
+	)
+
 	// Health check metrics
 
 	blockHeight = promauto.NewGaugeVec(
@@ -156,6 +189,96 @@ var (
 		[]string{"chain_name", "upstream_id", "url", "errorType"},
 	)
 
+	safeBlockHeight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "safe_block_height",
+			Help:      "Safe block height of upstream.",
+		},
+		[]string{"chain_name", "upstream_id", "url"},
+	)
+
+	finalizedBlockHeight = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "finalized_block_height",
+			Help:      "Finalized block height of upstream.",
+		},
+		[]string{"chain_name", "upstream_id", "url"},
+	)
+
+	finalityLag = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "finality_lag_blocks",
+			Help:      "Number of blocks between the latest and the finalized block of upstream.",
+		},
+		[]string{"chain_name", "upstream_id", "url"},
+	)
+
+	headBlockTimestamp = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "head_block_timestamp_seconds",
+			Help:      "Unix timestamp of the latest block of upstream.",
+		},
+		[]string{"chain_name", "upstream_id", "url"},
+	)
+
+	headArrivalDelay = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "head_arrival_delay_seconds",
+			Help:      "Average delay between the first upstream and this upstream reporting each of its latest heads.",
+		},
+		[]string{"chain_name", "upstream_id"},
+	)
+
+	reorgs = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "reorgs",
+			Help:      "Reorgs detected from a head of upstream that conflicts with the previously reported heads.",
+		},
+		[]string{"chain_name", "upstream_id"},
+	)
+
+	wsResubscribeAttempts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "ws_resubscribe_attempts",
+			Help:      "Attempts to resubscribe to newHeads over Websockets after the subscription failed.",
+		},
+		[]string{"chain_name", "upstream_id", "url"},
+	)
+
+	wsFallback = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "ws_fallback",
+			Help:      "Whether the block height of upstream is polled over HTTP because its newHeads subscription failed.",
+		},
+		[]string{"chain_name", "upstream_id", "url"},
+	)
+
+	wsFallbackDuration = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "ws_fallback_duration_seconds",
+			Help:      "Total time spent polling the block height of upstream over HTTP before its newHeads subscription recovered.",
+		},
+		[]string{"chain_name", "upstream_id", "url"},
+	)
+
 	peerCount = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: MetricsNamespace,
@@ -197,6 +320,48 @@ var (
 		[]string{"chain_name", "upstream_id", "url", "errorType"},
 	)
 
+	// Always 1, with the client name and version as labels
+	clientVersion = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "client_version",
+			Help:      "Client software of upstream, as reported by web3_clientVersion.",
+		},
+		[]string{"chain_name", "upstream_id", "url", "client", "version"},
+	)
+
+	clientVersionCheckRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "client_version_check_requests",
+			Help:      "Total client version requests made.",
+		},
+		[]string{"chain_name", "upstream_id", "url"},
+	)
+
+	clientVersionCheckDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "client_version_check_duration_seconds",
+			Help:      "Latency of client version requests.",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 20, 40},
+		},
+		[]string{"chain_name", "upstream_id", "url"},
+	)
+
+	clientVersionCheckErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "client_version_check_errors",
+			Help:      "Errors when retrieving or parsing the client version of upstream.",
+		},
+		[]string{"chain_name", "upstream_id", "url", "errorType"},
+	)
+
 	// Use 0 or 1
 	syncStatus = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -320,6 +485,118 @@ var (
 		[]string{"chain_name", "upstream_id", "url", "errorType", "method"},
 	)
 
+	latencyWindow = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "latency_window_seconds",
+			Help:      "Statistics of the latencies in the latency check's detection window, by statistic (mean, p50, p90 or p99).",
+		},
+		[]string{"chain_name", "upstream_id", "url", "method", "statistic"},
+	)
+
+	latencyWindowSamples = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "latency_window_samples",
+			Help:      "Number of latencies in the latency check's detection window.",
+		},
+		[]string{"chain_name", "upstream_id", "url", "method"},
+	)
+
+	latencyBaseline = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "latency_baseline_seconds",
+			Help:      "Median latency of the method across all upstreams, which adaptive latency thresholds are a multiple of.",
+		},
+		[]string{"chain_name", "method"},
+	)
+
+	latencyProbeRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "latency_probe_requests",
+			Help:      "Total latency probe requests made.",
+		},
+		[]string{"chain_name", "upstream_id", "url", "method"},
+	)
+
+	latencyProbeDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "latency_probe_duration_seconds",
+			Help:      "Latency of latency probe requests.",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 20, 40},
+		},
+		[]string{"chain_name", "upstream_id", "url", "method"},
+	)
+
+	latencyProbeErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "latency_probe_errors",
+			Help:      "Errors when sending latency probes to upstream.",
+		},
+		[]string{"chain_name", "upstream_id", "url", "errorType", "method"},
+	)
+
+	syntheticProbeRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "synthetic_probe_requests",
+			Help:      "Total synthetic probe requests made.",
+		},
+		[]string{"chain_name", "upstream_id", "url", "method"},
+	)
+
+	syntheticProbeDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "synthetic_probe_duration_seconds",
+			Help:      "Latency of synthetic probe requests.",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 20, 40},
+		},
+		[]string{"chain_name", "upstream_id", "url", "method"},
+	)
+
+	syntheticProbeErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "synthetic_probe_errors",
+			Help:      "Errors when sending synthetic probes to upstream, or unexpected results.",
+		},
+		[]string{"chain_name", "upstream_id", "url", "errorType", "method"},
+	)
+
+	outlierEjected = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "outlier_ejected",
+			Help:      "Whether the upstream is currently ejected as an outlier compared to its group.",
+		},
+		[]string{"chain_name", "upstream_id"},
+	)
+
+	outlierEjections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "healthcheck",
+			Name:      "outlier_ejections",
+			Help:      "Upstreams ejected as outliers compared to their group, by the metric they were outliers in.",
+		},
+		[]string{"chain_name", "upstream_id", "reason"},
+	)
+
 	cacheReadDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: MetricsNamespace,
@@ -363,6 +640,36 @@ var (
 		[]string{"chain_name", "operation"},
 	)
 
+	cacheInvalidations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "redis_cache",
+			Name:      "reorg_invalidations_total",
+			Help:      "Total number of cache entries deleted because the block they reference was reorged",
+		},
+		[]string{"chain_name"},
+	)
+
+	cacheStaleResponses = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "redis_cache",
+			Name:      "stale_responses_total",
+			Help:      "Total number of expired cached responses served because the request could not be routed",
+		},
+		[]string{"chain_name", "method"},
+	)
+
+	cacheRevalidations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: "redis_cache",
+			Name:      "revalidations_total",
+			Help:      "Total number of background refreshes of cached responses in their stale-while-revalidate window",
+		},
+		[]string{"chain_name", "method", "success"},
+	)
+
 	CacheConnections = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: MetricsNamespace,
@@ -392,17 +699,35 @@ type Container struct {
 	UpstreamRPCRequestErrorsTotal     *prometheus.CounterVec
 	UpstreamJSONRPCRequestErrorsTotal *prometheus.CounterVec
 	UpstreamRPCDuration               prometheus.ObserverVec
+	ActiveGroup                       *prometheus.GaugeVec
+	CoalescedRequestsTotal            *prometheus.CounterVec
 
 	BlockHeight              *prometheus.GaugeVec
 	BlockHeightCheckRequests *prometheus.CounterVec
 	BlockHeightCheckDuration prometheus.ObserverVec
 	BlockHeightCheckErrors   *prometheus.CounterVec
 
+	SafeBlockHeight      *prometheus.GaugeVec
+	FinalizedBlockHeight *prometheus.GaugeVec
+	FinalityLag          *prometheus.GaugeVec
+	HeadBlockTimestamp   *prometheus.GaugeVec
+	HeadArrivalDelay     *prometheus.GaugeVec
+	Reorgs               *prometheus.CounterVec
+
+	WSResubscribeAttempts *prometheus.CounterVec
+	WSFallback            *prometheus.GaugeVec
+	WSFallbackDuration    *prometheus.CounterVec
+
 	PeerCount              *prometheus.GaugeVec
 	PeerCountCheckRequests *prometheus.CounterVec
 	PeerCountCheckDuration prometheus.ObserverVec
 	PeerCountCheckErrors   *prometheus.CounterVec
 
+	ClientVersion              *prometheus.GaugeVec
+	ClientVersionCheckRequests *prometheus.CounterVec
+	ClientVersionCheckDuration prometheus.ObserverVec
+	ClientVersionCheckErrors   *prometheus.CounterVec
+
 	SyncStatus              *prometheus.GaugeVec
 	SyncStatusCheckRequests *prometheus.CounterVec
 	SyncStatusCheckDuration prometheus.ObserverVec
@@ -418,11 +743,29 @@ type Container struct {
 	LatencyCheckLatencyIsPassing *prometheus.CounterVec
 	LatencyCheckLatencyIsFailing *prometheus.CounterVec
 
+	LatencyWindow        *prometheus.GaugeVec
+	LatencyWindowSamples *prometheus.GaugeVec
+	LatencyBaseline      *prometheus.GaugeVec
+
+	LatencyProbeRequests *prometheus.CounterVec
+	LatencyProbeDuration prometheus.ObserverVec
+	LatencyProbeErrors   *prometheus.CounterVec
+
+	SyntheticProbeRequests *prometheus.CounterVec
+	SyntheticProbeDuration prometheus.ObserverVec
+	SyntheticProbeErrors   *prometheus.CounterVec
+
+	OutlierEjected   *prometheus.GaugeVec
+	OutlierEjections *prometheus.CounterVec
+
 	// RPC request metrics
 	CacheReadDuration     prometheus.ObserverVec
 	CacheWriteDuration    prometheus.ObserverVec
 	CacheRequestsInFlight *prometheus.CounterVec
 	CacheErrors           *prometheus.CounterVec
+	CacheInvalidations    *prometheus.CounterVec
+	CacheStaleResponses   *prometheus.CounterVec
+	CacheRevalidations    *prometheus.CounterVec
 }
 
 func NewContainer(chainName string) *Container {
@@ -432,9 +775,11 @@ func NewContainer(chainName string) *Container {
 	}
 
 	result.UpstreamRPCRequestsTotal = upstreamRPCRequestsTotal.MustCurryWith(presetLabels)
+	result.CoalescedRequestsTotal = coalescedRequestsTotal.MustCurryWith(presetLabels)
 	result.UpstreamRPCRequestErrorsTotal = upstreamRPCRequestErrorsTotal.MustCurryWith(presetLabels)
 	result.UpstreamJSONRPCRequestErrorsTotal = upstreamJSONRPCRequestErrorsTotal.MustCurryWith(presetLabels)
 	result.UpstreamRPCDuration = upstreamRPCDuration.MustCurryWith(presetLabels)
+	result.ActiveGroup = activeGroup.MustCurryWith(presetLabels)
 
 	result.RPCRequestsCounter = rpcRequestsCounter.MustCurryWith(presetLabels)
 	result.RPCRequestsDuration = rpcRequestsDuration.MustCurryWith(presetLabels)
@@ -445,11 +790,27 @@ func NewContainer(chainName string) *Container {
 	result.BlockHeightCheckDuration = blockHeightCheckDuration.MustCurryWith(presetLabels)
 	result.BlockHeightCheckErrors = blockHeightCheckErrors.MustCurryWith(presetLabels)
 
+	result.SafeBlockHeight = safeBlockHeight.MustCurryWith(presetLabels)
+	result.FinalizedBlockHeight = finalizedBlockHeight.MustCurryWith(presetLabels)
+	result.FinalityLag = finalityLag.MustCurryWith(presetLabels)
+	result.HeadBlockTimestamp = headBlockTimestamp.MustCurryWith(presetLabels)
+	result.HeadArrivalDelay = headArrivalDelay.MustCurryWith(presetLabels)
+	result.Reorgs = reorgs.MustCurryWith(presetLabels)
+
+	result.WSResubscribeAttempts = wsResubscribeAttempts.MustCurryWith(presetLabels)
+	result.WSFallback = wsFallback.MustCurryWith(presetLabels)
+	result.WSFallbackDuration = wsFallbackDuration.MustCurryWith(presetLabels)
+
 	result.PeerCount = peerCount.MustCurryWith(presetLabels)
 	result.PeerCountCheckRequests = peerCountCheckRequests.MustCurryWith(presetLabels)
 	result.PeerCountCheckDuration = peerCountCheckDuration.MustCurryWith(presetLabels)
 	result.PeerCountCheckErrors = peerCountCheckErrors.MustCurryWith(presetLabels)
 
+	result.ClientVersion = clientVersion.MustCurryWith(presetLabels)
+	result.ClientVersionCheckRequests = clientVersionCheckRequests.MustCurryWith(presetLabels)
+	result.ClientVersionCheckDuration = clientVersionCheckDuration.MustCurryWith(presetLabels)
+	result.ClientVersionCheckErrors = clientVersionCheckErrors.MustCurryWith(presetLabels)
+
 	result.SyncStatus = syncStatus.MustCurryWith(presetLabels)
 	result.SyncStatusCheckRequests = syncStatusCheckRequests.MustCurryWith(presetLabels)
 	result.SyncStatusCheckDuration = syncStatusCheckDuration.MustCurryWith(presetLabels)
@@ -464,10 +825,28 @@ func NewContainer(chainName string) *Container {
 	result.LatencyCheckLatencyIsPassing = latencyStatusCheckLatencyIsPassing.MustCurryWith(presetLabels)
 	result.LatencyCheckLatencyIsFailing = latencyStatusCheckLatencyIsFailing.MustCurryWith(presetLabels)
 
+	result.LatencyWindow = latencyWindow.MustCurryWith(presetLabels)
+	result.LatencyWindowSamples = latencyWindowSamples.MustCurryWith(presetLabels)
+	result.LatencyBaseline = latencyBaseline.MustCurryWith(presetLabels)
+
+	result.LatencyProbeRequests = latencyProbeRequests.MustCurryWith(presetLabels)
+	result.LatencyProbeDuration = latencyProbeDuration.MustCurryWith(presetLabels)
+	result.LatencyProbeErrors = latencyProbeErrors.MustCurryWith(presetLabels)
+
+	result.SyntheticProbeRequests = syntheticProbeRequests.MustCurryWith(presetLabels)
+	result.SyntheticProbeDuration = syntheticProbeDuration.MustCurryWith(presetLabels)
+	result.SyntheticProbeErrors = syntheticProbeErrors.MustCurryWith(presetLabels)
+
+	result.OutlierEjected = outlierEjected.MustCurryWith(presetLabels)
+	result.OutlierEjections = outlierEjections.MustCurryWith(presetLabels)
+
 	result.CacheReadDuration = cacheReadDuration.MustCurryWith(presetLabels)
 	result.CacheWriteDuration = cacheWriteDuration.MustCurryWith(presetLabels)
 	result.CacheRequestsInFlight = cacheQueryCacheRequestsInFlight.MustCurryWith(presetLabels)
 	result.CacheErrors = cacheErrors.MustCurryWith(presetLabels)
+	result.CacheInvalidations = cacheInvalidations.MustCurryWith(presetLabels)
+	result.CacheStaleResponses = cacheStaleResponses.MustCurryWith(presetLabels)
+	result.CacheRevalidations = cacheRevalidations.MustCurryWith(presetLabels)
 
 	return result
 }
@@ -20,6 +20,52 @@ func (_m *ErrorLatencyChecker) EXPECT() *ErrorLatencyChecker_Expecter {
 	return &ErrorLatencyChecker_Expecter{mock: &_m.Mock}
 }
 
+// IsAdmitted provides a mock function with given fields: methods
+func (_m *ErrorLatencyChecker) IsAdmitted(methods []string) bool {
+	ret := _m.Called(methods)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsAdmitted")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func([]string) bool); ok {
+		r0 = rf(methods)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// ErrorLatencyChecker_IsAdmitted_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsAdmitted'
+type ErrorLatencyChecker_IsAdmitted_Call struct {
+	*mock.Call
+}
+
+// IsAdmitted is a helper method to define mock.On call
+//   - methods []string
+func (_e *ErrorLatencyChecker_Expecter) IsAdmitted(methods interface{}) *ErrorLatencyChecker_IsAdmitted_Call {
+	return &ErrorLatencyChecker_IsAdmitted_Call{Call: _e.mock.On("IsAdmitted", methods)}
+}
+
+func (_c *ErrorLatencyChecker_IsAdmitted_Call) Run(run func(methods []string)) *ErrorLatencyChecker_IsAdmitted_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]string))
+	})
+	return _c
+}
+
+func (_c *ErrorLatencyChecker_IsAdmitted_Call) Return(_a0 bool) *ErrorLatencyChecker_IsAdmitted_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ErrorLatencyChecker_IsAdmitted_Call) RunAndReturn(run func([]string) bool) *ErrorLatencyChecker_IsAdmitted_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // IsPassing provides a mock function with given fields: methods
 func (_m *ErrorLatencyChecker) IsPassing(methods []string) bool {
 	ret := _m.Called(methods)
@@ -9,6 +9,8 @@ import (
 
 	ethereum "github.com/ethereum/go-ethereum"
 
+	json "encoding/json"
+
 	mock "github.com/stretchr/testify/mock"
 
 	time "time"
@@ -29,6 +31,98 @@ func (_m *EthClient) EXPECT() *EthClient_Expecter {
 	return &EthClient_Expecter{mock: &_m.Mock}
 }
 
+// CallRaw provides a mock function with given fields: ctx, method, params
+func (_m *EthClient) CallRaw(ctx context.Context, method string, params []interface{}) (json.RawMessage, error) {
+	ret := _m.Called(ctx, method, params)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CallRaw")
+	}
+
+	var r0 json.RawMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []interface{}) (json.RawMessage, error)); ok {
+		return rf(ctx, method, params)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []interface{}) json.RawMessage); ok {
+		r0 = rf(ctx, method, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(json.RawMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []interface{}) error); ok {
+		r1 = rf(ctx, method, params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EthClient_CallRaw_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CallRaw'
+type EthClient_CallRaw_Call struct {
+	*mock.Call
+}
+
+// CallRaw is a helper method to define mock.On call
+//   - ctx context.Context
+//   - method string
+//   - params []interface{}
+func (_e *EthClient_Expecter) CallRaw(ctx interface{}, method interface{}, params interface{}) *EthClient_CallRaw_Call {
+	return &EthClient_CallRaw_Call{Call: _e.mock.On("CallRaw", ctx, method, params)}
+}
+
+func (_c *EthClient_CallRaw_Call) Run(run func(ctx context.Context, method string, params []interface{})) *EthClient_CallRaw_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].([]interface{}))
+	})
+	return _c
+}
+
+func (_c *EthClient_CallRaw_Call) Return(_a0 json.RawMessage, _a1 error) *EthClient_CallRaw_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *EthClient_CallRaw_Call) RunAndReturn(run func(context.Context, string, []interface{}) (json.RawMessage, error)) *EthClient_CallRaw_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Close provides a mock function with given fields:
+func (_m *EthClient) Close() {
+	_m.Called()
+}
+
+// EthClient_Close_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Close'
+type EthClient_Close_Call struct {
+	*mock.Call
+}
+
+// Close is a helper method to define mock.On call
+func (_e *EthClient_Expecter) Close() *EthClient_Close_Call {
+	return &EthClient_Close_Call{Call: _e.mock.On("Close")}
+}
+
+func (_c *EthClient_Close_Call) Run(run func()) *EthClient_Close_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *EthClient_Close_Call) Return() *EthClient_Close_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *EthClient_Close_Call) RunAndReturn(run func()) *EthClient_Close_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // HeaderByNumber provides a mock function with given fields: ctx, number
 func (_m *EthClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
 	ret := _m.Called(ctx, number)
@@ -144,9 +238,9 @@ func (_c *EthClient_PeerCount_Call) RunAndReturn(run func(context.Context) (uint
 	return _c
 }
 
-// RecordLatency provides a mock function with given fields: ctx, method
-func (_m *EthClient) RecordLatency(ctx context.Context, method string) (time.Duration, error) {
-	ret := _m.Called(ctx, method)
+// RecordLatency provides a mock function with given fields: ctx, method, params
+func (_m *EthClient) RecordLatency(ctx context.Context, method string, params []interface{}) (time.Duration, error) {
+	ret := _m.Called(ctx, method, params)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RecordLatency")
@@ -154,17 +248,17 @@ func (_m *EthClient) RecordLatency(ctx context.Context, method string) (time.Dur
 
 	var r0 time.Duration
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (time.Duration, error)); ok {
-		return rf(ctx, method)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []interface{}) (time.Duration, error)); ok {
+		return rf(ctx, method, params)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) time.Duration); ok {
-		r0 = rf(ctx, method)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []interface{}) time.Duration); ok {
+		r0 = rf(ctx, method, params)
 	} else {
 		r0 = ret.Get(0).(time.Duration)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, method)
+	if rf, ok := ret.Get(1).(func(context.Context, string, []interface{}) error); ok {
+		r1 = rf(ctx, method, params)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -180,13 +274,14 @@ type EthClient_RecordLatency_Call struct {
 // RecordLatency is a helper method to define mock.On call
 //   - ctx context.Context
 //   - method string
-func (_e *EthClient_Expecter) RecordLatency(ctx interface{}, method interface{}) *EthClient_RecordLatency_Call {
-	return &EthClient_RecordLatency_Call{Call: _e.mock.On("RecordLatency", ctx, method)}
+//   - params []interface{}
+func (_e *EthClient_Expecter) RecordLatency(ctx interface{}, method interface{}, params interface{}) *EthClient_RecordLatency_Call {
+	return &EthClient_RecordLatency_Call{Call: _e.mock.On("RecordLatency", ctx, method, params)}
 }
 
-func (_c *EthClient_RecordLatency_Call) Run(run func(ctx context.Context, method string)) *EthClient_RecordLatency_Call {
+func (_c *EthClient_RecordLatency_Call) Run(run func(ctx context.Context, method string, params []interface{})) *EthClient_RecordLatency_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].([]interface{}))
 	})
 	return _c
 }
@@ -196,7 +291,7 @@ func (_c *EthClient_RecordLatency_Call) Return(_a0 time.Duration, _a1 error) *Et
 	return _c
 }
 
-func (_c *EthClient_RecordLatency_Call) RunAndReturn(run func(context.Context, string) (time.Duration, error)) *EthClient_RecordLatency_Call {
+func (_c *EthClient_RecordLatency_Call) RunAndReturn(run func(context.Context, string, []interface{}) (time.Duration, error)) *EthClient_RecordLatency_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -22,6 +22,96 @@ func (_m *Router) EXPECT() *Router_Expecter {
 	return &Router_Expecter{mock: &_m.Mock}
 }
 
+// GetChainName provides a mock function with given fields:
+func (_m *Router) GetChainName() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetChainName")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Router_GetChainName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetChainName'
+type Router_GetChainName_Call struct {
+	*mock.Call
+}
+
+// GetChainName is a helper method to define mock.On call
+func (_e *Router_Expecter) GetChainName() *Router_GetChainName_Call {
+	return &Router_GetChainName_Call{Call: _e.mock.On("GetChainName")}
+}
+
+func (_c *Router_GetChainName_Call) Run(run func()) *Router_GetChainName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Router_GetChainName_Call) Return(_a0 string) *Router_GetChainName_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Router_GetChainName_Call) RunAndReturn(run func() string) *Router_GetChainName_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsHeadStale provides a mock function with given fields:
+func (_m *Router) IsHeadStale() bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsHeadStale")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Router_IsHeadStale_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsHeadStale'
+type Router_IsHeadStale_Call struct {
+	*mock.Call
+}
+
+// IsHeadStale is a helper method to define mock.On call
+func (_e *Router_Expecter) IsHeadStale() *Router_IsHeadStale_Call {
+	return &Router_IsHeadStale_Call{Call: _e.mock.On("IsHeadStale")}
+}
+
+func (_c *Router_IsHeadStale_Call) Run(run func()) *Router_IsHeadStale_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *Router_IsHeadStale_Call) Return(_a0 bool) *Router_IsHeadStale_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Router_IsHeadStale_Call) RunAndReturn(run func() bool) *Router_IsHeadStale_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // IsInitialized provides a mock function with given fields:
 func (_m *Router) IsInitialized() bool {
 	ret := _m.Called()
@@ -1,6 +1,7 @@
 package route
 
 import (
+	"github.com/satsuma-data/node-gateway/internal/checks"
 	"github.com/satsuma-data/node-gateway/internal/metadata"
 	"github.com/satsuma-data/node-gateway/internal/types"
 	"go.uber.org/zap"
@@ -11,6 +12,8 @@ type AlwaysRouteFilteringStrategy struct {
 	Logger           *zap.Logger
 	NodeFilters      []NodeFilter
 	RemovableFilters []NodeFilterType
+	// Admits upstreams that are recovering from a ban in proportion to their share of traffic. Nil to admit all.
+	HealthCheckManager checks.HealthCheckManager
 }
 
 func (s *AlwaysRouteFilteringStrategy) RouteNextRequest(
@@ -33,7 +36,8 @@ func (s *AlwaysRouteFilteringStrategy) RouteNextRequest(
 
 		// If there is at least one healthy upstream, route using the backing strategy.
 		if len(upstreams) > 0 {
-			return s.BackingStrategy.RouteNextRequest(upstreams, requestMetadata)
+			admittedUpstreams := admitUpstreams(upstreams, requestMetadata, s.HealthCheckManager, s.Logger)
+			return s.BackingStrategy.RouteNextRequest(admittedUpstreams, requestMetadata)
 		}
 
 		// There are no more filters to remove and no healthy upstreams found, so give up.
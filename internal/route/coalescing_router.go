@@ -0,0 +1,118 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/satsuma-data/node-gateway/internal/cache"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/util"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// CoalescingRouter makes identical in-flight requests for eligible methods, from any client, share a single routed
+// request. Each requester gets the shared response with its own ID. Batches are routed as is.
+type CoalescingRouter struct {
+	Router
+	config           *config.CoalescingConfig
+	metricsContainer *metrics.Container
+	logger           *zap.Logger
+	requestGroup     singleflight.Group
+}
+
+// coalescedResponse is the shared result of a routed request.
+type coalescedResponse struct {
+	respBody   jsonrpc.ResponseBody
+	upstreamID string
+}
+
+func NewCoalescingRouter(
+	router Router,
+	coalescingConfig *config.CoalescingConfig,
+	metricsContainer *metrics.Container,
+	logger *zap.Logger,
+) Router {
+	return &CoalescingRouter{
+		Router:           router,
+		config:           coalescingConfig,
+		metricsContainer: metricsContainer,
+		logger:           logger,
+	}
+}
+
+func (r *CoalescingRouter) Route(
+	ctx context.Context,
+	requestBody jsonrpc.RequestBody,
+) (string, jsonrpc.ResponseBody, error) {
+	singleRequestBody, ok := requestBody.(*jsonrpc.SingleRequestBody)
+	if !ok || singleRequestBody.ID == nil || !r.config.IsEligible(singleRequestBody.Method) {
+		return r.Router.Route(ctx, requestBody)
+	}
+
+	// Set by the shared call iff this request started it. Read only after the shared call has completed.
+	var isLeader atomic.Bool
+
+	key := cache.CreateRequestKey(r.GetChainName(), *singleRequestBody)
+
+	resultChan := r.requestGroup.DoChan(key, func() (any, error) {
+		isLeader.Store(true)
+
+		// The request is shared, so it must not be canceled when the client that happened to send it first goes away.
+		// It is bounded by its own timeout instead, so that a hung upstream cannot hold the requests waiting for it.
+		sharedCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.config.GetTimeout())
+		defer cancel()
+
+		upstreamID, respBody, err := r.Router.Route(sharedCtx, requestBody)
+
+		return coalescedResponse{respBody: respBody, upstreamID: upstreamID}, err
+	})
+
+	var result singleflight.Result
+
+	select {
+	case result = <-resultChan:
+	case <-ctx.Done():
+		// Stop waiting, the shared call carries on for the other requesters.
+		return "", nil, ctx.Err()
+	}
+
+	response, _ := result.Val.(coalescedResponse)
+	err := result.Err
+
+	if isLeader.Load() {
+		return response.upstreamID, response.respBody, err
+	}
+
+	// The raw content of an undecodable response is served as is, with the leader's request ID, so it is not shared.
+	var decodeError *jsonrpc.DecodeError
+	if errors.As(err, &decodeError) {
+		return r.Router.Route(ctx, requestBody)
+	}
+
+	r.metricsContainer.CoalescedRequestsTotal.WithLabelValues(util.GetClientFromContext(ctx), singleRequestBody.Method).Inc()
+	r.logger.Debug("Coalesced request with an identical in-flight request.", zap.Any("request", requestBody))
+
+	if staleResponseError, ok := err.(*StaleResponseError); ok {
+		err = &StaleResponseError{staleResponseError.err, withRequestID(staleResponseError.ResponseBody, singleRequestBody)}
+	}
+
+	return response.upstreamID, withRequestID(response.respBody, singleRequestBody), err
+}
+
+// withRequestID returns a copy of the response to a coalesced request, with the ID and version of the given request.
+func withRequestID(respBody jsonrpc.ResponseBody, requestBody *jsonrpc.SingleRequestBody) jsonrpc.ResponseBody {
+	singleRespBody, ok := respBody.(*jsonrpc.SingleResponseBody)
+	if !ok {
+		return respBody
+	}
+
+	respBodyCopy := *singleRespBody
+	respBodyCopy.ID = *requestBody.ID
+	respBodyCopy.JSONRPC = requestBody.JSONRPCVersion
+
+	return &respBodyCopy
+}
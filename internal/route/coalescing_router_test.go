@@ -0,0 +1,222 @@
+package route
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func newCoalescingTestRequest(id int64, method string) *jsonrpc.SingleRequestBody {
+	return &jsonrpc.SingleRequestBody{ID: lo.ToPtr(id), JSONRPCVersion: "2.0", Method: method, Params: []any{"0x01"}}
+}
+
+func TestCoalescingRouter_Route(t *testing.T) {
+	routerMock := mocks.NewRouter(t)
+	routerMock.EXPECT().GetChainName().Return("mainnet")
+
+	var routedRequests atomic.Int32
+
+	releaseRoute := make(chan struct{})
+	routerMock.EXPECT().Route(mock.Anything, mock.Anything).RunAndReturn(
+		func(_ context.Context, requestBody jsonrpc.RequestBody) (string, jsonrpc.ResponseBody, error) {
+			routedRequests.Add(1)
+			<-releaseRoute
+
+			return "geth", &jsonrpc.SingleResponseBody{
+				ID:      *requestBody.(*jsonrpc.SingleRequestBody).ID,
+				JSONRPC: "2.0",
+				Result:  json.RawMessage(`"0x2a"`),
+			}, nil
+		})
+
+	router := NewCoalescingRouter(routerMock, &config.CoalescingConfig{Methods: []string{"eth_get*"}}, metrics.NewContainer(config.TestChainName), zap.L())
+
+	var wg sync.WaitGroup
+
+	for id := int64(1); id <= 5; id++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			upstreamID, respBody, err := router.Route(context.Background(), newCoalescingTestRequest(id, "eth_getBalance"))
+
+			assert.NoError(t, err)
+			assert.Equal(t, "geth", upstreamID)
+			// Each requester gets the shared response with its own ID.
+			assert.Equal(t, &jsonrpc.SingleResponseBody{ID: id, JSONRPC: "2.0", Result: json.RawMessage(`"0x2a"`)}, respBody)
+		}()
+	}
+
+	// Give the requests time to join the in-flight one.
+	time.Sleep(20 * time.Millisecond)
+	close(releaseRoute)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), routedRequests.Load())
+}
+
+func TestCoalescingRouter_Route_StaleResponseError(t *testing.T) {
+	routerMock := mocks.NewRouter(t)
+	routerMock.EXPECT().GetChainName().Return("mainnet")
+
+	releaseRoute := make(chan struct{})
+	routerMock.EXPECT().Route(mock.Anything, mock.Anything).RunAndReturn(
+		func(_ context.Context, requestBody jsonrpc.RequestBody) (string, jsonrpc.ResponseBody, error) {
+			<-releaseRoute
+
+			staleRespBody := &jsonrpc.SingleResponseBody{ID: *requestBody.(*jsonrpc.SingleRequestBody).ID, JSONRPC: "2.0", Result: json.RawMessage(`"0x1"`)}
+
+			return "", nil, &StaleResponseError{DefaultNoHealthyUpstreamsError, staleRespBody}
+		}).Once()
+
+	router := NewCoalescingRouter(routerMock, &config.CoalescingConfig{Methods: []string{"eth_getBalance"}}, metrics.NewContainer(config.TestChainName), zap.L())
+
+	var wg sync.WaitGroup
+
+	for id := int64(1); id <= 2; id++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, _, err := router.Route(context.Background(), newCoalescingTestRequest(id, "eth_getBalance"))
+
+			var staleResponseError *StaleResponseError
+
+			assert.True(t, errors.As(err, &staleResponseError))
+			assert.Equal(t, id, staleResponseError.ResponseBody.GetSubResponses()[0].ID)
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(releaseRoute)
+	wg.Wait()
+}
+
+func TestCoalescingRouter_Route_NotEligible(t *testing.T) {
+	for _, testCase := range []struct {
+		testName    string
+		requestBody jsonrpc.RequestBody
+	}{
+		{"Method not eligible.", newCoalescingTestRequest(1, "eth_sendRawTransaction")},
+		{"Notification.", &jsonrpc.SingleRequestBody{JSONRPCVersion: "2.0", Method: "eth_getBalance"}},
+		{"Batch.", &jsonrpc.BatchRequestBody{Requests: []jsonrpc.SingleRequestBody{*newCoalescingTestRequest(1, "eth_getBalance")}}},
+	} {
+		t.Run(testCase.testName, func(t *testing.T) {
+			routerMock := mocks.NewRouter(t)
+			routerMock.EXPECT().Route(mock.Anything, testCase.requestBody).Return("geth", nil, nil).Times(2)
+
+			router := NewCoalescingRouter(routerMock, &config.CoalescingConfig{Methods: []string{"eth_get*"}}, metrics.NewContainer(config.TestChainName), zap.L())
+
+			for range 2 {
+				upstreamID, _, err := router.Route(context.Background(), testCase.requestBody)
+
+				assert.NoError(t, err)
+				assert.Equal(t, "geth", upstreamID)
+			}
+		})
+	}
+}
+
+func TestCoalescingRouter_Route_CanceledFollower(t *testing.T) {
+	routerMock := mocks.NewRouter(t)
+	routerMock.EXPECT().GetChainName().Return("mainnet")
+
+	releaseRoute := make(chan struct{})
+	routerMock.EXPECT().Route(mock.Anything, mock.Anything).RunAndReturn(
+		func(ctx context.Context, requestBody jsonrpc.RequestBody) (string, jsonrpc.ResponseBody, error) {
+			// The shared request is bounded by the coalescing timeout.
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline)
+
+			<-releaseRoute
+
+			return "geth", &jsonrpc.SingleResponseBody{ID: *requestBody.(*jsonrpc.SingleRequestBody).ID, JSONRPC: "2.0", Result: json.RawMessage(`"0x2a"`)}, nil
+		}).Once()
+
+	router := NewCoalescingRouter(routerMock, &config.CoalescingConfig{Methods: []string{"eth_getBalance"}}, metrics.NewContainer(config.TestChainName), zap.L())
+
+	leaderDone := make(chan struct{})
+
+	go func() {
+		defer close(leaderDone)
+
+		_, respBody, err := router.Route(context.Background(), newCoalescingTestRequest(1, "eth_getBalance"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, json.RawMessage(`"0x2a"`), respBody.GetSubResponses()[0].Result)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	// A follower whose client goes away stops waiting for the shared request.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, _, err := router.Route(ctx, newCoalescingTestRequest(2, "eth_getBalance"))
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(releaseRoute)
+	<-leaderDone
+}
+
+func TestCoalescingRouter_Route_DecodeError(t *testing.T) {
+	routerMock := mocks.NewRouter(t)
+	routerMock.EXPECT().GetChainName().Return("mainnet")
+
+	var routedRequests atomic.Int32
+
+	releaseRoute := make(chan struct{})
+	routerMock.EXPECT().Route(mock.Anything, mock.Anything).RunAndReturn(
+		func(_ context.Context, requestBody jsonrpc.RequestBody) (string, jsonrpc.ResponseBody, error) {
+			if routedRequests.Add(1) == 1 {
+				<-releaseRoute
+			}
+
+			content := fmt.Sprintf(`{"id":%d,"jsonrpc":"2.0","result":`, *requestBody.(*jsonrpc.SingleRequestBody).ID)
+
+			return "geth", nil, jsonrpc.NewDecodeError(errors.New("unexpected end of JSON input"), []byte(content))
+		})
+
+	router := NewCoalescingRouter(routerMock, &config.CoalescingConfig{Methods: []string{"eth_getBalance"}}, metrics.NewContainer(config.TestChainName), zap.L())
+
+	var wg sync.WaitGroup
+
+	for id := int64(1); id <= 2; id++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, _, err := router.Route(context.Background(), newCoalescingTestRequest(id, "eth_getBalance"))
+
+			// Each requester gets the raw content of its own request.
+			var decodeError *jsonrpc.DecodeError
+
+			assert.True(t, errors.As(err, &decodeError))
+			assert.Contains(t, string(decodeError.Content), fmt.Sprintf(`"id":%d,`, id))
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(releaseRoute)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), routedRequests.Load())
+}
@@ -0,0 +1,230 @@
+package route
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/satsuma-data/node-gateway/internal/checks"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/types"
+	"go.uber.org/zap"
+	"golang.org/x/exp/maps"
+)
+
+// FailoverHysteresisStrategy picks the priority group that should receive the request, and delegates picking an
+// upstream within that group to BackingStrategy. Unlike PriorityRoundRobinStrategy on its own, it does not switch
+// groups the moment a higher priority group becomes healthy again, or a group's healthy upstream count drops below its
+// minimum, as configured per group by config.FailoverConfig. Group health is evaluated once per health check round,
+// from the upstreams' health regardless of any request. Without any FailoverConfig, it behaves like
+// PriorityRoundRobinStrategy.
+type FailoverHysteresisStrategy struct {
+	activeSince             time.Time
+	BackingStrategy         RoutingStrategy
+	groupsByPriority        map[int]config.GroupConfig
+	healthyRoundsByPriority map[int]uint
+	metricsContainer        *metrics.Container
+	logger                  *zap.Logger
+	groupConfigs            []config.GroupConfig
+	lock                    sync.Mutex
+	activePriority          int
+	hasActiveGroup          bool
+}
+
+func NewFailoverHysteresisStrategy(
+	groupConfigs []config.GroupConfig,
+	backingStrategy RoutingStrategy,
+	metricsContainer *metrics.Container,
+	logger *zap.Logger,
+) *FailoverHysteresisStrategy {
+	groupsByPriority := make(map[int]config.GroupConfig)
+	for _, groupConfig := range groupConfigs {
+		groupsByPriority[groupConfig.Priority] = groupConfig
+	}
+
+	return &FailoverHysteresisStrategy{
+		BackingStrategy:         backingStrategy,
+		groupsByPriority:        groupsByPriority,
+		healthyRoundsByPriority: make(map[int]uint),
+		metricsContainer:        metricsContainer,
+		logger:                  logger,
+		groupConfigs:            groupConfigs,
+	}
+}
+
+// StartGroupHealthChecks evaluates the health of the groups every interval, from the upstreams that pass the node
+// filter for any request. Rounds are skipped until the health checks are initialized, as the node filters cannot look
+// up the upstreams' statuses before then.
+func (s *FailoverHysteresisStrategy) StartGroupHealthChecks(
+	upstreamConfigs []config.UpstreamConfig,
+	nodeFilter NodeFilter,
+	healthCheckManager checks.HealthCheckManager,
+	interval time.Duration,
+) {
+	upstreamsByPriority := groupUpstreamsByPriority(upstreamConfigs, s.groupConfigs)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if !healthCheckManager.IsInitialized() {
+				continue
+			}
+
+			healthyUpstreamsByPriority := filterUpstreams(upstreamsByPriority, metadata.RequestMetadata{}, []NodeFilter{nodeFilter}, s.logger)
+			s.UpdateGroupHealth(healthyUpstreamsByPriority)
+		}
+	}()
+}
+
+func (s *FailoverHysteresisStrategy) RouteNextRequest(
+	upstreamsByPriority types.PriorityToUpstreamsMap,
+	requestMetadata metadata.RequestMetadata,
+) (string, error) {
+	priority, ok := s.selectPriority(upstreamsByPriority)
+	if !ok {
+		return s.BackingStrategy.RouteNextRequest(upstreamsByPriority, requestMetadata)
+	}
+
+	return s.BackingStrategy.RouteNextRequest(
+		types.PriorityToUpstreamsMap{priority: upstreamsByPriority[priority]},
+		requestMetadata,
+	)
+}
+
+// selectPriority returns the priority of the active group if it has any upstreams that can serve the request. It
+// returns false otherwise, e.g. before the first health check round or if the request needs an archive node the
+// active group does not have, so that the request is routed to the highest priority group that can serve it, without
+// switching the active group.
+func (s *FailoverHysteresisStrategy) selectPriority(upstreamsByPriority types.PriorityToUpstreamsMap) (int, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.hasActiveGroup || len(upstreamsByPriority[s.activePriority]) == 0 {
+		return 0, false
+	}
+
+	return s.activePriority, true
+}
+
+// UpdateGroupHealth is a health check round. It switches the active group if needed, given the healthy upstreams of
+// each group.
+func (s *FailoverHysteresisStrategy) UpdateGroupHealth(healthyUpstreamsByPriority types.PriorityToUpstreamsMap) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	priorities := make([]int, 0, len(healthyUpstreamsByPriority))
+
+	for _, priority := range maps.Keys(healthyUpstreamsByPriority) {
+		if len(healthyUpstreamsByPriority[priority]) > 0 {
+			priorities = append(priorities, priority)
+		}
+	}
+
+	if len(priorities) == 0 {
+		// There is nothing to fail over to.
+		return
+	}
+
+	sort.Ints(priorities)
+
+	isHealthy := func(priority int) bool {
+		return len(healthyUpstreamsByPriority[priority]) >= s.getMinHealthyUpstreams(priority)
+	}
+
+	// The best group is the highest priority healthy one, falling back to the highest priority one with any upstreams.
+	bestPriority := priorities[0]
+
+	for _, priority := range priorities {
+		if isHealthy(priority) {
+			bestPriority = priority
+			break
+		}
+	}
+
+	if !s.hasActiveGroup {
+		s.activate(bestPriority)
+		return
+	}
+
+	isDwellTimeOver := time.Since(s.activeSince) >= s.getMinDwellTime(s.activePriority)
+
+	// Track for how many consecutive rounds each higher priority group has been healthy, and fail back to the
+	// highest priority one that has been healthy for long enough.
+	for priority := range s.healthyRoundsByPriority {
+		if !isHealthy(priority) {
+			delete(s.healthyRoundsByPriority, priority)
+		}
+	}
+
+	failbackPriority, shouldFailback := 0, false
+
+	for _, priority := range priorities {
+		if priority >= s.activePriority || !isHealthy(priority) {
+			continue
+		}
+
+		s.healthyRoundsByPriority[priority]++
+
+		if !shouldFailback && s.healthyRoundsByPriority[priority] >= s.getFailbackHealthyRounds(priority) {
+			failbackPriority, shouldFailback = priority, true
+		}
+	}
+
+	isActiveGroupEmpty := len(healthyUpstreamsByPriority[s.activePriority]) == 0
+
+	switch {
+	case shouldFailback && isDwellTimeOver:
+		s.logger.Info("Failing back to higher priority group.", zap.Int("fromPriority", s.activePriority), zap.Int("toPriority", failbackPriority))
+		s.activate(failbackPriority)
+	case !isHealthy(s.activePriority) && (isHealthy(bestPriority) || isActiveGroupEmpty) && isDwellTimeOver:
+		s.logger.Info("Failing over from unhealthy group.", zap.Int("fromPriority", s.activePriority), zap.Int("toPriority", bestPriority))
+		s.activate(bestPriority)
+	}
+}
+
+// activate makes the group with the given priority the active group. Requires external locking.
+func (s *FailoverHysteresisStrategy) activate(priority int) {
+	if s.hasActiveGroup {
+		s.setActiveGroupMetric(s.activePriority, 0)
+	}
+
+	s.activePriority = priority
+	s.activeSince = time.Now()
+	s.hasActiveGroup = true
+	s.healthyRoundsByPriority = make(map[int]uint)
+
+	s.setActiveGroupMetric(priority, 1)
+}
+
+func (s *FailoverHysteresisStrategy) setActiveGroupMetric(priority int, value float64) {
+	s.metricsContainer.ActiveGroup.WithLabelValues(s.groupsByPriority[priority].ID, strconv.Itoa(priority)).Set(value)
+}
+
+func (s *FailoverHysteresisStrategy) getMinDwellTime(priority int) time.Duration {
+	if failoverConfig := s.groupsByPriority[priority].Failover; failoverConfig != nil {
+		return failoverConfig.MinDwellTime
+	}
+
+	return 0
+}
+
+func (s *FailoverHysteresisStrategy) getFailbackHealthyRounds(priority int) uint {
+	if failoverConfig := s.groupsByPriority[priority].Failover; failoverConfig != nil {
+		return failoverConfig.FailbackHealthyRounds
+	}
+
+	return 0
+}
+
+func (s *FailoverHysteresisStrategy) getMinHealthyUpstreams(priority int) int {
+	if failoverConfig := s.groupsByPriority[priority].Failover; failoverConfig != nil && failoverConfig.MinHealthyUpstreams > 0 {
+		return failoverConfig.MinHealthyUpstreams
+	}
+
+	return 1
+}
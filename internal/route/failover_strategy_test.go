@@ -0,0 +1,193 @@
+package route
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/mocks"
+	"github.com/satsuma-data/node-gateway/internal/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+var (
+	healthyUpstreams = types.PriorityToUpstreamsMap{
+		0: {cfg("primary1"), cfg("primary2")},
+		1: {cfg("fallback1")},
+	}
+	primaryDownUpstreams = types.PriorityToUpstreamsMap{
+		1: {cfg("fallback1")},
+	}
+	primaryDegradedUpstreams = types.PriorityToUpstreamsMap{
+		0: {cfg("primary1")},
+		1: {cfg("fallback1")},
+	}
+)
+
+func newTestFailoverHysteresisStrategy(primaryFailoverConfig, fallbackFailoverConfig *config.FailoverConfig) *FailoverHysteresisStrategy {
+	return NewFailoverHysteresisStrategy(
+		[]config.GroupConfig{
+			{ID: "primary", Priority: 0, Failover: primaryFailoverConfig},
+			{ID: "fallback", Priority: 1, Failover: fallbackFailoverConfig},
+		},
+		NewPriorityRoundRobinStrategy(zap.L()),
+		metrics.NewContainer(config.TestChainName),
+		zap.L(),
+	)
+}
+
+func assertRoutedTo(t *testing.T, strategy RoutingStrategy, upstreams types.PriorityToUpstreamsMap, expectedUpstreamIDs ...string) {
+	t.Helper()
+
+	upstreamID, err := strategy.RouteNextRequest(upstreams, metadata.RequestMetadata{})
+	assert.NoError(t, err)
+	assert.Contains(t, expectedUpstreamIDs, upstreamID)
+}
+
+// assertRoutedAfterRound runs a health check round in which the given upstreams are healthy, and asserts that a request
+// any of them can serve is routed to one of the expected upstreams.
+func assertRoutedAfterRound(t *testing.T, strategy *FailoverHysteresisStrategy, upstreams types.PriorityToUpstreamsMap, expectedUpstreamIDs ...string) {
+	t.Helper()
+
+	strategy.UpdateGroupHealth(upstreams)
+	assertRoutedTo(t, strategy, upstreams, expectedUpstreamIDs...)
+}
+
+func TestFailoverHysteresisStrategy_NoFailoverConfig(t *testing.T) {
+	strategy := newTestFailoverHysteresisStrategy(nil, nil)
+
+	// Before the first round, requests go to the highest priority group.
+	assertRoutedTo(t, strategy, healthyUpstreams, "primary1", "primary2")
+
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "primary1", "primary2")
+	assertRoutedAfterRound(t, strategy, primaryDegradedUpstreams, "primary1")
+	assertRoutedAfterRound(t, strategy, primaryDownUpstreams, "fallback1")
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "primary1", "primary2")
+
+	upstreamID, err := strategy.RouteNextRequest(types.PriorityToUpstreamsMap{0: {}, 1: {}}, metadata.RequestMetadata{})
+	assert.Equal(t, "", upstreamID)
+	assert.True(t, errors.Is(err, DefaultNoHealthyUpstreamsError))
+}
+
+func TestFailoverHysteresisStrategy_FailbackHealthyRounds(t *testing.T) {
+	strategy := newTestFailoverHysteresisStrategy(&config.FailoverConfig{FailbackHealthyRounds: 3}, nil)
+
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "primary1", "primary2")
+	assertRoutedAfterRound(t, strategy, primaryDownUpstreams, "fallback1")
+
+	// The primary group must be healthy for 3 consecutive rounds before traffic fails back to it.
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "fallback1")
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "fallback1")
+	assertRoutedAfterRound(t, strategy, primaryDownUpstreams, "fallback1")
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "fallback1")
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "fallback1")
+
+	// Requests between rounds do not count as rounds.
+	for range 10 {
+		assertRoutedTo(t, strategy, healthyUpstreams, "fallback1")
+	}
+
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "primary1", "primary2")
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(strategy.metricsContainer.ActiveGroup.WithLabelValues("primary", "0")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(strategy.metricsContainer.ActiveGroup.WithLabelValues("fallback", "1")))
+}
+
+func TestFailoverHysteresisStrategy_MinDwellTime(t *testing.T) {
+	minDwellTime := 50 * time.Millisecond
+	strategy := newTestFailoverHysteresisStrategy(nil, &config.FailoverConfig{MinDwellTime: minDwellTime})
+
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "primary1", "primary2")
+	assertRoutedAfterRound(t, strategy, primaryDownUpstreams, "fallback1")
+	assert.Equal(t, 1.0, testutil.ToFloat64(strategy.metricsContainer.ActiveGroup.WithLabelValues("fallback", "1")))
+
+	// The fallback group stays active until its dwell time is over.
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "fallback1")
+
+	time.Sleep(minDwellTime)
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "primary1", "primary2")
+	assert.Equal(t, 0.0, testutil.ToFloat64(strategy.metricsContainer.ActiveGroup.WithLabelValues("fallback", "1")))
+}
+
+func TestFailoverHysteresisStrategy_MinHealthyUpstreams(t *testing.T) {
+	strategy := newTestFailoverHysteresisStrategy(&config.FailoverConfig{MinHealthyUpstreams: 2}, nil)
+
+	// A single healthy upstream is not enough for the primary group to be healthy.
+	assertRoutedAfterRound(t, strategy, primaryDegradedUpstreams, "fallback1")
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "primary1", "primary2")
+	assertRoutedAfterRound(t, strategy, primaryDegradedUpstreams, "fallback1")
+}
+
+func TestFailoverHysteresisStrategy_ActiveGroupEmptyForRequest(t *testing.T) {
+	strategy := newTestFailoverHysteresisStrategy(nil, nil)
+
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "primary1", "primary2")
+
+	// A request no upstream in the active group can serve goes to the fallback group, without failing over to it.
+	assertRoutedTo(t, strategy, primaryDownUpstreams, "fallback1")
+	assertRoutedTo(t, strategy, healthyUpstreams, "primary1", "primary2")
+	assert.Equal(t, 1.0, testutil.ToFloat64(strategy.metricsContainer.ActiveGroup.WithLabelValues("primary", "0")))
+}
+
+func TestFailoverHysteresisStrategy_NoHealthyGroup(t *testing.T) {
+	strategy := newTestFailoverHysteresisStrategy(nil, nil)
+
+	assertRoutedAfterRound(t, strategy, healthyUpstreams, "primary1", "primary2")
+
+	// Without any healthy upstream, the active group is kept.
+	strategy.UpdateGroupHealth(types.PriorityToUpstreamsMap{})
+	assert.Equal(t, 1.0, testutil.ToFloat64(strategy.metricsContainer.ActiveGroup.WithLabelValues("primary", "0")))
+}
+
+// countingFilter passes every upstream, and counts how many times it is applied.
+type countingFilter struct {
+	numApplied atomic.Int32
+}
+
+func (f *countingFilter) Apply(metadata.RequestMetadata, *config.UpstreamConfig, int) bool {
+	f.numApplied.Add(1)
+	return true
+}
+
+func TestFailoverHysteresisStrategy_StartGroupHealthChecksBeforeInitialization(t *testing.T) {
+	strategy := newTestFailoverHysteresisStrategy(nil, nil)
+
+	var isInitialized atomic.Bool
+
+	var numInitializationChecks atomic.Int32
+
+	manager := mocks.NewHealthCheckManager(t)
+	manager.EXPECT().IsInitialized().RunAndReturn(func() bool {
+		numInitializationChecks.Add(1)
+		return isInitialized.Load()
+	})
+
+	filter := &countingFilter{}
+	upstreamConfigs := []config.UpstreamConfig{
+		{ID: "primary1", GroupID: "primary"},
+		{ID: "fallback1", GroupID: "fallback"},
+	}
+	strategy.StartGroupHealthChecks(upstreamConfigs, filter, manager, time.Millisecond)
+
+	// Rounds before the health checks are initialized must not apply the node filters, which would look up upstream
+	// statuses that do not exist yet.
+	assert.Eventually(t, func() bool { return numInitializationChecks.Load() >= 3 }, time.Second, time.Millisecond)
+	assert.Zero(t, filter.numApplied.Load())
+
+	_, hasActiveGroup := strategy.selectPriority(healthyUpstreams)
+	assert.False(t, hasActiveGroup)
+
+	isInitialized.Store(true)
+
+	assert.Eventually(t, func() bool {
+		priority, hasActiveGroup := strategy.selectPriority(healthyUpstreams)
+		return hasActiveGroup && priority == 0
+	}, time.Second, time.Millisecond)
+	assert.Positive(t, filter.numApplied.Load())
+}
@@ -1,6 +1,7 @@
 package route
 
 import (
+	"github.com/satsuma-data/node-gateway/internal/checks"
 	"github.com/satsuma-data/node-gateway/internal/config"
 	"github.com/satsuma-data/node-gateway/internal/metadata"
 	"github.com/satsuma-data/node-gateway/internal/types"
@@ -11,6 +12,8 @@ type FilteringRoutingStrategy struct {
 	NodeFilter      NodeFilter
 	BackingStrategy RoutingStrategy
 	Logger          *zap.Logger
+	// Admits upstreams that are recovering from a ban in proportion to their share of traffic. Nil to admit all.
+	HealthCheckManager checks.HealthCheckManager
 }
 
 func (s *FilteringRoutingStrategy) RouteNextRequest(
@@ -18,7 +21,9 @@ func (s *FilteringRoutingStrategy) RouteNextRequest(
 	requestMetadata metadata.RequestMetadata,
 ) (string, error) {
 	filteredUpstreams := s.filter(upstreamsByPriority, requestMetadata)
-	return s.BackingStrategy.RouteNextRequest(filteredUpstreams, requestMetadata)
+	admittedUpstreams := admitUpstreams(filteredUpstreams, requestMetadata, s.HealthCheckManager, s.Logger)
+
+	return s.BackingStrategy.RouteNextRequest(admittedUpstreams, requestMetadata)
 }
 
 func (s *FilteringRoutingStrategy) filter(
@@ -64,3 +69,42 @@ func filterUpstreams(
 
 	return priorityToHealthyUpstreams
 }
+
+// admitUpstreams drops the healthy upstreams that are recovering from a ban and were not admitted to serve this
+// request, so that they get a growing share of traffic. If no upstream is admitted, e.g. because the only healthy
+// upstream is recovering, all of them are kept.
+func admitUpstreams(
+	upstreamsByPriority types.PriorityToUpstreamsMap,
+	requestMetadata metadata.RequestMetadata,
+	healthCheckManager checks.HealthCheckManager,
+	logger *zap.Logger,
+) types.PriorityToUpstreamsMap {
+	if healthCheckManager == nil {
+		return upstreamsByPriority
+	}
+
+	admittedUpstreamsByPriority := make(types.PriorityToUpstreamsMap)
+
+	for priority, upstreamConfigs := range upstreamsByPriority {
+		admittedUpstreams := make([]*config.UpstreamConfig, 0, len(upstreamConfigs))
+
+		for _, upstreamConfig := range upstreamConfigs {
+			upstreamStatus := healthCheckManager.GetUpstreamStatus(upstreamConfig.ID)
+			if upstreamStatus.ErrorCheck.IsAdmitted(requestMetadata.Methods) && upstreamStatus.LatencyCheck.IsAdmitted(requestMetadata.Methods) {
+				admittedUpstreams = append(admittedUpstreams, upstreamConfig)
+			}
+		}
+
+		if len(admittedUpstreams) > 0 {
+			admittedUpstreamsByPriority[priority] = admittedUpstreams
+		}
+	}
+
+	if len(admittedUpstreamsByPriority) == 0 {
+		logger.Debug("No upstream admitted, routing to recovering upstreams.", zap.Any("upstreamsByPriority", upstreamsByPriority))
+
+		return upstreamsByPriority
+	}
+
+	return admittedUpstreamsByPriority
+}
@@ -0,0 +1,62 @@
+package route
+
+import (
+	"testing"
+
+	"github.com/satsuma-data/node-gateway/internal/config"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
+	"github.com/satsuma-data/node-gateway/internal/mocks"
+	"github.com/satsuma-data/node-gateway/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func newAdmissionCheck(t *testing.T, isAdmitted bool) *mocks.ErrorLatencyChecker {
+	t.Helper()
+
+	check := mocks.NewErrorLatencyChecker(t)
+	check.EXPECT().IsAdmitted(mock.Anything).Return(isAdmitted).Maybe()
+
+	return check
+}
+
+func TestFilteringRoutingStrategy_AdmitsRecoveringUpstreams(t *testing.T) {
+	upstream1Config := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID1}
+	upstream2Config := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID2}
+
+	for _, testCase := range []struct {
+		name                string
+		upstreamsByPriority types.PriorityToUpstreamsMap
+		isAdmitted1         bool
+		isAdmitted2         bool
+		expectedUpstreamID  string
+	}{
+		{"Only the upstream that is not admitted is dropped.", types.PriorityToUpstreamsMap{0: {upstream1Config, upstream2Config}}, false, true, UpstreamID2},
+		{"A lone recovering upstream is admitted.", types.PriorityToUpstreamsMap{0: {upstream1Config}}, false, false, UpstreamID1},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			manager := mocks.NewHealthCheckManager(t)
+			manager.EXPECT().GetUpstreamStatus(UpstreamID1).Return(&types.UpstreamStatus{
+				ErrorCheck:   newAdmissionCheck(t, testCase.isAdmitted1),
+				LatencyCheck: newAdmissionCheck(t, true),
+			})
+			manager.EXPECT().GetUpstreamStatus(UpstreamID2).Return(&types.UpstreamStatus{
+				ErrorCheck:   newAdmissionCheck(t, true),
+				LatencyCheck: newAdmissionCheck(t, testCase.isAdmitted2),
+			}).Maybe()
+
+			strategy := FilteringRoutingStrategy{
+				NodeFilter:         AlwaysPass{},
+				BackingStrategy:    NewPriorityRoundRobinStrategy(zap.L()),
+				Logger:             zap.L(),
+				HealthCheckManager: manager,
+			}
+
+			upstreamID, err := strategy.RouteNextRequest(testCase.upstreamsByPriority, metadata.RequestMetadata{})
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedUpstreamID, upstreamID)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package route
 import (
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/satsuma-data/node-gateway/internal/checks"
 	"github.com/satsuma-data/node-gateway/internal/config"
@@ -61,7 +62,6 @@ func (a *AndFilter) Apply(requestMetadata metadata.RequestMetadata, upstreamConf
 type HasEnoughPeers struct {
 	healthCheckManager checks.HealthCheckManager
 	logger             *zap.Logger
-	minimumPeerCount   uint64
 }
 
 func (f *HasEnoughPeers) Apply(_ metadata.RequestMetadata, upstreamConfig *config.UpstreamConfig, _ int) bool {
@@ -74,13 +74,14 @@ func (f *HasEnoughPeers) Apply(_ metadata.RequestMetadata, upstreamConfig *confi
 			return false
 		}
 
-		if peerCheck.PeerCount >= f.minimumPeerCount {
+		minimumPeerCount := upstreamConfig.HealthCheckConfig.GetMinPeerCount()
+		if peerCheck.PeerCount >= minimumPeerCount {
 			return true
 		}
 
 		f.logger.Debug("HasEnoughPeers failed.",
 			zap.String("UpstreamID", upstreamConfig.ID),
-			zap.Uint64("MinimumPeerCount", f.minimumPeerCount),
+			zap.Uint64("MinimumPeerCount", minimumPeerCount),
 			zap.Uint64("ActualPeerCount", peerCheck.PeerCount),
 		)
 
@@ -90,6 +91,64 @@ func (f *HasEnoughPeers) Apply(_ metadata.RequestMetadata, upstreamConfig *confi
 	return true
 }
 
+// AreSyntheticProbesPassing filters out upstreams with a failing synthetic probe whose method scope includes any of the
+// request's methods.
+type AreSyntheticProbesPassing struct {
+	healthCheckManager checks.HealthCheckManager
+	logger             *zap.Logger
+}
+
+func (f *AreSyntheticProbesPassing) Apply(requestMetadata metadata.RequestMetadata, upstreamConfig *config.UpstreamConfig, _ int) bool {
+	upstreamStatus := f.healthCheckManager.GetUpstreamStatus(upstreamConfig.ID)
+
+	for _, probeCheck := range upstreamStatus.SyntheticProbeChecks {
+		if !probeCheck.IsInScope(requestMetadata.Methods) || probeCheck.IsPassing() {
+			continue
+		}
+
+		f.logger.Debug("AreSyntheticProbesPassing failed.",
+			zap.String("UpstreamID", upstreamConfig.ID),
+			zap.Any("RequestMetadata", requestMetadata),
+			zap.Error(probeCheck.GetError()),
+		)
+
+		return false
+	}
+
+	return true
+}
+
+// IsClientAllowed filters out upstreams that do not meet the chain's client requirements for any of the request's
+// methods, including upstreams whose client is not known yet.
+type IsClientAllowed struct {
+	healthCheckManager checks.HealthCheckManager
+	logger             *zap.Logger
+	clientRequirements []config.ClientRequirementConfig
+}
+
+func (f *IsClientAllowed) Apply(requestMetadata metadata.RequestMetadata, upstreamConfig *config.UpstreamConfig, _ int) bool {
+	clientVersionCheck := f.healthCheckManager.GetUpstreamStatus(upstreamConfig.ID).ClientVersionCheck
+
+	for i := range f.clientRequirements {
+		requirement := &f.clientRequirements[i]
+		if !requirement.IsInScope(requestMetadata.Methods) {
+			continue
+		}
+
+		if clientVersionCheck == nil || !clientVersionCheck.MeetsRequirement(requirement) {
+			f.logger.Debug("IsClientAllowed failed.",
+				zap.String("UpstreamID", upstreamConfig.ID),
+				zap.Any("RequestMetadata", requestMetadata),
+				zap.Any("ClientRequirement", requirement),
+			)
+
+			return false
+		}
+	}
+
+	return true
+}
+
 type IsErrorRateAcceptable struct {
 	HealthCheckManager checks.HealthCheckManager
 	MetricsContainer   *metrics.Container
@@ -116,6 +175,24 @@ func (f *IsErrorRateAcceptable) Apply(requestMetadata metadata.RequestMetadata,
 	return isPassing
 }
 
+// IsNotOutlier passes iff the upstream is not ejected for being an outlier compared to its group, or outlier detection
+// is disabled.
+type IsNotOutlier struct {
+	HealthCheckManager checks.HealthCheckManager
+	Logger             *zap.Logger
+}
+
+func (f *IsNotOutlier) Apply(requestMetadata metadata.RequestMetadata, upstreamConfig *config.UpstreamConfig, _ int) bool {
+	upstreamStatus := f.HealthCheckManager.GetUpstreamStatus(upstreamConfig.ID)
+	if upstreamStatus.OutlierCheck == nil || upstreamStatus.OutlierCheck.IsPassing() {
+		return true
+	}
+
+	f.Logger.Debug("IsNotOutlier failed.", zap.String("UpstreamID", upstreamConfig.ID), zap.Any("RequestMetadata", requestMetadata))
+
+	return false
+}
+
 type IsLatencyAcceptable struct {
 	HealthCheckManager checks.HealthCheckManager
 	MetricsContainer   *metrics.Container
@@ -159,11 +236,13 @@ func (f *IsLatencyAcceptable) Apply(requestMetadata metadata.RequestMetadata, up
 type IsCloseToGlobalMaxHeight struct {
 	chainMetadataStore *metadata.ChainMetadataStore
 	logger             *zap.Logger
-	maxBlocksBehind    uint64
+	maxBlocksBehind    uint64 // Used unless the upstream's health check policy overrides it.
+	// How many blocks the safe and finalized heads can be behind the max across all upstreams.
+	maxFinalityBlocksBehind uint64
 }
 
 func (f *IsCloseToGlobalMaxHeight) Apply(
-	_ metadata.RequestMetadata,
+	requestMetadata metadata.RequestMetadata,
 	upstreamConfig *config.UpstreamConfig,
 	_ int,
 ) bool {
@@ -178,9 +257,14 @@ func (f *IsCloseToGlobalMaxHeight) Apply(
 		return false
 	}
 
-	isClose := status.BlockHeight+f.maxBlocksBehind >= status.GlobalMaxBlockHeight
+	maxBlocksBehind := f.maxBlocksBehind
+	if upstreamConfig.HealthCheckConfig.MaxBlocksBehind != nil {
+		maxBlocksBehind = *upstreamConfig.HealthCheckConfig.MaxBlocksBehind
+	}
+
+	isClose := status.BlockHeight+maxBlocksBehind >= status.GlobalMaxBlockHeight
 	if isClose {
-		return true
+		return isAtMaxFinalityHeight(requestMetadata, &status, false, f.maxFinalityBlocksBehind, f.logger)
 	}
 
 	f.logger.Debug(
@@ -193,12 +277,81 @@ func (f *IsCloseToGlobalMaxHeight) Apply(
 	return false
 }
 
+// IsHeadFresh filters out upstreams whose latest block is older than maxHeadAge, which IsCloseToGlobalMaxHeight cannot
+// detect if all upstreams stall at the same height. If no upstream has a fresh head, the whole chain is stale and no
+// upstream is filtered out, since the stale heads are still the best available.
+type IsHeadFresh struct {
+	chainMetadataStore *metadata.ChainMetadataStore
+	logger             *zap.Logger
+	maxHeadAge         time.Duration
+}
+
+func (f *IsHeadFresh) Apply(_ metadata.RequestMetadata, upstreamConfig *config.UpstreamConfig, _ int) bool {
+	if f.maxHeadAge == 0 {
+		return true
+	}
+
+	status := f.chainMetadataStore.GetBlockHeightStatus(upstreamConfig.GroupID, upstreamConfig.ID)
+
+	// Upstreams whose head has not been seen yet are left to the other filters.
+	if status.BlockTimestamp.IsZero() || time.Since(status.BlockTimestamp) <= f.maxHeadAge {
+		return true
+	}
+
+	if time.Since(status.GlobalMaxBlockTimestamp) > f.maxHeadAge {
+		f.logger.Debug("IsHeadFresh passing because no upstream has a fresh head.", zap.String("UpstreamID", upstreamConfig.ID))
+		return true
+	}
+
+	f.logger.Debug(
+		"Upstream head is stale!",
+		zap.String("UpstreamID", upstreamConfig.ID),
+		zap.Time("BlockTimestamp", status.BlockTimestamp),
+		zap.Time("GlobalMaxBlockTimestamp", status.GlobalMaxBlockTimestamp),
+		zap.Duration("MaxHeadAge", f.maxHeadAge),
+	)
+
+	return false
+}
+
+// IsHeadArrivalFast filters out upstreams that, on average, report new heads more than maxHeadArrivalDelay later than
+// the fastest upstream. The fastest upstream always passes.
+type IsHeadArrivalFast struct {
+	chainMetadataStore  *metadata.ChainMetadataStore
+	logger              *zap.Logger
+	maxHeadArrivalDelay time.Duration
+}
+
+func (f *IsHeadArrivalFast) Apply(_ metadata.RequestMetadata, upstreamConfig *config.UpstreamConfig, _ int) bool {
+	if f.maxHeadArrivalDelay == 0 {
+		return true
+	}
+
+	status := f.chainMetadataStore.GetBlockHeightStatus(upstreamConfig.GroupID, upstreamConfig.ID)
+
+	if status.HeadArrivalDelay <= status.MinHeadArrivalDelay+f.maxHeadArrivalDelay {
+		return true
+	}
+
+	f.logger.Debug(
+		"Upstream reports new heads too late!",
+		zap.String("UpstreamID", upstreamConfig.ID),
+		zap.Duration("HeadArrivalDelay", status.HeadArrivalDelay),
+		zap.Duration("MinHeadArrivalDelay", status.MinHeadArrivalDelay),
+		zap.Duration("MaxHeadArrivalDelay", f.maxHeadArrivalDelay),
+	)
+
+	return false
+}
+
 type IsAtMaxHeightForGroup struct {
 	chainMetadataStore *metadata.ChainMetadataStore
 	logger             *zap.Logger
+	// How many blocks the safe and finalized heads can be behind the max of the group.
+	maxFinalityBlocksBehind uint64
 }
 
-func (f *IsAtMaxHeightForGroup) Apply(_ metadata.RequestMetadata, upstreamConfig *config.UpstreamConfig, numUpstreamsInPriorityGroup int) bool {
+func (f *IsAtMaxHeightForGroup) Apply(requestMetadata metadata.RequestMetadata, upstreamConfig *config.UpstreamConfig, numUpstreamsInPriorityGroup int) bool {
 	status := f.chainMetadataStore.GetBlockHeightStatus(upstreamConfig.GroupID, upstreamConfig.ID)
 
 	if status.Error != nil {
@@ -222,7 +375,7 @@ func (f *IsAtMaxHeightForGroup) Apply(_ metadata.RequestMetadata, upstreamConfig
 	}
 
 	if status.BlockHeight >= status.GroupMaxBlockHeight {
-		return true
+		return isAtMaxFinalityHeight(requestMetadata, &status, true, f.maxFinalityBlocksBehind, f.logger)
 	}
 
 	f.logger.Debug(
@@ -235,6 +388,46 @@ func (f *IsAtMaxHeightForGroup) Apply(_ metadata.RequestMetadata, upstreamConfig
 	return false
 }
 
+// isAtMaxFinalityHeight returns true iff, for every finality tag (e.g. "safe" or "finalized") referenced by the
+// request, the upstream's head for that tag is at most maxBlocksBehind blocks behind the max head for that tag in its
+// group or across all upstreams. Upstreams whose head for the tag is unknown only pass if no upstream in scope reports
+// a head for the tag, e.g. because finality is not checked or not supported.
+func isAtMaxFinalityHeight(
+	requestMetadata metadata.RequestMetadata,
+	status *metadata.BlockHeightStatus,
+	compareToGroup bool,
+	maxBlocksBehind uint64,
+	logger *zap.Logger,
+) bool {
+	for _, tag := range metadata.FinalityBlockTags {
+		if !requestMetadata.HasBlockTag(tag) {
+			continue
+		}
+
+		blockHeight, groupMaxBlockHeight, globalMaxBlockHeight := status.GetFinalityHeights(tag)
+
+		maxBlockHeight := globalMaxBlockHeight
+		if compareToGroup {
+			maxBlockHeight = groupMaxBlockHeight
+		}
+
+		if maxBlockHeight != 0 && blockHeight+maxBlocksBehind < maxBlockHeight {
+			logger.Debug(
+				"Upstream finality head behind max height!",
+				zap.String("UpstreamID", status.UpstreamID),
+				zap.Any("BlockTag", tag),
+				zap.Uint64("UpstreamHeight", blockHeight),
+				zap.Uint64("MaxHeight", maxBlockHeight),
+				zap.Bool("ComparedToGroup", compareToGroup),
+			)
+
+			return false
+		}
+	}
+
+	return true
+}
+
 func isArchiveNodeMethod(method string) bool {
 	switch method {
 	case "eth_getBalance", "eth_getStorageAt", "eth_getTransactionCount", "eth_getCode", "eth_call", "eth_estimateGas":
@@ -313,7 +506,6 @@ func CreateSingleNodeFilter(
 		hasEnoughPeers := HasEnoughPeers{
 			healthCheckManager: manager,
 			logger:             logger,
-			minimumPeerCount:   checks.MinimumPeerCount,
 		}
 
 		return &AndFilter{
@@ -329,14 +521,39 @@ func CreateSingleNodeFilter(
 		}
 
 		return &IsCloseToGlobalMaxHeight{
-			chainMetadataStore: store,
-			logger:             logger,
-			maxBlocksBehind:    uint64(maxBlocksBehind), //nolint:gosec // ignore error
+			chainMetadataStore:      store,
+			logger:                  logger,
+			maxBlocksBehind:         uint64(maxBlocksBehind), //nolint:gosec // ignore error
+			maxFinalityBlocksBehind: routingConfig.MaxFinalityBlocksBehind,
 		}
 	case MaxHeightForGroup:
 		return &IsAtMaxHeightForGroup{
+			chainMetadataStore:      store,
+			logger:                  logger,
+			maxFinalityBlocksBehind: routingConfig.MaxFinalityBlocksBehind,
+		}
+	case HeadFresh:
+		return &IsHeadFresh{
 			chainMetadataStore: store,
 			logger:             logger,
+			maxHeadAge:         routingConfig.MaxHeadAge,
+		}
+	case HeadArrivalFast:
+		return &IsHeadArrivalFast{
+			chainMetadataStore:  store,
+			logger:              logger,
+			maxHeadArrivalDelay: routingConfig.MaxHeadArrivalDelay,
+		}
+	case ProbesPassing:
+		return &AreSyntheticProbesPassing{
+			healthCheckManager: manager,
+			logger:             logger,
+		}
+	case ClientAllowed:
+		return &IsClientAllowed{
+			healthCheckManager: manager,
+			logger:             logger,
+			clientRequirements: routingConfig.ClientRequirements,
 		}
 	case MethodsAllowed:
 		return &AreMethodsAllowed{logger: logger}
@@ -358,6 +575,10 @@ const (
 	MethodsAllowed      NodeFilterType = "methodsAllowed"
 	ErrorRateAcceptable NodeFilterType = "errorRateAcceptable"
 	LatencyAcceptable   NodeFilterType = "latencyAcceptable"
+	HeadFresh           NodeFilterType = "headFresh"
+	HeadArrivalFast     NodeFilterType = "headArrivalFast"
+	ProbesPassing       NodeFilterType = "probesPassing"
+	ClientAllowed       NodeFilterType = "clientAllowed"
 )
 
 func GetFilterTypeName(v interface{}) NodeFilterType {
@@ -1,11 +1,19 @@
 package route
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/satsuma-data/node-gateway/internal/checks"
+	"github.com/satsuma-data/node-gateway/internal/client"
 	"github.com/satsuma-data/node-gateway/internal/config"
 	"github.com/satsuma-data/node-gateway/internal/metadata"
+	"github.com/satsuma-data/node-gateway/internal/metrics"
+	"github.com/satsuma-data/node-gateway/internal/mocks"
+	"github.com/satsuma-data/node-gateway/internal/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 )
 
@@ -91,7 +99,7 @@ func TestAndFilter_Apply(t *testing.T) {
 func TestIsCloseToGlobalMaxHeight_Apply(t *testing.T) {
 	upstreamConfig := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID1}
 
-	chainMetadataStore := metadata.NewChainMetadataStore()
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
 	chainMetadataStore.Start()
 
 	filter := IsCloseToGlobalMaxHeight{
@@ -116,10 +124,37 @@ func TestIsCloseToGlobalMaxHeight_Apply(t *testing.T) {
 	assert.False(t, filter.Apply(metadata.RequestMetadata{}, upstreamConfig, 1))
 }
 
+func TestIsCloseToGlobalMaxHeight_Apply_UpstreamMaxBlocksBehind(t *testing.T) {
+	maxBlocksBehind := uint64(2)
+	upstreamConfig := &config.UpstreamConfig{
+		GroupID:           GroupID1,
+		ID:                UpstreamID1,
+		HealthCheckConfig: config.HealthCheckConfig{MaxBlocksBehind: &maxBlocksBehind},
+	}
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	chainMetadataStore.Start()
+
+	filter := IsCloseToGlobalMaxHeight{
+		chainMetadataStore: chainMetadataStore,
+		logger:             zap.L(),
+		maxBlocksBehind:    10,
+	}
+
+	emitBlockHeight(chainMetadataStore, GroupID2, UpstreamID2, 100)
+
+	// Within the chain's default but not within the upstream's own policy.
+	emitBlockHeight(chainMetadataStore, GroupID1, UpstreamID1, 95)
+	assert.False(t, filter.Apply(metadata.RequestMetadata{}, upstreamConfig, 1))
+
+	emitBlockHeight(chainMetadataStore, GroupID1, UpstreamID1, 98)
+	assert.True(t, filter.Apply(metadata.RequestMetadata{}, upstreamConfig, 1))
+}
+
 func TestIsAtMaxHeightForGroup_Apply(t *testing.T) {
 	upstream1Config := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID1}
 
-	chainMetadataStore := metadata.NewChainMetadataStore()
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
 	chainMetadataStore.Start()
 
 	filter := IsAtMaxHeightForGroup{
@@ -143,7 +178,7 @@ func TestIsAtMaxHeightForGroup_Apply(t *testing.T) {
 func TestIsAtMaxHeightForGroupOnlyUpstream_Apply(t *testing.T) {
 	upstreamConfig := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID1}
 
-	chainMetadataStore := metadata.NewChainMetadataStore()
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
 	chainMetadataStore.Start()
 
 	filter := IsAtMaxHeightForGroup{
@@ -166,6 +201,238 @@ func TestIsAtMaxHeightForGroupOnlyUpstream_Apply(t *testing.T) {
 	assert.False(t, filter.Apply(metadata.RequestMetadata{}, upstreamConfig, 1))
 }
 
+func TestFinalityTaggedRequests_Apply(t *testing.T) {
+	upstream1Config := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID1}
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	chainMetadataStore.Start()
+
+	groupFilter := IsAtMaxHeightForGroup{
+		chainMetadataStore: chainMetadataStore,
+		logger:             zap.L(),
+	}
+	globalFilter := IsCloseToGlobalMaxHeight{
+		chainMetadataStore: chainMetadataStore,
+		logger:             zap.L(),
+		maxBlocksBehind:    10,
+	}
+
+	finalizedRequest := metadata.RequestMetadata{Methods: []string{"eth_getBalance"}, BlockTags: []metadata.BlockTag{metadata.FinalizedBlockTag}}
+	latestRequest := metadata.RequestMetadata{Methods: []string{"eth_getBalance"}}
+
+	emitBlockHeight(chainMetadataStore, GroupID1, UpstreamID1, 100)
+	emitBlockHeight(chainMetadataStore, GroupID1, UpstreamID2, 100)
+
+	// No upstream reports a finalized head, e.g. because finality is not checked.
+	assert.True(t, groupFilter.Apply(finalizedRequest, upstream1Config, 2))
+	assert.True(t, globalFilter.Apply(finalizedRequest, upstream1Config, 2))
+
+	// Once another upstream reports a finalized head, an unknown one no longer passes.
+	chainMetadataStore.ProcessFinalizedBlockHeightUpdate(GroupID1, UpstreamID2, 64)
+	assert.False(t, groupFilter.Apply(finalizedRequest, upstream1Config, 2))
+	assert.False(t, globalFilter.Apply(finalizedRequest, upstream1Config, 2))
+
+	// Upstream 1 is at the latest head but its finalized head lags behind by one block.
+	chainMetadataStore.ProcessFinalizedBlockHeightUpdate(GroupID1, UpstreamID1, 63)
+	assert.True(t, groupFilter.Apply(latestRequest, upstream1Config, 2))
+	assert.True(t, globalFilter.Apply(latestRequest, upstream1Config, 2))
+	assert.False(t, groupFilter.Apply(finalizedRequest, upstream1Config, 2))
+	assert.False(t, globalFilter.Apply(finalizedRequest, upstream1Config, 2))
+
+	chainMetadataStore.ProcessFinalizedBlockHeightUpdate(GroupID1, UpstreamID1, 64)
+	assert.True(t, groupFilter.Apply(finalizedRequest, upstream1Config, 2))
+	assert.True(t, globalFilter.Apply(finalizedRequest, upstream1Config, 2))
+
+	// An upstream in another group finalizes a later block.
+	chainMetadataStore.ProcessFinalizedBlockHeightUpdate(GroupID2, "upstream3", 96)
+	assert.True(t, groupFilter.Apply(finalizedRequest, upstream1Config, 2))
+	assert.False(t, globalFilter.Apply(finalizedRequest, upstream1Config, 2))
+}
+
+func TestFinalityTaggedRequests_MaxFinalityBlocksBehind(t *testing.T) {
+	upstream1Config := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID1}
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	chainMetadataStore.Start()
+
+	groupFilter := IsAtMaxHeightForGroup{
+		chainMetadataStore:      chainMetadataStore,
+		logger:                  zap.L(),
+		maxFinalityBlocksBehind: 2,
+	}
+	globalFilter := IsCloseToGlobalMaxHeight{
+		chainMetadataStore:      chainMetadataStore,
+		logger:                  zap.L(),
+		maxBlocksBehind:         10,
+		maxFinalityBlocksBehind: 2,
+	}
+
+	finalizedRequest := metadata.RequestMetadata{Methods: []string{"eth_getBalance"}, BlockTags: []metadata.BlockTag{metadata.FinalizedBlockTag}}
+
+	emitBlockHeight(chainMetadataStore, GroupID1, UpstreamID1, 100)
+	emitBlockHeight(chainMetadataStore, GroupID1, UpstreamID2, 100)
+	chainMetadataStore.ProcessFinalizedBlockHeightUpdate(GroupID1, UpstreamID2, 64)
+
+	// An unknown finalized head is not within any number of blocks of the max.
+	assert.False(t, groupFilter.Apply(finalizedRequest, upstream1Config, 2))
+	assert.False(t, globalFilter.Apply(finalizedRequest, upstream1Config, 2))
+
+	chainMetadataStore.ProcessFinalizedBlockHeightUpdate(GroupID1, UpstreamID1, 61)
+	assert.False(t, groupFilter.Apply(finalizedRequest, upstream1Config, 2))
+	assert.False(t, globalFilter.Apply(finalizedRequest, upstream1Config, 2))
+
+	chainMetadataStore.ProcessFinalizedBlockHeightUpdate(GroupID1, UpstreamID1, 62)
+	assert.True(t, groupFilter.Apply(finalizedRequest, upstream1Config, 2))
+	assert.True(t, globalFilter.Apply(finalizedRequest, upstream1Config, 2))
+}
+
+func TestIsHeadFresh_Apply(t *testing.T) {
+	upstream1Config := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID1}
+	upstream2Config := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID2}
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	chainMetadataStore.Start()
+
+	filter := IsHeadFresh{
+		chainMetadataStore: chainMetadataStore,
+		logger:             zap.L(),
+		maxHeadAge:         time.Minute,
+	}
+
+	// Upstreams whose head has not been seen yet pass.
+	assert.True(t, filter.Apply(metadata.RequestMetadata{}, upstream1Config, 2))
+
+	now := time.Now()
+	chainMetadataStore.ProcessBlockTimestampUpdate(GroupID1, UpstreamID1, now.Add(-3*time.Minute))
+	chainMetadataStore.ProcessBlockTimestampUpdate(GroupID1, UpstreamID2, now.Add(-2*time.Minute))
+
+	// If all upstreams are stale, the whole chain is stale and none of them is filtered out.
+	assert.True(t, filter.Apply(metadata.RequestMetadata{}, upstream1Config, 2))
+	assert.True(t, filter.Apply(metadata.RequestMetadata{}, upstream2Config, 2))
+
+	chainMetadataStore.ProcessBlockTimestampUpdate(GroupID1, UpstreamID2, now)
+	assert.False(t, filter.Apply(metadata.RequestMetadata{}, upstream1Config, 2))
+	assert.True(t, filter.Apply(metadata.RequestMetadata{}, upstream2Config, 2))
+}
+
+func TestIsHeadFresh_Apply_Disabled(t *testing.T) {
+	upstream1Config := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID1}
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	chainMetadataStore.Start()
+
+	filter := CreateSingleNodeFilter(HeadFresh, nil, chainMetadataStore, zap.L(), &config.RoutingConfig{})
+
+	chainMetadataStore.ProcessBlockTimestampUpdate(GroupID1, UpstreamID1, time.Now().Add(-time.Hour))
+	chainMetadataStore.ProcessBlockTimestampUpdate(GroupID1, UpstreamID2, time.Now())
+	assert.True(t, filter.Apply(metadata.RequestMetadata{}, upstream1Config, 2))
+}
+
+func TestIsHeadArrivalFast_Apply(t *testing.T) {
+	upstream1Config := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID1}
+	upstream2Config := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID2}
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	chainMetadataStore.Start()
+
+	filter := CreateSingleNodeFilter(HeadArrivalFast, nil, chainMetadataStore, zap.L(), &config.RoutingConfig{MaxHeadArrivalDelay: 50 * time.Millisecond})
+	disabledFilter := CreateSingleNodeFilter(HeadArrivalFast, nil, chainMetadataStore, zap.L(), &config.RoutingConfig{})
+
+	emitBlockHeight(chainMetadataStore, GroupID1, UpstreamID1, 100)
+	time.Sleep(100 * time.Millisecond)
+	emitBlockHeight(chainMetadataStore, GroupID1, UpstreamID2, 100)
+
+	assert.True(t, filter.Apply(metadata.RequestMetadata{}, upstream1Config, 2))
+	assert.False(t, filter.Apply(metadata.RequestMetadata{}, upstream2Config, 2))
+	assert.True(t, disabledFilter.Apply(metadata.RequestMetadata{}, upstream2Config, 2))
+}
+
+func TestAreSyntheticProbesPassing_Apply(t *testing.T) {
+	upstreamConfig := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID1}
+
+	traceProbeConfig := &config.SyntheticProbeConfig{Method: "trace_block", Methods: []string{"trace_*"}}
+	traceProbeCheck := checks.NewSyntheticProbeChecker(upstreamConfig, traceProbeConfig, nil, nil, zap.L())
+	chainIDProbeConfig := &config.SyntheticProbeConfig{Method: "eth_chainId", Methods: []string{"eth_chainId"}}
+	chainIDProbeCheck := checks.NewSyntheticProbeChecker(upstreamConfig, chainIDProbeConfig, nil, nil, zap.L())
+
+	manager := mocks.NewHealthCheckManager(t)
+	manager.EXPECT().GetUpstreamStatus(UpstreamID1).Return(&types.UpstreamStatus{
+		SyntheticProbeChecks: []types.SyntheticProbeChecker{traceProbeCheck, chainIDProbeCheck},
+	})
+
+	filter := CreateSingleNodeFilter(ProbesPassing, manager, nil, zap.L(), &config.RoutingConfig{})
+
+	traceRequest := metadata.RequestMetadata{Methods: []string{"trace_transaction"}}
+	chainIDRequest := metadata.RequestMetadata{Methods: []string{"eth_chainId"}}
+
+	assert.True(t, filter.Apply(traceRequest, upstreamConfig, 1))
+	assert.True(t, filter.Apply(chainIDRequest, upstreamConfig, 1))
+
+	// A failing probe only affects the methods in its scope.
+	traceProbeCheck.(*checks.SyntheticProbeCheck).Err = assert.AnError //nolint:errcheck // ignore error
+
+	assert.False(t, filter.Apply(traceRequest, upstreamConfig, 1))
+	assert.True(t, filter.Apply(chainIDRequest, upstreamConfig, 1))
+	assert.True(t, filter.Apply(metadata.RequestMetadata{Methods: []string{"eth_blockNumber"}}, upstreamConfig, 1))
+}
+
+func TestIsNotOutlier_Apply(t *testing.T) {
+	upstreamConfig := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID1}
+
+	outlierCheck := mocks.NewChecker(t)
+	outlierCheck.EXPECT().IsPassing().Return(false)
+
+	manager := mocks.NewHealthCheckManager(t)
+	manager.EXPECT().GetUpstreamStatus(UpstreamID1).Return(&types.UpstreamStatus{OutlierCheck: outlierCheck}).Once()
+	manager.EXPECT().GetUpstreamStatus(UpstreamID1).Return(&types.UpstreamStatus{}).Once()
+
+	filter := IsNotOutlier{HealthCheckManager: manager, Logger: zap.L()}
+
+	assert.False(t, filter.Apply(metadata.RequestMetadata{}, upstreamConfig, 1))
+
+	// Outlier detection is disabled.
+	assert.True(t, filter.Apply(metadata.RequestMetadata{}, upstreamConfig, 1))
+}
+
+func TestIsClientAllowed_Apply(t *testing.T) {
+	erigonConfig := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID1}
+	unknownConfig := &config.UpstreamConfig{GroupID: GroupID1, ID: UpstreamID2}
+
+	ethClient := mocks.NewEthClient(t)
+	ethClient.EXPECT().CallRaw(mock.Anything, "web3_clientVersion", mock.Anything).Return(json.RawMessage(`"erigon/2.59.3/linux-amd64/go1.21.5"`), nil)
+
+	ethClientGetter := func(string, *config.BasicAuthConfig, *[]config.RequestHeaderConfig) (client.EthClient, error) {
+		return ethClient, nil
+	}
+
+	erigonCheck := checks.NewClientVersionChecker(erigonConfig, ethClientGetter, metrics.NewContainer(config.TestChainName), zap.L())
+	erigonCheck.RunCheck()
+
+	manager := mocks.NewHealthCheckManager(t)
+	manager.EXPECT().GetUpstreamStatus(UpstreamID1).Return(&types.UpstreamStatus{ClientVersionCheck: erigonCheck})
+	manager.EXPECT().GetUpstreamStatus(UpstreamID2).Return(&types.UpstreamStatus{})
+
+	routingConfig := &config.RoutingConfig{
+		ClientRequirements: []config.ClientRequirementConfig{
+			{Methods: []string{"trace_*"}, Clients: []config.ClientConstraintConfig{{Name: "erigon", MinVersion: "2.59.0"}}},
+			{Methods: []string{"eth_getLogs"}, Clients: []config.ClientConstraintConfig{{Name: "geth"}}},
+		},
+	}
+	filter := CreateSingleNodeFilter(ClientAllowed, manager, nil, zap.L(), routingConfig)
+
+	traceRequest := metadata.RequestMetadata{Methods: []string{"trace_block"}}
+	getLogsRequest := metadata.RequestMetadata{Methods: []string{"eth_getLogs"}}
+	blockNumberRequest := metadata.RequestMetadata{Methods: []string{"eth_blockNumber"}}
+
+	assert.True(t, filter.Apply(traceRequest, erigonConfig, 1))
+	assert.False(t, filter.Apply(getLogsRequest, erigonConfig, 1))
+	assert.True(t, filter.Apply(blockNumberRequest, erigonConfig, 1))
+
+	// Upstreams whose client is unknown only get the requests without a client requirement.
+	assert.False(t, filter.Apply(traceRequest, unknownConfig, 1))
+	assert.True(t, filter.Apply(blockNumberRequest, unknownConfig, 1))
+}
+
 func TestMethodsAllowedFilter_Apply(t *testing.T) {
 	fullNodeConfig := config.UpstreamConfig{NodeType: config.Full}
 	fullNodeConfigWithArchiveMethodEnabled := config.UpstreamConfig{
@@ -48,6 +48,21 @@ func (e *OriginError) Error() string {
 	return fmt.Sprintf("error making request to origin. err: %v, resp: %s, respCode: %d", e.err, e.response, e.ResponseCode)
 }
 
+// StaleResponseError is returned when a request could not be routed, but an expired cached response within the
+// stale-if-error grace period can be served instead.
+type StaleResponseError struct {
+	err          error
+	ResponseBody jsonrpc.ResponseBody
+}
+
+func (e *StaleResponseError) Error() string {
+	return fmt.Sprintf("serving stale cached response. err: %v", e.err)
+}
+
+func (e *StaleResponseError) Unwrap() error {
+	return e.err
+}
+
 /*
  * Return arguments
  * 1. JSON Response body - Body of response decoded into JSON RPC, if possible.
@@ -59,31 +74,31 @@ func (r *RequestExecutor) routeToConfig(
 	ctx context.Context,
 	requestBody jsonrpc.RequestBody,
 	configToRoute *config.UpstreamConfig,
+	upstreamBlockHeight uint64,
 ) (jsonrpc.ResponseBody, *HTTPResponse, bool, error) {
-	bodyBytes, err := requestBody.Encode()
-	if err != nil {
-		r.logger.Error("Could not serialize request.", zap.Any("request", requestBody), zap.Error(err))
-		return nil, nil, false, err
+	if singleRequestBody, ok := requestBody.(*jsonrpc.SingleRequestBody); ok && r.useCache(requestBody) {
+		// Resolve "latest" before the request is keyed and forwarded, so the response matches the cached block.
+		requestBody = r.cache.ResolveLatestBlock(singleRequestBody, upstreamBlockHeight)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", configToRoute.HTTPURL, bytes.NewReader(bodyBytes))
-	if err != nil {
-		r.logger.Error("Could not create new http request.", zap.Any("request", requestBody),
-			zap.String("upstreamID", configToRoute.ID), zap.Error(err))
-		return nil, nil, false, err
-	}
-
-	httpReq.Header.Set("content-type", "application/json")
+	if batchRequestBody, ok := requestBody.(*jsonrpc.BatchRequestBody); ok && r.useBatchCache(batchRequestBody) {
+		jsonRespBody, httpResp, cached, err := r.retrieveOrCacheBatchRequest(ctx, batchRequestBody, configToRoute, upstreamBlockHeight)
+		if err == nil {
+			return jsonRespBody, httpResp, cached, nil
+		}
 
-	if configToRoute.RequestHeadersConfig != nil {
-		for _, headerConfig := range configToRoute.RequestHeadersConfig {
-			httpReq.Header.Set(headerConfig.Key, headerConfig.Value)
+		switch err.(type) {
+		case *OriginError, *jsonrpc.DecodeError:
+			r.logger.Warn("caching error making batch request to origin", zap.Error(err), zap.Any("request", requestBody), zap.Any("resp", httpResp))
+			return nil, httpResp, cached, err
+		default:
+			r.logger.Warn("unknown batch caching error", zap.Error(err), zap.Any("request", requestBody), zap.Any("resp", httpResp))
 		}
 	}
 
-	if configToRoute.BasicAuthConfig.Username != "" && configToRoute.BasicAuthConfig.Password != "" {
-		encodedCredentials := base64.StdEncoding.EncodeToString([]byte(configToRoute.BasicAuthConfig.Username + ":" + configToRoute.BasicAuthConfig.Password))
-		httpReq.Header.Set("Authorization", "Basic "+encodedCredentials)
+	httpReq, err := r.newUpstreamRequest(ctx, requestBody, configToRoute)
+	if err != nil {
+		return nil, nil, false, err
 	}
 
 	var (
@@ -119,6 +134,36 @@ func (r *RequestExecutor) routeToConfig(
 	return jsonRespBody, httpResp, false, err
 }
 
+func (r *RequestExecutor) newUpstreamRequest(ctx context.Context, requestBody jsonrpc.RequestBody, configToRoute *config.UpstreamConfig) (*http.Request, error) {
+	bodyBytes, err := requestBody.Encode()
+	if err != nil {
+		r.logger.Error("Could not serialize request.", zap.Any("request", requestBody), zap.Error(err))
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", configToRoute.HTTPURL, bytes.NewReader(bodyBytes))
+	if err != nil {
+		r.logger.Error("Could not create new http request.", zap.Any("request", requestBody),
+			zap.String("upstreamID", configToRoute.ID), zap.Error(err))
+		return nil, err
+	}
+
+	httpReq.Header.Set("content-type", "application/json")
+
+	if configToRoute.RequestHeadersConfig != nil {
+		for _, headerConfig := range configToRoute.RequestHeadersConfig {
+			httpReq.Header.Set(headerConfig.Key, headerConfig.Value)
+		}
+	}
+
+	if configToRoute.BasicAuthConfig.Username != "" && configToRoute.BasicAuthConfig.Password != "" {
+		encodedCredentials := base64.StdEncoding.EncodeToString([]byte(configToRoute.BasicAuthConfig.Username + ":" + configToRoute.BasicAuthConfig.Password))
+		httpReq.Header.Set("Authorization", "Basic "+encodedCredentials)
+	}
+
+	return httpReq, nil
+}
+
 func (r *RequestExecutor) useCache(requestBody jsonrpc.RequestBody) bool {
 	if r.cache == nil {
 		return false
@@ -127,6 +172,110 @@ func (r *RequestExecutor) useCache(requestBody jsonrpc.RequestBody) bool {
 	return r.cache.ShouldCacheMethod(requestBody.GetMethod())
 }
 
+// useBatchCache returns whether any sub-request of the batch can be answered from the cache. Batches with duplicate
+// IDs are forwarded as is, since responses are matched to sub-requests by ID.
+func (r *RequestExecutor) useBatchCache(requestBody *jsonrpc.BatchRequestBody) bool {
+	if r.cache == nil {
+		return false
+	}
+
+	useCache := false
+	ids := make(map[int64]bool, len(requestBody.Requests))
+
+	for i := range requestBody.Requests {
+		subRequest := &requestBody.Requests[i]
+		if subRequest.ID == nil {
+			continue
+		}
+
+		if ids[*subRequest.ID] {
+			return false
+		}
+
+		ids[*subRequest.ID] = true
+		useCache = useCache || r.useCache(subRequest)
+	}
+
+	return useCache
+}
+
+// retrieveOrCacheBatchRequest answers the sub-requests of a batch from the cache, and forwards the misses to origin as
+// a smaller batch. The responses are merged in the order of the sub-requests.
+func (r *RequestExecutor) retrieveOrCacheBatchRequest(
+	ctx context.Context,
+	requestBody *jsonrpc.BatchRequestBody,
+	configToRoute *config.UpstreamConfig,
+	upstreamBlockHeight uint64,
+) (jsonrpc.ResponseBody, *HTTPResponse, bool, error) {
+	subRequests := requestBody.GetSubRequests()
+	for i := range subRequests {
+		if r.useCache(&subRequests[i]) {
+			subRequests[i] = *r.cache.ResolveLatestBlock(&subRequests[i], upstreamBlockHeight)
+		}
+	}
+
+	httpResp := &HTTPResponse{StatusCode: http.StatusOK}
+
+	originFunc := func(originSubRequests []jsonrpc.SingleRequestBody) ([]jsonrpc.SingleResponseBody, error) {
+		originRequestBody := &jsonrpc.BatchRequestBody{Requests: originSubRequests}
+
+		httpReq, err := r.newUpstreamRequest(ctx, originRequestBody, configToRoute)
+		if err != nil {
+			return nil, err
+		}
+
+		var jsonRPCRespBody jsonrpc.ResponseBody
+
+		jsonRPCRespBody, httpResp, err = r.getResponseBody(httpReq, originRequestBody, configToRoute)
+		if err != nil {
+			return nil, err
+		}
+
+		// Batches of notifications have an empty response.
+		if jsonRPCRespBody == nil {
+			return nil, nil
+		}
+
+		return jsonRPCRespBody.GetSubResponses(), nil
+	}
+
+	revalidateFunc := func(subRequest jsonrpc.SingleRequestBody) (*jsonrpc.SingleResponseBody, error) {
+		return r.revalidate(subRequest, configToRoute)
+	}
+
+	subResponses, cached, err := r.cache.HandleBatchRequestParallel(r.chainName, subRequests, originFunc, revalidateFunc)
+	if err != nil {
+		return nil, httpResp, cached, err
+	}
+
+	if cached {
+		r.logger.Debug("batch cache hit", zap.Any("request", requestBody))
+	}
+
+	return &jsonrpc.BatchResponseBody{Responses: subResponses}, httpResp, cached, nil
+}
+
+// revalidate requests a fresh response to refresh the cached entry of the request. Refreshes in the background outlive
+// the client's request, so they use their own upstream request.
+func (r *RequestExecutor) revalidate(requestBody jsonrpc.SingleRequestBody, configToRoute *config.UpstreamConfig) (*jsonrpc.SingleResponseBody, error) {
+	revalidateHTTPReq, err := r.newUpstreamRequest(context.Background(), &requestBody, configToRoute)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, _, err := r.getResponseBody(revalidateHTTPReq, &requestBody, configToRoute)
+	if err != nil {
+		return nil, err
+	}
+
+	singleRespBody, ok := respBody.(*jsonrpc.SingleResponseBody)
+	if !ok {
+		return nil, errors.New("batched responses do not support caching")
+	}
+
+	return singleRespBody, nil
+}
+
 func (r *RequestExecutor) retrieveOrCacheRequest(httpReq *http.Request, requestBody jsonrpc.SingleRequestBody, configToRoute *config.UpstreamConfig) (jsonrpc.ResponseBody, *HTTPResponse, bool, error) {
 	var (
 		jsonRPCRespBody jsonrpc.ResponseBody
@@ -152,16 +301,20 @@ func (r *RequestExecutor) retrieveOrCacheRequest(httpReq *http.Request, requestB
 			return nil, &HandledError{singleRespBody}
 		}
 
+		// Null results are returned as is, the cache decides whether they can be cached.
 		result := bytes.NewBuffer(singleRespBody.Result).String()
 		if result == "null" {
 			r.logger.Debug("null result", zap.Any("request", requestBody), zap.Any("respBody", singleRespBody))
-			return nil, &HandledError{singleRespBody}
 		}
 
 		return singleRespBody, nil
 	}
 
-	val, cached, err := r.cache.HandleRequestParallel(r.chainName, requestBody, originFunc)
+	revalidateFunc := func() (*jsonrpc.SingleResponseBody, error) {
+		return r.revalidate(requestBody, configToRoute)
+	}
+
+	val, cached, err := r.cache.HandleRequestParallel(r.chainName, requestBody, originFunc, revalidateFunc)
 
 	if err != nil {
 		switch err := err.(type) {
@@ -16,6 +16,7 @@ import (
 	"github.com/satsuma-data/node-gateway/internal/cache"
 	"github.com/satsuma-data/node-gateway/internal/config"
 	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
+	"github.com/satsuma-data/node-gateway/internal/metadata"
 	"github.com/satsuma-data/node-gateway/internal/metrics"
 	"github.com/satsuma-data/node-gateway/internal/mocks"
 	"github.com/stretchr/testify/assert"
@@ -62,8 +63,9 @@ func TestRetrieveOrCacheRequest(t *testing.T) {
 	cacheKey := cache.CreateRequestKey("mainnet", requestBody)
 	redisClientMock.ExpectGet(cacheKey).SetErr(errors.New("error"))
 	// The cache has custom marshaling to pack the cache efficiently.
+	// The result is cached inside an entry.
 	raw := json.RawMessage(`"hello"`)
-	rawBytes, _ := rpcCache.Marshal(raw)
+	rawBytes, _ := rpcCache.Marshal(json.RawMessage(`{"result":"hello"}`))
 	redisClientMock.ExpectSetNX(cacheKey, rawBytes, defaultCacheConfig.TTL).SetVal(true)
 
 	jsonRPCResponseBody, httpResponse, cached, _ := executor.retrieveOrCacheRequest(httpReq, requestBody, &configToRoute)
@@ -288,3 +290,112 @@ func TestUseCache(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteToConfig_ResolveLatestBlock(t *testing.T) {
+	cacheConfig := config.ChainCacheConfig{TTL: 6 * time.Second, ResolveLatest: true}
+	rpcCache := cache.FromBackend(cacheConfig, cache.NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	rpcCache.UseChainMetadataStore(chainMetadataStore)
+	chainMetadataStore.Start()
+	chainMetadataStore.ProcessBlockHeightUpdate("primary", "geth", 0x100)
+	assert.Eventually(t, func() bool {
+		maxBlockHeight, _ := chainMetadataStore.GetGlobalMaxBlockHeights()
+		return maxBlockHeight == 0x100
+	}, time.Second, time.Millisecond)
+
+	httpClientMock := mocks.NewHTTPClient(t)
+	// Identical requests within the same block share one upstream call.
+	httpClientMock.On("Do", mock.MatchedBy(func(httpReq *http.Request) bool {
+		body, _ := io.ReadAll(httpReq.Body)
+		return strings.Contains(string(body), `"params":["0x01","0x100"]`)
+	})).Return(&http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(`{"id":1,"jsonrpc":"2.0","result":"0x2a"}`)),
+	}, nil).Once()
+
+	executor := RequestExecutor{httpClientMock, cacheConfig, zap.L(), rpcCache, "mainnet"}
+	configToRoute := config.UpstreamConfig{ID: "geth", GroupID: "primary", HTTPURL: "gethURL"}
+
+	for _, params := range [][]any{{"0x01", "latest"}, {"0x01"}} {
+		requestBody := &jsonrpc.SingleRequestBody{
+			ID:             lo.ToPtr[int64](1),
+			JSONRPCVersion: "2.0",
+			Method:         "eth_getBalance",
+			Params:         params,
+		}
+
+		respBody, _, _, err := executor.routeToConfig(context.Background(), requestBody, &configToRoute, 0x100)
+
+		assert.NoError(t, err)
+		assert.Equal(t, json.RawMessage(`"0x2a"`), respBody.GetSubResponses()[0].Result)
+
+		// Allow the async cache set to complete.
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRouteToConfig_BatchRequestCache(t *testing.T) {
+	cacheConfig := config.ChainCacheConfig{
+		MethodTTLs: map[string]time.Duration{"eth_getTransactionReceipt": time.Minute},
+	}
+	rpcCache := cache.FromBackend(cacheConfig, cache.NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+
+	newBatchRequestBody := func(firstID, secondID int64) *jsonrpc.BatchRequestBody {
+		return &jsonrpc.BatchRequestBody{Requests: []jsonrpc.SingleRequestBody{
+			{ID: lo.ToPtr(firstID), JSONRPCVersion: "2.0", Method: "eth_getTransactionReceipt", Params: []any{"0x01"}},
+			{ID: lo.ToPtr(secondID), JSONRPCVersion: "2.0", Method: "eth_blockNumber"},
+		}}
+	}
+
+	httpClientMock := mocks.NewHTTPClient(t)
+	httpClientMock.On("Do", mock.Anything).Return(&http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(`[{"id":1,"jsonrpc":"2.0","result":"receipt"},{"id":2,"jsonrpc":"2.0","result":"0x10"}]`)),
+	}, nil).Once()
+	// Only the sub-request that is not cached is forwarded.
+	httpClientMock.On("Do", mock.MatchedBy(func(httpReq *http.Request) bool {
+		body, _ := io.ReadAll(httpReq.Body)
+		return string(body) == `[{"id":20,"jsonrpc":"2.0","method":"eth_blockNumber"}]`
+	})).Return(&http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(`[{"id":20,"jsonrpc":"2.0","result":"0x11"}]`)),
+	}, nil).Once()
+
+	executor := RequestExecutor{httpClientMock, cacheConfig, zap.L(), rpcCache, "mainnet"}
+	configToRoute := config.UpstreamConfig{ID: "geth", GroupID: "primary", HTTPURL: "gethURL"}
+
+	respBody, _, cached, err := executor.routeToConfig(context.Background(), newBatchRequestBody(1, 2), &configToRoute, 0)
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	assert.Len(t, respBody.GetSubResponses(), 2)
+
+	// Allow the async cache set to complete.
+	time.Sleep(5 * time.Millisecond)
+
+	respBody, httpResp, cached, err := executor.routeToConfig(context.Background(), newBatchRequestBody(10, 20), &configToRoute, 0)
+	assert.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	// Client IDs and order are preserved.
+	assert.Equal(t, []jsonrpc.SingleResponseBody{
+		{ID: 10, JSONRPC: "2.0", Result: json.RawMessage(`"receipt"`)},
+		{ID: 20, JSONRPC: "2.0", Result: json.RawMessage(`"0x11"`)},
+	}, respBody.GetSubResponses())
+}
+
+func TestUseBatchCache(t *testing.T) {
+	cacheConfig := config.ChainCacheConfig{
+		MethodTTLs: map[string]time.Duration{"eth_getTransactionReceipt": time.Minute},
+	}
+	rpcCache := cache.FromBackend(cacheConfig, cache.NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+	executor := RequestExecutor{mocks.NewHTTPClient(t), cacheConfig, zap.L(), rpcCache, "mainnet"}
+
+	cacheable := jsonrpc.SingleRequestBody{ID: lo.ToPtr[int64](1), Method: "eth_getTransactionReceipt"}
+	notCacheable := jsonrpc.SingleRequestBody{ID: lo.ToPtr[int64](2), Method: "eth_blockNumber"}
+	duplicateID := jsonrpc.SingleRequestBody{ID: lo.ToPtr[int64](1), Method: "eth_blockNumber"}
+
+	assert.True(t, executor.useBatchCache(&jsonrpc.BatchRequestBody{Requests: []jsonrpc.SingleRequestBody{cacheable, notCacheable}}))
+	assert.False(t, executor.useBatchCache(&jsonrpc.BatchRequestBody{Requests: []jsonrpc.SingleRequestBody{notCacheable}}))
+	assert.False(t, executor.useBatchCache(&jsonrpc.BatchRequestBody{Requests: []jsonrpc.SingleRequestBody{cacheable, duplicateID}}))
+}
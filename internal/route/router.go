@@ -26,6 +26,9 @@ import (
 type Router interface {
 	Start()
 	IsInitialized() bool
+	// IsHeadStale returns true iff the chain's head, across all upstreams, is older than the configured maxHeadAge.
+	IsHeadStale() bool
+	GetChainName() string
 	Route(ctx context.Context, requestBody jsonrpc.RequestBody) (string, jsonrpc.ResponseBody, error)
 }
 
@@ -38,7 +41,9 @@ type SimpleRouter struct {
 	metricsContainer    *metrics.Container
 	logger              *zap.Logger
 	priorityToUpstreams types.PriorityToUpstreamsMap
+	chainName           string
 	upstreamConfigs     []config.UpstreamConfig
+	maxHeadAge          time.Duration
 }
 
 func NewRouter(
@@ -46,6 +51,7 @@ func NewRouter(
 	cacheConfig config.ChainCacheConfig,
 	upstreamConfigs []config.UpstreamConfig,
 	groupConfigs []config.GroupConfig,
+	maxHeadAge time.Duration,
 	chainMetadataStore *metadata.ChainMetadataStore,
 	healthCheckManager checks.HealthCheckManager,
 	routingStrategy RoutingStrategy,
@@ -63,6 +69,8 @@ func NewRouter(
 		metadataParser:      metadata.RequestMetadataParser{},
 		metricsContainer:    metricsContainer,
 		logger:              logger,
+		chainName:           chainName,
+		maxHeadAge:          maxHeadAge,
 	}
 
 	return r
@@ -78,6 +86,10 @@ func groupUpstreamsByPriority(
 		upstreamConfig := &upstreamConfigs[configIndex]
 		groupID := upstreamConfig.GroupID
 
+		if upstreamConfig.ReferenceOnly {
+			continue
+		}
+
 		groupPriority := 0
 		// If groups are not specified, all upstreams will be on priority 0.
 		for _, groupConfig := range groupConfigs {
@@ -102,6 +114,21 @@ func (r *SimpleRouter) IsInitialized() bool {
 	return r.healthCheckManager.IsInitialized()
 }
 
+func (r *SimpleRouter) IsHeadStale() bool {
+	if r.maxHeadAge == 0 {
+		return false
+	}
+
+	// Until a block has been seen, staleness is unknown.
+	globalMaxBlockTimestamp := r.chainMetadataStore.GetGlobalMaxBlockTimestamp()
+
+	return !globalMaxBlockTimestamp.IsZero() && time.Since(globalMaxBlockTimestamp) > r.maxHeadAge
+}
+
+func (r *SimpleRouter) GetChainName() string {
+	return r.chainName
+}
+
 func (r *SimpleRouter) Route(
 	ctx context.Context,
 	requestBody jsonrpc.RequestBody,
@@ -110,7 +137,7 @@ func (r *SimpleRouter) Route(
 	upstreamID, err := r.routingStrategy.RouteNextRequest(r.priorityToUpstreams, requestMetadata)
 
 	if err != nil {
-		return "", nil, err
+		return "", nil, r.getStaleResponseOr(requestBody, err)
 	}
 
 	var configToRoute config.UpstreamConfig
@@ -123,8 +150,14 @@ func (r *SimpleRouter) Route(
 
 	r.logger.Debug("Routing request to upstream.", zap.String("upstreamID", upstreamID), zap.Any("request", requestBody), zap.String("client", util.GetClientFromContext(ctx)))
 
+	// The upstream may be a few blocks behind the chain's head, so "latest" is resolved to a block it has.
+	var upstreamBlockHeight uint64
+	if r.requestExecutor.cacheConfig.ResolveLatest {
+		upstreamBlockHeight = r.healthCheckManager.GetUpstreamStatus(upstreamID).BlockHeightCheck.GetBlockHeight()
+	}
+
 	start := time.Now()
-	jsonRPCResponse, httpResponse, cached, err := r.requestExecutor.routeToConfig(ctx, requestBody, &configToRoute)
+	jsonRPCResponse, httpResponse, cached, err := r.requestExecutor.routeToConfig(ctx, requestBody, &configToRoute, upstreamBlockHeight)
 	statusCode := 0
 	HTTPResponseCode := ""
 
@@ -139,6 +172,8 @@ func (r *SimpleRouter) Route(
 		ResponseBody:     jsonRPCResponse,
 		Latency:          time.Since(start),
 		Error:            err,
+		Cached:           cached,
+		RequestBody:      requestBody,
 	})
 
 	r.metricsContainer.UpstreamRPCRequestsTotal.WithLabelValues(
@@ -204,5 +239,27 @@ func (r *SimpleRouter) Route(
 		HTTPResponseCode,
 	).Observe(time.Since(start).Seconds())
 
+	// Undecodable responses are still passed to the client.
+	if _, isDecodeError := err.(*jsonrpc.DecodeError); err != nil && !isDecodeError {
+		err = r.getStaleResponseOr(requestBody, err)
+	}
+
 	return upstreamID, jsonRPCResponse, err
 }
+
+// getStaleResponseOr returns a StaleResponseError with the cached response of the request if it can be served stale,
+// or err otherwise.
+func (r *SimpleRouter) getStaleResponseOr(requestBody jsonrpc.RequestBody, err error) error {
+	if r.requestExecutor.cache == nil {
+		return err
+	}
+
+	respBody, ok := r.requestExecutor.cache.GetStaleResponse(r.chainName, requestBody)
+	if !ok {
+		return err
+	}
+
+	r.logger.Warn("Serving stale cached response.", zap.Any("request", requestBody), zap.Error(err))
+
+	return &StaleResponseError{err, respBody}
+}
@@ -19,3 +19,16 @@ func (r *RouterCollection) IsInitialized() bool {
 
 	return true
 }
+
+// GetChainsWithStaleHeads returns the names of the chains whose head is older than their configured maxHeadAge.
+func (r *RouterCollection) GetChainsWithStaleHeads() []string {
+	staleChainNames := make([]string, 0)
+
+	for _, router := range r.Routers {
+		if router.IsHeadStale() {
+			staleChainNames = append(staleChainNames, router.GetChainName())
+		}
+	}
+
+	return staleChainNames
+}
@@ -8,7 +8,9 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/satsuma-data/node-gateway/internal/cache"
 	"github.com/satsuma-data/node-gateway/internal/metadata"
 	"github.com/satsuma-data/node-gateway/internal/metrics"
 	"github.com/satsuma-data/node-gateway/internal/types"
@@ -37,7 +39,7 @@ func TestRouter_NoHealthyUpstreams(t *testing.T) {
 	routingStrategy := mocks.NewMockRoutingStrategy(t)
 	routingStrategy.EXPECT().RouteNextRequest(mock.Anything, mock.Anything).Return("", DefaultNoHealthyUpstreamsError)
 
-	router := NewRouter("mainnet", cacheConfig, upstreamConfigs, make([]config.GroupConfig, 0), metadata.NewChainMetadataStore(), managerMock, routingStrategy, metrics.NewContainer(config.TestChainName), zap.L(), nil)
+	router := NewRouter("mainnet", cacheConfig, upstreamConfigs, make([]config.GroupConfig, 0), 0, metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName)), managerMock, routingStrategy, metrics.NewContainer(config.TestChainName), zap.L(), nil)
 	router.(*SimpleRouter).healthCheckManager = managerMock //nolint:errcheck // ignore error
 	router.Start()
 
@@ -47,6 +49,98 @@ func TestRouter_NoHealthyUpstreams(t *testing.T) {
 	assert.Equal(t, DefaultNoHealthyUpstreamsError, err)
 }
 
+func TestRouter_NoHealthyUpstreams_StaleResponse(t *testing.T) {
+	managerMock := mocks.NewHealthCheckManager(t)
+	managerMock.EXPECT().RecordRequest(mock.Anything, mock.Anything).Once()
+
+	httpClientMock := mocks.NewHTTPClient(t)
+	httpClientMock.On("Do", mock.Anything).Return(&http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(`{"id":1,"jsonrpc":"2.0","result":"receipt"}`)),
+	}, nil).Once()
+
+	routingStrategy := mocks.NewMockRoutingStrategy(t)
+	routingStrategy.EXPECT().RouteNextRequest(mock.Anything, mock.Anything).Return("geth", nil).Once()
+	routingStrategy.EXPECT().RouteNextRequest(mock.Anything, mock.Anything).Return("", DefaultNoHealthyUpstreamsError).Once()
+
+	cacheConfig := config.ChainCacheConfig{TTL: time.Minute, StaleIfError: time.Minute}
+	rpcCache := cache.FromBackend(cacheConfig, cache.NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+	upstreamConfigs := []config.UpstreamConfig{{ID: "geth", GroupID: "primary", HTTPURL: "gethURL"}}
+
+	router := NewRouter("mainnet", cacheConfig, upstreamConfigs, make([]config.GroupConfig, 0), 0, metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName)), managerMock, routingStrategy, metrics.NewContainer(config.TestChainName), zap.L(), rpcCache)
+	router.(*SimpleRouter).requestExecutor.httpClient = httpClientMock
+
+	newRequestBody := func(id int64) *jsonrpc.SingleRequestBody {
+		return &jsonrpc.SingleRequestBody{ID: &id, JSONRPCVersion: "2.0", Method: "eth_getTransactionReceipt", Params: []any{"0x01"}}
+	}
+
+	_, _, err := router.Route(context.Background(), newRequestBody(1))
+	assert.NoError(t, err)
+
+	// Allow the async cache set to complete.
+	time.Sleep(5 * time.Millisecond)
+
+	_, jsonResp, err := router.Route(context.Background(), newRequestBody(2))
+
+	assert.Nil(t, jsonResp)
+
+	var staleResponseError *StaleResponseError
+
+	assert.ErrorAs(t, err, &staleResponseError)
+	assert.ErrorIs(t, err, DefaultNoHealthyUpstreamsError)
+	assert.Equal(t, &jsonrpc.SingleResponseBody{ID: 2, JSONRPC: "2.0", Result: json.RawMessage(`"receipt"`)}, staleResponseError.ResponseBody)
+}
+
+func TestRouter_ResolveLatestBlock_UpstreamBehindHead(t *testing.T) {
+	blockHeightCheckMock := mocks.NewBlockHeightChecker(t)
+	blockHeightCheckMock.EXPECT().GetBlockHeight().Return(0xff)
+
+	managerMock := mocks.NewHealthCheckManager(t)
+	managerMock.EXPECT().GetUpstreamStatus("geth").Return(&types.UpstreamStatus{BlockHeightCheck: blockHeightCheckMock})
+	managerMock.EXPECT().RecordRequest(mock.Anything, mock.Anything).Once()
+
+	// The upstream is one block behind the chain's head, so "latest" is resolved to its own head.
+	httpClientMock := mocks.NewHTTPClient(t)
+	httpClientMock.On("Do", mock.MatchedBy(func(httpReq *http.Request) bool {
+		body, _ := io.ReadAll(httpReq.Body)
+		return strings.Contains(string(body), `"params":["0x01","0xff"]`)
+	})).Return(&http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(`{"id":1,"jsonrpc":"2.0","result":"0x2a"}`)),
+	}, nil).Once()
+
+	routingStrategy := mocks.NewMockRoutingStrategy(t)
+	routingStrategy.EXPECT().RouteNextRequest(mock.Anything, mock.Anything).Return("geth", nil).Once()
+
+	cacheConfig := config.ChainCacheConfig{TTL: time.Minute, ResolveLatest: true}
+	rpcCache := cache.FromBackend(cacheConfig, cache.NewMemoryBackend(100, 10000), metrics.NewContainer(config.TestChainName))
+
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	rpcCache.UseChainMetadataStore(chainMetadataStore)
+	chainMetadataStore.Start()
+	chainMetadataStore.ProcessBlockHeightUpdate("primary", "erigon", 0x100)
+	assert.Eventually(t, func() bool {
+		maxBlockHeight, _ := chainMetadataStore.GetGlobalMaxBlockHeights()
+		return maxBlockHeight == 0x100
+	}, time.Second, time.Millisecond)
+
+	upstreamConfigs := []config.UpstreamConfig{{ID: "geth", GroupID: "primary", HTTPURL: "gethURL"}}
+
+	router := NewRouter("mainnet", cacheConfig, upstreamConfigs, make([]config.GroupConfig, 0), 0, chainMetadataStore, managerMock, routingStrategy, metrics.NewContainer(config.TestChainName), zap.L(), rpcCache)
+	router.(*SimpleRouter).requestExecutor.httpClient = httpClientMock
+
+	id := int64(1)
+	_, jsonResp, err := router.Route(context.Background(), &jsonrpc.SingleRequestBody{
+		ID:             &id,
+		JSONRPCVersion: "2.0",
+		Method:         "eth_getBalance",
+		Params:         []any{"0x01", "latest"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`"0x2a"`), jsonResp.GetSubResponses()[0].Result)
+}
+
 func TestRouter_GroupUpstreamsByPriority(t *testing.T) {
 	managerMock := mocks.NewHealthCheckManager(t)
 	managerMock.EXPECT().RecordRequest(mock.Anything, mock.Anything)
@@ -105,7 +199,7 @@ func TestRouter_GroupUpstreamsByPriority(t *testing.T) {
 	}
 	cacheConfig := config.ChainCacheConfig{}
 
-	router := NewRouter("mainnet", cacheConfig, upstreamConfigs, groupConfigs, metadata.NewChainMetadataStore(), managerMock, nil, metrics.NewContainer(config.TestChainName), zap.L(), nil)
+	router := NewRouter("mainnet", cacheConfig, upstreamConfigs, groupConfigs, 0, metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName)), managerMock, nil, metrics.NewContainer(config.TestChainName), zap.L(), nil)
 	router.(*SimpleRouter).requestExecutor.httpClient = httpClientMock //nolint:errcheck // ignore error
 	router.(*SimpleRouter).routingStrategy = routingStrategyMock       //nolint:errcheck // ignore error
 
@@ -152,7 +246,7 @@ func TestGroupUpstreamsByPriority_NoGroups(t *testing.T) {
 	}
 	cacheConfig := config.ChainCacheConfig{}
 
-	router := NewRouter("mainnet", cacheConfig, upstreamConfigs, make([]config.GroupConfig, 0), metadata.NewChainMetadataStore(), managerMock, nil, metrics.NewContainer(config.TestChainName), zap.L(), nil)
+	router := NewRouter("mainnet", cacheConfig, upstreamConfigs, make([]config.GroupConfig, 0), 0, metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName)), managerMock, nil, metrics.NewContainer(config.TestChainName), zap.L(), nil)
 	router.(*SimpleRouter).requestExecutor.httpClient = httpClientMock //nolint:errcheck // ignore error
 	router.(*SimpleRouter).routingStrategy = routingStrategyMock       //nolint:errcheck // ignore error
 
@@ -167,3 +261,45 @@ func TestGroupUpstreamsByPriority_NoGroups(t *testing.T) {
 	}, metadata.RequestMetadata{Methods: []string{"my_method"}})
 	assert.Equal(t, "erigonURL", httpClientMock.Calls[0].Arguments[0].(*http.Request).URL.Path) //nolint:errcheck // ignore error
 }
+
+func TestGroupUpstreamsByPriority_ReferenceOnly(t *testing.T) {
+	upstreamConfigs := []config.UpstreamConfig{
+		{ID: "geth", GroupID: "primary", HTTPURL: "gethURL"},
+		{ID: "reference", HTTPURL: "referenceURL", ReferenceOnly: true},
+		{ID: "erigon", GroupID: "fallback", HTTPURL: "erigonURL"},
+	}
+	groupConfigs := []config.GroupConfig{
+		{ID: "primary", Priority: 0},
+		{ID: "fallback", Priority: 1},
+	}
+
+	// Reference-only upstreams are never routed to.
+	assert.Equal(t, types.PriorityToUpstreamsMap{
+		0: {&upstreamConfigs[0]},
+		1: {&upstreamConfigs[2]},
+	}, groupUpstreamsByPriority(upstreamConfigs, groupConfigs))
+}
+
+func TestRouter_IsHeadStale(t *testing.T) {
+	chainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	chainMetadataStore.Start()
+
+	router := NewRouter("mainnet", config.ChainCacheConfig{}, nil, nil, time.Minute, chainMetadataStore, nil, nil, metrics.NewContainer(config.TestChainName), zap.L(), nil)
+	assert.Equal(t, "mainnet", router.GetChainName())
+
+	// Staleness is unknown until a block has been seen.
+	assert.False(t, router.IsHeadStale())
+
+	chainMetadataStore.ProcessBlockTimestampUpdate("primary", "geth", time.Now().Add(-2*time.Minute))
+	assert.True(t, router.IsHeadStale())
+
+	chainMetadataStore.ProcessBlockTimestampUpdate("primary", "erigon", time.Now())
+	assert.False(t, router.IsHeadStale())
+
+	staleChainMetadataStore := metadata.NewChainMetadataStore(metrics.NewContainer(config.TestChainName))
+	staleChainMetadataStore.Start()
+	staleChainMetadataStore.ProcessBlockTimestampUpdate("primary", "geth", time.Now().Add(-time.Hour))
+
+	disabledRouter := NewRouter("mainnet", config.ChainCacheConfig{}, nil, nil, 0, staleChainMetadataStore, nil, nil, metrics.NewContainer(config.TestChainName), zap.L(), nil)
+	assert.False(t, disabledRouter.IsHeadStale())
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/satsuma-data/node-gateway/internal/route"
 	"go.uber.org/zap"
@@ -13,9 +14,17 @@ type HealthCheckHandler struct {
 }
 
 func (h *HealthCheckHandler) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
-	if h.routerCollection.IsInitialized() {
-		respondRaw(h.logger, writer, []byte("OK"), http.StatusOK)
-	} else {
+	if !h.routerCollection.IsInitialized() {
 		respondRaw(h.logger, writer, []byte("Starting up"), http.StatusServiceUnavailable)
+		return
 	}
+
+	// Stale chains are reported without failing the health check, since every gateway instance sees the same stale
+	// upstreams and the other chains can still be served.
+	if staleChainNames := h.routerCollection.GetChainsWithStaleHeads(); len(staleChainNames) > 0 {
+		respondRaw(h.logger, writer, []byte("Stale head: "+strings.Join(staleChainNames, ", ")), http.StatusOK)
+		return
+	}
+
+	respondRaw(h.logger, writer, []byte("OK"), http.StatusOK)
 }
@@ -2,7 +2,6 @@ package server
 
 import (
 	"net/http"
-	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/satsuma-data/node-gateway/internal/cache"
@@ -32,19 +31,18 @@ func wireSingleChainDependencies(
 	globalConfig *config.GlobalConfig,
 	chainConfig *config.SingleChainConfig,
 	logger *zap.Logger,
-	redisReader *redis.Client,
-	redisWriter *redis.Client,
+	redisReader redis.UniversalClient,
+	redisWriter redis.UniversalClient,
+	memoryCacheBackend *cache.MemoryBackend,
 ) singleChainObjectGraph {
 	metricContainer := metrics.NewContainer(chainConfig.ChainName)
-	chainMetadataStore := metadata.NewChainMetadataStore()
-	ticker := time.NewTicker(checks.PeriodicHealthCheckInterval)
+	chainMetadataStore := metadata.NewChainMetadataStore(metricContainer)
 	healthCheckManager := checks.NewHealthCheckManager(
 		client.NewEthClient,
 		chainConfig.Upstreams,
 		chainConfig.Routing,
 		globalConfig.Routing,
 		chainMetadataStore,
-		ticker,
 		metricContainer,
 		logger,
 	)
@@ -54,6 +52,10 @@ func wireSingleChainDependencies(
 		route.MaxHeightForGroup,
 		route.MethodsAllowed,
 		route.NearGlobalMaxHeight,
+		route.HeadFresh,
+		route.HeadArrivalFast,
+		route.ProbesPassing,
+		route.ClientAllowed,
 	}
 	nodeFilter := route.CreateNodeFilter(
 		enabledNodeFilters,
@@ -70,7 +72,12 @@ func wireSingleChainDependencies(
 	}
 
 	// If we should always route, use AlwaysRouteFilteringStrategy. Otherwise, use FilteringRoutingStrategy.
-	backingStrategy := route.NewPriorityRoundRobinStrategy(logger)
+	backingStrategy := route.NewFailoverHysteresisStrategy(
+		chainConfig.Groups,
+		route.NewPriorityRoundRobinStrategy(logger),
+		metricContainer,
+		logger,
+	)
 
 	var routingStrategy route.RoutingStrategy
 
@@ -82,39 +89,65 @@ func wireSingleChainDependencies(
 		HealthCheckManager: healthCheckManager,
 		MetricsContainer:   metricContainer,
 	}
+	outlierFilter := route.IsNotOutlier{
+		HealthCheckManager: healthCheckManager,
+		Logger:             logger,
+	}
 
 	// These should be ordered from most important to least important.
 	nodeFilters := []route.NodeFilter{
 		nodeFilter,
 		&errorFilter,
 		&latencyFilter,
+		&outlierFilter,
 	}
 
+	// Group health does not depend on any request, so it is evaluated once per health check round.
+	backingStrategy.StartGroupHealthChecks(
+		chainConfig.Upstreams,
+		route.NewAndFilter(nodeFilters, logger),
+		healthCheckManager,
+		chainConfig.HealthCheck.GetInterval(),
+	)
+
 	if alwaysRoute {
 		routingStrategy = &route.AlwaysRouteFilteringStrategy{
 			NodeFilters: nodeFilters,
 			RemovableFilters: []route.NodeFilterType{
 				route.GetFilterTypeName(errorFilter),
 				route.GetFilterTypeName(latencyFilter),
+				route.GetFilterTypeName(outlierFilter),
 			},
-			BackingStrategy: backingStrategy,
-			Logger:          logger,
+			BackingStrategy:    backingStrategy,
+			Logger:             logger,
+			HealthCheckManager: healthCheckManager,
 		}
 	} else {
 		routingStrategy = &route.FilteringRoutingStrategy{
-			NodeFilter:      route.NewAndFilter(nodeFilters, logger),
-			BackingStrategy: backingStrategy,
-			Logger:          logger,
+			NodeFilter:         route.NewAndFilter(nodeFilters, logger),
+			BackingStrategy:    backingStrategy,
+			Logger:             logger,
+			HealthCheckManager: healthCheckManager,
 		}
 	}
 
-	rpcCache := cache.FromClients(chainConfig.Cache, redisReader, redisWriter, metricContainer)
+	var rpcCache *cache.RPCCache
+	if memoryCacheBackend != nil {
+		rpcCache = cache.FromBackend(chainConfig.Cache, memoryCacheBackend, metricContainer)
+	} else {
+		rpcCache = cache.FromClients(chainConfig.Cache, redisReader, redisWriter, metricContainer)
+	}
+
+	if rpcCache != nil {
+		rpcCache.UseChainMetadataStore(chainMetadataStore)
+	}
 
 	router := route.NewRouter(
 		chainConfig.ChainName,
 		chainConfig.Cache,
 		chainConfig.Upstreams,
 		chainConfig.Groups,
+		chainConfig.Routing.MaxHeadAge,
 		chainMetadataStore,
 		healthCheckManager,
 		routingStrategy,
@@ -123,6 +156,10 @@ func wireSingleChainDependencies(
 		rpcCache,
 	)
 
+	if chainConfig.Routing.Coalescing != nil {
+		router = route.NewCoalescingRouter(router, chainConfig.Routing.Coalescing, metricContainer, logger)
+	}
+
 	path := "/" + chainConfig.ChainName
 	handler := &RPCHandler{
 		path:   path,
@@ -143,10 +180,14 @@ func WireDependenciesForAllChains(
 	gatewayConfig config.Config, //nolint:gocritic // Legacy
 	rootLogger *zap.Logger,
 ) ObjectGraph {
-	readerAddr, writerAddr := gatewayConfig.Global.Cache.GetRedisAddresses()
+	redisReader := cache.CreateRedisReaderClient(&gatewayConfig.Global.Cache)
+	redisWriter := cache.CreateRedisWriterClient(&gatewayConfig.Global.Cache)
 
-	redisReader := cache.CreateRedisReaderClient(readerAddr)
-	redisWriter := cache.CreateRedisWriterClient(writerAddr)
+	// All chains share the memory cache, so that its bounds apply to the whole gateway.
+	var memoryCacheBackend *cache.MemoryBackend
+	if memoryCacheConfig := gatewayConfig.Global.Cache.Memory; memoryCacheConfig != nil {
+		memoryCacheBackend = cache.NewMemoryBackend(memoryCacheConfig.MaxEntries, memoryCacheConfig.MaxMemoryMB<<20)
+	}
 
 	singleChainDependencies := make([]singleChainObjectGraph, 0, len(gatewayConfig.Chains))
 	routers := make([]route.Router, 0, len(gatewayConfig.Chains))
@@ -161,6 +202,7 @@ func WireDependenciesForAllChains(
 			childLogger,
 			redisReader,
 			redisWriter,
+			memoryCacheBackend,
 		)
 
 		singleChainDependencies = append(singleChainDependencies, dependencyContainer)
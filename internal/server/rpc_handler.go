@@ -74,6 +74,11 @@ func (h *RPCHandler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
 		// Still pass the response to client if we're not able to decode response from upstream.
 		case *jsonrpc.DecodeError:
 			respondRaw(nil, writer, e.Content, http.StatusOK)
+			return
+		case *route.StaleResponseError:
+			writer.Header().Set("X-Cache-Stale", "true")
+			respondJSONRPC(h.logger, writer, e.ResponseBody, http.StatusOK)
+
 			return
 		case *route.NoHealthyUpstreamsError:
 			respondJSON(h.logger, writer, "No healthy upstreams.", http.StatusServiceUnavailable)
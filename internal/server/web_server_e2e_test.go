@@ -735,6 +735,9 @@ func handleSingleRequest(t *testing.T, request jsonrpc.SingleRequestBody,
 	case "eth_chainId":
 		return jsonrpc.SingleResponseBody{Result: getResultFromString(hexutil.Uint64(11).String())}
 
+	case "web3_clientVersion":
+		return jsonrpc.SingleResponseBody{Result: getResultFromString("Geth/v1.13.14-stable-2bd6bd01/linux-amd64/go1.21.7")}
+
 	case "eth_getBlockByNumber":
 		result, _ := json.Marshal(types.Header{
 			Number:     big.NewInt(latestBlockNumber),
@@ -773,7 +776,7 @@ func setUpUnhealthyUpstream(t *testing.T) *httptest.Server {
 		switch r := requestBody.(type) {
 		case *jsonrpc.SingleRequestBody:
 			switch requestBody.GetMethod() {
-			case "eth_syncing", "net_peerCount", "eth_chainId", "eth_getBlockByNumber":
+			case "eth_syncing", "net_peerCount", "eth_chainId", "eth_getBlockByNumber", "web3_clientVersion":
 				responseBody = &jsonrpc.SingleResponseBody{Error: &jsonrpc.Error{Message: "This is a failing fake node!"}}
 				writeResponseBody(t, writer, responseBody)
 			default:
@@ -16,6 +16,7 @@ import (
 
 	"github.com/satsuma-data/node-gateway/internal/jsonrpc"
 	"github.com/satsuma-data/node-gateway/internal/mocks"
+	"github.com/satsuma-data/node-gateway/internal/route"
 )
 
 func TestHandleJSONRPCRequest_Success(t *testing.T) {
@@ -168,3 +169,75 @@ func TestHandleJSONRPCRequest_JSONRPCDecodeError(t *testing.T) {
 	body, _ := io.ReadAll(result.Body)
 	assert.Equal(t, undecodableContent, body)
 }
+
+func TestHandleJSONRPCRequest_StaleResponse(t *testing.T) {
+	router := mocks.NewRouter(t)
+	staleRPCResponse := &jsonrpc.SingleResponseBody{
+		JSONRPC: jsonrpc.JSONRPCVersion,
+		Result:  json.RawMessage(`"stale"`),
+		ID:      2,
+	}
+
+	router.EXPECT().Route(mock.Anything, mock.Anything).
+		Return("", nil, &route.StaleResponseError{ResponseBody: staleRPCResponse})
+
+	handler := &RPCHandler{path: "/" + config.TestChainName, router: router, logger: zap.L()}
+
+	emptyJSONBody, _ := json.Marshal(map[string]any{})
+	req := httptest.NewRequest(http.MethodPost, "/"+config.TestChainName, bytes.NewReader(emptyJSONBody))
+	req.Header.Add("Content-Type", "application/json")
+
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, req)
+
+	result := recorder.Result()
+	resultBody, _ := io.ReadAll(result.Body)
+
+	defer result.Body.Close()
+
+	jsonRPCResponse, _ := jsonrpc.DecodeResponseBody(resultBody)
+
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, "true", result.Header.Get("X-Cache-Stale"))
+	assert.Equal(t, staleRPCResponse, jsonRPCResponse)
+}
+
+func TestHealthCheckHandler(t *testing.T) {
+	for _, tc := range []struct {
+		name               string
+		expectedBody       string
+		isInitialized      bool
+		isHeadStale        bool
+		expectedStatusCode int
+	}{
+		{"Starting up", "Starting up", false, false, http.StatusServiceUnavailable},
+		{"Healthy", "OK", true, false, http.StatusOK},
+		{"Stale head", "Stale head: " + config.TestChainName, true, true, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router := mocks.NewRouter(t)
+			router.EXPECT().IsInitialized().Return(tc.isInitialized)
+
+			if tc.isInitialized {
+				router.EXPECT().IsHeadStale().Return(tc.isHeadStale)
+			}
+
+			if tc.isHeadStale {
+				router.EXPECT().GetChainName().Return(config.TestChainName)
+			}
+
+			handler := &HealthCheckHandler{routerCollection: route.RouterCollection{Routers: []route.Router{router}}, logger: zap.L()}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", http.NoBody))
+
+			result := recorder.Result()
+			defer result.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, result.StatusCode)
+			body, _ := io.ReadAll(result.Body)
+			assert.Equal(t, tc.expectedBody, string(body))
+		})
+	}
+}
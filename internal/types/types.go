@@ -8,12 +8,19 @@ import (
 )
 
 type UpstreamStatus struct {
-	BlockHeightCheck BlockHeightChecker
-	PeerCheck        Checker
-	ErrorCheck       ErrorLatencyChecker
-	LatencyCheck     ErrorLatencyChecker
-	ID               string
-	GroupID          string
+	BlockHeightCheck  BlockHeightChecker
+	PeerCheck         Checker
+	ErrorCheck        ErrorLatencyChecker
+	LatencyCheck      ErrorLatencyChecker
+	LatencyProbeCheck Checker
+	ID                string
+	GroupID           string
+	// One check per synthetic probe configured on the upstream.
+	SyntheticProbeChecks []SyntheticProbeChecker
+	// Nil unless outlier detection is enabled.
+	OutlierCheck Checker
+	// Records the upstream's client software. Always passing.
+	ClientVersionCheck ClientVersionChecker
 }
 
 type RequestData struct {
@@ -22,6 +29,9 @@ type RequestData struct {
 	Method           string
 	HTTPResponseCode int
 	Latency          time.Duration
+	Cached           bool // Whether the response was served from the cache instead of the upstream.
+	// Used to attribute the responses of a batch to the methods of their subrequests. Nil for health check requests.
+	RequestBody jsonrpc.RequestBody
 }
 
 //go:generate mockery --output ../mocks --name BlockHeightChecker --with-expecter
@@ -38,9 +48,28 @@ type Checker interface {
 	IsPassing() bool
 }
 
+//go:generate mockery --output ../mocks --name SyntheticProbeChecker --with-expecter
+type SyntheticProbeChecker interface {
+	RunCheck()
+	GetError() error
+	IsPassing() bool
+	// Returns true iff any of the given methods is in the probe's method scope.
+	IsInScope(methods []string) bool
+}
+
+//go:generate mockery --output ../mocks --name ClientVersionChecker --with-expecter
+type ClientVersionChecker interface {
+	RunCheck()
+	IsPassing() bool
+	// Returns true iff the upstream's client is known and meets the requirement.
+	MeetsRequirement(requirement *config.ClientRequirementConfig) bool
+}
+
 //go:generate mockery --output ../mocks --name ErrorLatencyChecker --with-expecter
 type ErrorLatencyChecker interface {
 	IsPassing(methods []string) bool
+	// Randomly admits requests in proportion to the upstream's share of traffic while it recovers from a ban.
+	IsAdmitted(methods []string) bool
 	RecordRequest(data *RequestData) bool
 }
 
@@ -0,0 +1,41 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+var versionRegexp = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+// ParseVersion parses the major, minor and patch numbers at the start of a version such as "v1.13.14-stable". The minor
+// and patch numbers default to zero.
+func ParseVersion(version string) ([3]int, error) {
+	var parsed [3]int
+
+	match := versionRegexp.FindStringSubmatch(version)
+	if match == nil {
+		return parsed, fmt.Errorf("invalid version %q", version)
+	}
+
+	for i, number := range match[1:] {
+		if number == "" {
+			continue
+		}
+
+		value, err := strconv.Atoi(number)
+		if err != nil {
+			return parsed, fmt.Errorf("invalid version %q: %w", version, err)
+		}
+
+		parsed[i] = value
+	}
+
+	return parsed, nil
+}
+
+// CompareVersions returns -1, 0 or 1 if a is lower than, equal to or higher than b.
+func CompareVersions(a, b [3]int) int {
+	return slices.Compare(a[:], b[:])
+}